@@ -1,12 +1,14 @@
 package bagging
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"runtime"
 
 	"seehuhn.de/go/classification"
 	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
 )
 
 const baggingSeed = 1070630982
@@ -16,6 +18,43 @@ type RandomFactory interface {
 	FromDataRandom(d *data.Data, rng *rand.Rand) classification.Classifier
 }
 
+// CheckedRandomFactory is implemented by RandomFactory values which
+// can report invalid training data or parameters as errors, instead
+// of panicking.
+type CheckedRandomFactory interface {
+	RandomFactory
+	CheckedFromDataRandom(d *data.Data, rng *rand.Rand) (classification.Classifier, error)
+}
+
+// ContextRandomFactory is implemented by RandomFactory values which
+// support cancellation and progress reporting, as described for
+// classification.ContextFactory.
+type ContextRandomFactory interface {
+	RandomFactory
+	FromDataRandomContext(ctx context.Context, d *data.Data, rng *rand.Rand) (classification.Classifier, error)
+}
+
+// fromDataRandom calls the base factory, converting panics into errors
+// if the base factory does not implement CheckedRandomFactory.
+func fromDataRandom(ctx context.Context, f RandomFactory, d *data.Data, rng *rand.Rand) (c classification.Classifier, err error) {
+	if cf, ok := f.(ContextRandomFactory); ok {
+		return cf.FromDataRandomContext(ctx, d, rng)
+	}
+	if cf, ok := f.(CheckedRandomFactory); ok {
+		return cf.CheckedFromDataRandom(d, rng)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c = nil
+			err = &classification.FactoryError{
+				Factory: f.GetName(),
+				Err:     &classification.PanicError{Value: r},
+			}
+		}
+	}()
+	return f.FromDataRandom(d, rng), nil
+}
+
 type randomize struct {
 	base      classification.Factory
 	voterSize int
@@ -26,13 +65,35 @@ func (f randomize) GetName() string {
 }
 
 func (f randomize) FromDataRandom(d *data.Data, rng *rand.Rand) classification.Classifier {
+	c, err := f.CheckedFromDataRandom(d, rng)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (f randomize) CheckedFromDataRandom(d *data.Data, rng *rand.Rand) (classification.Classifier, error) {
+	return f.FromDataRandomContext(context.Background(), d, rng)
+}
+
+func (f randomize) FromDataRandomContext(ctx context.Context, d *data.Data, rng *rand.Rand) (classification.Classifier, error) {
 	voterSize := f.voterSize
 	if voterSize == 0 {
 		voterSize = d.NRow()
 	}
+	if voterSize < 0 {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("voter size %d: %w",
+				voterSize, classification.ErrInvalidParameter),
+		}
+	}
+	if d.NRow() == 0 {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: data.ErrNoSamples}
+	}
 
 	sample := d.SampleWithReplacement(voterSize, rng)
-	return f.base.FromData(sample)
+	return classification.FromDataContext(ctx, f.base, sample)
 }
 
 // New constructs a new `classification.Factory`, using the `base`
@@ -63,6 +124,34 @@ func (f *baggingFactory) GetName() string {
 }
 
 func (f *baggingFactory) FromData(data *data.Data) classification.Classifier {
+	c, err := f.CheckedFromData(data)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// CheckedFromData trains all voters.  If any of the voters cannot be
+// trained, the first error is returned.
+func (f *baggingFactory) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	return f.FromDataContext(context.Background(), d)
+}
+
+// FromDataContext trains all voters, in parallel.  Training stops
+// early if `ctx` is cancelled, and a TreeDone event is reported
+// whenever a voter has been trained.
+func (f *baggingFactory) FromDataContext(ctx context.Context, d *data.Data) (classification.Classifier, error) {
+	if f.NumVoters < 1 {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("%d voters: %w",
+				f.NumVoters, classification.ErrInvalidParameter),
+		}
+	}
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+
 	numWorkers := runtime.NumCPU()
 	jobs := make(chan int64, f.NumVoters)
 	for i := 0; i < f.NumVoters; i++ {
@@ -70,29 +159,68 @@ func (f *baggingFactory) FromData(data *data.Data) classification.Classifier {
 	}
 	close(jobs)
 
-	results := make(chan classification.Classifier)
+	type result struct {
+		c   classification.Classifier
+		err error
+	}
+	results := make(chan result)
 	for j := 0; j < numWorkers; j++ {
 		go func() {
 			for seed := range jobs {
+				if err := ctx.Err(); err != nil {
+					results <- result{nil, err}
+					continue
+				}
 				rng := rand.New(rand.NewSource(seed))
-				results <- f.Base.FromDataRandom(data, rng)
+				c, err := fromDataRandom(ctx, f.Base, d, rng)
+				results <- result{c, err}
 			}
 		}()
 	}
 
-	res := make(baggingClassifier, f.NumVoters)
-	for i := range res {
-		res[i] = <-results
+	voters := make([]classification.Classifier, f.NumVoters)
+	var firstErr error
+	for i := range voters {
+		r := <-results
+		voters[i] = r.c
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		classification.ReportProgress(ctx, classification.ProgressEvent{
+			Kind:   classification.TreeDone,
+			Source: f.GetName(),
+			Done:   i + 1,
+			Total:  f.NumVoters,
+		})
 	}
-	return res
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return &baggingClassifier{
+		info:   classification.NewInfo(d),
+		voters: voters,
+	}, nil
+}
+
+type baggingClassifier struct {
+	info   *classification.Info
+	voters []classification.Classifier
 }
 
-type baggingClassifier []classification.Classifier
+func (bag *baggingClassifier) GetInfo() *classification.Info {
+	return bag.info
+}
 
-func (bag baggingClassifier) EstimateClassProbabilities(x []float64) data.Histogram {
+func (bag *baggingClassifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := bag.info.CheckInput(x); err != nil {
+		panic(err)
+	}
 	var res data.Histogram
 	// TODO(voss): should averages take leaf size into account for trees?
-	for _, cfr := range bag {
+	for _, cfr := range bag.voters {
 		p := cfr.EstimateClassProbabilities(x)
 		if res == nil {
 			res = make(data.Histogram, len(p))
@@ -102,7 +230,71 @@ func (bag baggingClassifier) EstimateClassProbabilities(x []float64) data.Histog
 		}
 	}
 	for i := range res {
-		res[i] /= float64(len(bag))
+		res[i] /= float64(len(bag.voters))
 	}
 	return res
 }
+
+// EstimateClassProbabilitiesBatch stores the averaged class
+// probabilities of all voters for row i of `x` in row i of `prob`.
+// The voters are evaluated in parallel.
+func (bag *baggingClassifier) EstimateClassProbabilitiesBatch(x, prob *matrix.Float64) {
+	m, _ := x.Shape()
+	n, k := prob.Shape()
+	if m != n || bag.info != nil && k != bag.info.NumClasses {
+		panic("wrong shape for the result matrix")
+	}
+	if err := classification.CheckInputs(bag, x); err != nil {
+		panic(err)
+	}
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(bag.voters) {
+		numWorkers = len(bag.voters)
+	}
+	jobs := make(chan int, len(bag.voters))
+	for i := range bag.voters {
+		jobs <- i
+	}
+	close(jobs)
+
+	results := make(chan *matrix.Float64)
+	for j := 0; j < numWorkers; j++ {
+		go func() {
+			sum := matrix.NewFloat64(n, k, 0, nil)
+			tmp := matrix.NewFloat64(n, k, 0, nil)
+			for i := range jobs {
+				classification.Batch(bag.voters[i]).EstimateClassProbabilitiesBatch(x, tmp)
+				for r := 0; r < n; r++ {
+					sumRow := sum.Row(r)
+					for c, p := range tmp.Row(r) {
+						sumRow[c] += p
+					}
+				}
+			}
+			results <- sum
+		}()
+	}
+
+	for r := 0; r < n; r++ {
+		row := prob.Row(r)
+		for c := range row {
+			row[c] = 0
+		}
+	}
+	for j := 0; j < numWorkers; j++ {
+		sum := <-results
+		for r := 0; r < n; r++ {
+			row := prob.Row(r)
+			for c, p := range sum.Row(r) {
+				row[c] += p
+			}
+		}
+	}
+	for r := 0; r < n; r++ {
+		row := prob.Row(r)
+		for c := range row {
+			row[c] /= float64(len(bag.voters))
+		}
+	}
+}
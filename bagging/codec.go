@@ -0,0 +1,41 @@
+package bagging
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/model"
+)
+
+// maxVoters limits the number of voters accepted when decoding.
+const maxVoters = 1 << 20
+
+func init() {
+	model.Register(&baggingClassifier{}, &model.Codec{
+		Tag:     "bagging",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			bag := c.(*baggingClassifier)
+			w.Int(len(bag.voters))
+			for _, voter := range bag.voters {
+				w.Classifier(voter)
+			}
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			n := r.Len(maxVoters)
+			if n == 0 {
+				r.Fail(model.ErrEncoding)
+			}
+			voters := make([]classification.Classifier, 0, n)
+			for i := 0; i < n && r.Err() == nil; i++ {
+				voters = append(voters, r.Classifier())
+			}
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			return &baggingClassifier{
+				info:   info,
+				voters: voters,
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,194 @@
+// Package baseline implements trivial classifiers, which can be used
+// as reference points when assessing the performance of other
+// methods.
+//
+// Prior, Uniform and Stratified ignore the inputs and only use the
+// class frequencies of the training data.  Oracle uses the true class
+// probabilities of a synthetic data set and thus implements the Bayes
+// classifier.
+package baseline
+
+import (
+	"math/rand"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+const stratifiedSeed = 1938475527
+
+// Prior is a classification.Factory for classifiers which always
+// return the class frequencies of the training data.
+type Prior struct{}
+
+// GetName returns a human-readable name for the factory.
+func (f *Prior) GetName() string {
+	return "class prior"
+}
+
+// FromData computes the class frequencies of the training data.
+func (f *Prior) FromData(d *data.Data) classification.Classifier {
+	return &constant{
+		prob: classFrequencies(d),
+		info: classification.NewInfo(d),
+	}
+}
+
+// Uniform is a classification.Factory for classifiers which always
+// assign the same probability to every class.
+type Uniform struct{}
+
+// GetName returns a human-readable name for the factory.
+func (f *Uniform) GetName() string {
+	return "uniform"
+}
+
+// FromData returns a classifier which assigns probability 1/K to each
+// of the K classes.
+func (f *Uniform) FromData(d *data.Data) classification.Classifier {
+	prob := make(data.Histogram, d.NumClasses)
+	for k := range prob {
+		prob[k] = 1 / float64(d.NumClasses)
+	}
+	return &constant{
+		prob: prob,
+		info: classification.NewInfo(d),
+	}
+}
+
+// Stratified is a classification.Factory for classifiers which guess
+// a random class for every input, with the class frequencies of the
+// training data.  The returned class probabilities put all mass on the
+// guessed class.
+type Stratified struct {
+	// Seed is used to initialise the random number generator of the
+	// classifiers.  If this is zero, a fixed default seed is used.
+	Seed int64
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Stratified) GetName() string {
+	return "stratified random guessing"
+}
+
+// FromData computes the class frequencies of the training data.
+func (f *Stratified) FromData(d *data.Data) classification.Classifier {
+	seed := f.Seed
+	if seed == 0 {
+		seed = stratifiedSeed
+	}
+	return &stratified{
+		prob: classFrequencies(d),
+		rng:  rand.New(rand.NewSource(seed)),
+		info: classification.NewInfo(d),
+	}
+}
+
+type stratified struct {
+	prob data.Histogram
+
+	lock sync.Mutex
+	rng  *rand.Rand
+
+	info *classification.Info
+}
+
+func (c *stratified) GetInfo() *classification.Info {
+	return c.info
+}
+
+func (c *stratified) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	c.lock.Lock()
+	u := c.rng.Float64()
+	c.lock.Unlock()
+
+	res := make(data.Histogram, len(c.prob))
+	last := 0
+	for k, pk := range c.prob {
+		if pk <= 0 {
+			continue
+		}
+		last = k
+		if u < pk {
+			break
+		}
+		u -= pk
+	}
+	res[last] = 1
+	return res
+}
+
+// Oracle is a classification.Factory for the Bayes classifier of a
+// synthetic data set, where the true class probabilities are known.
+// The training data is ignored.
+type Oracle struct {
+	// Truth gives the true class probabilities.  Data sets which
+	// implement the data.Posterior interface can be used here
+	// directly.
+	Truth data.Posterior
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Oracle) GetName() string {
+	return "oracle"
+}
+
+// FromData returns the Bayes classifier.
+func (f *Oracle) FromData(d *data.Data) classification.Classifier {
+	return &oracle{
+		truth: f.Truth,
+		info:  classification.NewInfo(d),
+	}
+}
+
+type oracle struct {
+	truth data.Posterior
+	info  *classification.Info
+}
+
+func (c *oracle) GetInfo() *classification.Info {
+	return c.info
+}
+
+func (c *oracle) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	return c.truth.TrueClassProbabilities(x)
+}
+
+// constant is a classifier which returns the same probabilities for
+// every input.
+type constant struct {
+	prob data.Histogram
+	info *classification.Info
+}
+
+func (c *constant) GetInfo() *classification.Info {
+	return c.info
+}
+
+func (c *constant) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	res := make(data.Histogram, len(c.prob))
+	copy(res, c.prob)
+	return res
+}
+
+// classFrequencies returns the (weighted) class frequencies of `d`.
+// If `d` has no samples, all classes get the same probability.
+func classFrequencies(d *data.Data) data.Histogram {
+	hist := d.GetHist()
+	if hist.Sum() <= 0 {
+		for k := range hist {
+			hist[k] = 1
+		}
+	}
+	return hist.Probabilities()
+}
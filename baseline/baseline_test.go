@@ -0,0 +1,78 @@
+package baseline
+
+import (
+	"math"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestBaselines(c *C) {
+	d := data.NewEmpty(3, 1000, 1)
+	for i := range d.Y {
+		switch {
+		case i < 600:
+			d.Y[i] = 0
+		case i < 900:
+			d.Y[i] = 1
+		default:
+			d.Y[i] = 2
+		}
+	}
+	x := []float64{0}
+
+	p := (&Prior{}).FromData(d).EstimateClassProbabilities(x)
+	c.Check(p, DeepEquals, data.Histogram{0.6, 0.3, 0.1})
+
+	p = (&Uniform{}).FromData(d).EstimateClassProbabilities(x)
+	c.Check(p, DeepEquals, data.Histogram{1. / 3, 1. / 3, 1. / 3})
+
+	cf := (&Stratified{}).FromData(d)
+	counts := make(data.Histogram, 3)
+	n := 10000
+	for i := 0; i < n; i++ {
+		p := cf.EstimateClassProbabilities(x)
+		c.Assert(p.Sum(), Equals, 1.0)
+		counts[p.ArgMax()]++
+	}
+	for k, pk := range []float64{0.6, 0.3, 0.1} {
+		c.Check(math.Abs(counts[k]/float64(n)-pk) < 0.02, Equals, true,
+			Commentf("class %d: %g", k, counts[k]/float64(n)))
+	}
+}
+
+func (*Tests) TestOracle(c *C) {
+	delta := 2.0
+	set := data.NewNormals(delta, 100, 20000)
+	truth, ok := set.(data.Posterior)
+	c.Assert(ok, Equals, true)
+
+	res := classification.Assess(&Oracle{Truth: truth}, set, loss.ZeroOne)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.HasBayes, Equals, true)
+	c.Check(res.ExcessLoss, Equals, 0.0)
+	c.Check(res.ExcessStdErr, Equals, 0.0)
+	// The Bayes error rate is Phi(-delta/2).
+	bayes := 0.5 * math.Erfc(delta/2/math.Sqrt2)
+	c.Check(math.Abs(res.BayesLoss-bayes) < 3*res.StdErr, Equals, true,
+		Commentf("%g vs %g", res.BayesLoss, bayes))
+
+	res = classification.Assess(&Prior{}, set, loss.ZeroOne)
+	c.Check(res.HasBayes, Equals, true)
+	c.Check(math.Abs(res.ExcessLoss-(0.5-bayes)) < 0.02, Equals, true,
+		Commentf("excess loss %g", res.ExcessLoss))
+	c.Check(res.ExcessStdErr > 0, Equals, true)
+
+	res = classification.Assess(&Prior{}, data.Digits, loss.ZeroOne)
+	c.Check(res.HasBayes, Equals, false)
+}
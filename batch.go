@@ -0,0 +1,73 @@
+package classification
+
+import (
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// BatchClassifier is implemented by classifiers which can estimate the
+// class probabilities for many inputs at once.
+type BatchClassifier interface {
+	Classifier
+
+	// EstimateClassProbabilitiesBatch stores the estimated class
+	// probabilities for row i of `x` in row i of `prob`.  The matrix
+	// `prob` must have the same number of rows as `x` and one column
+	// per class.  Implementations may process the rows in parallel.
+	EstimateClassProbabilitiesBatch(x, prob *matrix.Float64)
+}
+
+// Batch converts a Classifier into a BatchClassifier.  If `c` already
+// implements BatchClassifier, it is returned unchanged.  Otherwise the
+// returned classifier calls c.EstimateClassProbabilities once for
+// every row.  Since classifiers are not required to be safe for
+// concurrent use, the rows are processed sequentially in this case.
+func Batch(c Classifier) BatchClassifier {
+	if bc, ok := c.(BatchClassifier); ok {
+		return bc
+	}
+	return batch{c}
+}
+
+type batch struct {
+	Classifier
+}
+
+func (c batch) EstimateClassProbabilitiesBatch(x, prob *matrix.Float64) {
+	n, _ := x.Shape()
+	m, k := prob.Shape()
+	if m != n {
+		panic("wrong number of rows for the result matrix")
+	}
+	if err := CheckInputs(c.Classifier, x); err != nil {
+		panic(err)
+	}
+	for i := 0; i < n; i++ {
+		p := c.EstimateClassProbabilities(x.Row(i))
+		if len(p) != k {
+			panic("wrong number of columns for the result matrix")
+		}
+		copy(prob.Row(i), p)
+	}
+}
+
+// estimateAll returns the estimated class probabilities for all
+// samples in `d`, as a matrix with one row per sample in the order
+// given by d.GetRows().  An error is returned if the classifier
+// expects a different number of inputs.
+func estimateAll(c Classifier, d *data.Data) (*matrix.Float64, error) {
+	if err := CheckInputs(c, d.X); err != nil {
+		return nil, err
+	}
+	x := d.X
+	if d.Rows != nil {
+		p := d.NCol()
+		x = matrix.NewFloat64(len(d.Rows), p, 0, nil)
+		for k, i := range d.Rows {
+			copy(x.Row(k), d.X.Row(i))
+		}
+	}
+	prob := matrix.NewFloat64(d.NRow(), d.NumClasses, 0, nil)
+	Batch(c).EstimateClassProbabilitiesBatch(x, prob)
+	return prob, nil
+}
@@ -0,0 +1,374 @@
+// Package boosting implements the SAMME and SAMME.R algorithms for
+// multiclass boosting of classification trees.
+//
+// The algorithms are described in the article "Multi-class AdaBoost"
+// by Zhu, Rosset, Zou and Hastie (Statistics and Its Interface, 2009).
+package boosting
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/tree"
+	"seehuhn.de/go/classification/tree/stop"
+)
+
+// Algorithm selects the variant of the boosting algorithm.
+type Algorithm int
+
+const (
+	// SAMME combines the class predictions of the weak learners,
+	// using weighted votes.
+	SAMME Algorithm = iota + 1
+
+	// SAMMER (SAMME.R) combines the class probabilities estimated by
+	// the weak learners.
+	SAMMER
+)
+
+func (alg Algorithm) String() string {
+	switch alg {
+	case SAMME:
+		return "SAMME"
+	case SAMMER:
+		return "SAMME.R"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", int(alg))
+	}
+}
+
+// probEpsilon is the smallest class probability used by SAMME.R, to
+// avoid taking the logarithm of zero.
+const probEpsilon = 1e-10
+
+// Factory stores the parameters for boosting classification trees.
+// Any zero field values are interpreted as the corresponding values
+// from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Algorithm selects between SAMME and SAMME.R.
+	Algorithm Algorithm
+
+	// Rounds gives the maximal number of weak learners to use.
+	Rounds int
+
+	// LearningRate is used to shrink the contribution of each weak
+	// learner.
+	LearningRate float64
+
+	// Tree is used to grow the weak learners.  The trees are grown
+	// using `tree.Factory.GrowTree`, i.e. they are not pruned, and
+	// should normally have a `MaxDepth` set.  The training data
+	// passed to the tree factory carries the current boosting
+	// weights.
+	Tree *tree.Factory
+}
+
+// DefaultFactory specifies the default parameters for boosting.  The
+// default uses SAMME.R with 100 decision stumps as weak learners.
+var DefaultFactory = &Factory{
+	Name:         "SAMME.R",
+	Algorithm:    SAMMER,
+	Rounds:       100,
+	LearningRate: 1,
+	Tree: &tree.Factory{
+		StopGrowth: stop.IfPure,
+		MaxDepth:   1,
+	},
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	return fmt.Sprintf("%s, %d rounds", f.Algorithm, f.Rounds)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.Algorithm == 0 {
+		res.Algorithm = DefaultFactory.Algorithm
+	}
+	if res.Rounds == 0 {
+		res.Rounds = DefaultFactory.Rounds
+	}
+	if res.LearningRate == 0 {
+		res.LearningRate = DefaultFactory.LearningRate
+	}
+	if res.Tree == nil {
+		res.Tree = DefaultFactory.Tree
+	}
+	return &res
+}
+
+// FromData constructs a new boosted classifier from training data.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.BoostFromData(d)
+}
+
+// BoostFromData constructs a new boosted classifier from training
+// data.  Boosting stops early, if a weak learner classifies the
+// (weighted) training data perfectly, or if a weak learner is no
+// better than random guessing.
+func (f *Factory) BoostFromData(d *data.Data) *Classifier {
+	f = f.setDefaults()
+
+	K := d.NumClasses
+	res := &Classifier{
+		Algorithm:  f.Algorithm,
+		NumClasses: K,
+		Info:       classification.NewInfo(d),
+	}
+	if K < 2 {
+		return res
+	}
+
+	rows := d.GetRows()
+	n := float64(len(rows))
+
+	// The boosting weights are stored in a new slice, indexed by the
+	// rows of d.X like the original weights.
+	w := make([]float64, len(d.Y))
+	total := 0.0
+	for _, row := range rows {
+		wi := d.Weight(row)
+		w[row] = wi
+		total += wi
+	}
+	weighted := *d // make a shallow copy
+	weighted.Weights = w
+
+	logK1 := math.Log(float64(K - 1))
+	for m := 0; m < f.Rounds; m++ {
+		// Normalise the weights to sum to n, so that stop functions
+		// based on node sizes keep working.
+		for _, row := range rows {
+			w[row] *= n / total
+		}
+
+		t := f.Tree.GrowTree(&weighted)
+
+		if f.Algorithm == SAMME {
+			errSum := 0.0
+			for _, row := range rows {
+				if t.GuessClass(d.X.Row(row)) != d.Y[row] {
+					errSum += w[row]
+				}
+			}
+			err := errSum / n
+			if err <= 0 {
+				// perfect fit, no need to continue
+				res.Trees = append(res.Trees, t)
+				res.Alpha = append(res.Alpha, f.LearningRate)
+				break
+			}
+			if err >= 1-1/float64(K) {
+				// no better than random guessing
+				break
+			}
+			alpha := f.LearningRate * (math.Log((1-err)/err) + logK1)
+			res.Trees = append(res.Trees, t)
+			res.Alpha = append(res.Alpha, alpha)
+
+			total = 0
+			for _, row := range rows {
+				if t.GuessClass(d.X.Row(row)) != d.Y[row] {
+					w[row] *= math.Exp(alpha)
+				}
+				total += w[row]
+			}
+		} else {
+			res.Trees = append(res.Trees, t)
+			res.Alpha = append(res.Alpha, f.LearningRate)
+
+			// With the symmetric class coding y_k = 1 for the
+			// correct class and y_k = -1/(K-1) otherwise, the
+			// update factor is exp(-lr*(K-1)/K * sum_k y_k log p_k).
+			kk := float64(K)
+			total = 0
+			for _, row := range rows {
+				logP := logProbabilities(t, d.X.Row(row))
+				yi := d.Y[row]
+				s := 0.0
+				for k, lp := range logP {
+					if k == yi {
+						s += lp
+					} else {
+						s -= lp / (kk - 1)
+					}
+				}
+				w[row] *= math.Exp(-f.LearningRate * (kk - 1) / kk * s)
+				total += w[row]
+			}
+		}
+		if total <= 0 || math.IsInf(total, 0) || math.IsNaN(total) {
+			break
+		}
+	}
+
+	return res
+}
+
+// Classifier is the result of boosting classification trees.
+type Classifier struct {
+	// Algorithm indicates how the weak learners are combined.
+	Algorithm Algorithm
+
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Trees lists the weak learners, in the order they were
+	// constructed.
+	Trees []*tree.Tree
+
+	// Alpha gives the weight of each weak learner.
+	Alpha []float64
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// Rounds returns the number of weak learners in the classifier.
+func (c *Classifier) Rounds() int {
+	return len(c.Trees)
+}
+
+// Truncate returns a new classifier which uses only the first `m`
+// weak learners of `c`.  This can be used to select the number of
+// boosting rounds, for example using a validation set.
+func (c *Classifier) Truncate(m int) *Classifier {
+	if m > len(c.Trees) {
+		m = len(c.Trees)
+	}
+	return &Classifier{
+		Algorithm:  c.Algorithm,
+		NumClasses: c.NumClasses,
+		Trees:      c.Trees[:m],
+		Alpha:      c.Alpha[:m],
+		Info:       c.Info,
+	}
+}
+
+// addDecision adds the contribution of weak learner `m` for input `x`
+// to the decision function `F`.
+func (c *Classifier) addDecision(F []float64, m int, x []float64) {
+	t := c.Trees[m]
+	alpha := c.Alpha[m]
+	if c.Algorithm == SAMME {
+		F[t.GuessClass(x)] += alpha
+		return
+	}
+
+	logP := logProbabilities(t, x)
+	mean := 0.0
+	for _, lp := range logP {
+		mean += lp
+	}
+	mean /= float64(len(logP))
+	K1 := float64(c.NumClasses - 1)
+	for k, lp := range logP {
+		F[k] += alpha * K1 * (lp - mean)
+	}
+}
+
+// probabilities converts the decision function `F` into class
+// probabilities.
+func (c *Classifier) probabilities(F []float64) data.Histogram {
+	scale := 1.0
+	if c.NumClasses > 1 {
+		scale = 1 / float64(c.NumClasses-1)
+	}
+	max := math.Inf(-1)
+	for _, Fk := range F {
+		if Fk > max {
+			max = Fk
+		}
+	}
+	prob := make(data.Histogram, len(F))
+	total := 0.0
+	for k, Fk := range F {
+		prob[k] = math.Exp((Fk - max) * scale)
+		total += prob[k]
+	}
+	for k := range prob {
+		prob[k] /= total
+	}
+	return prob
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	F := make([]float64, c.NumClasses)
+	for m := range c.Trees {
+		c.addDecision(F, m, x)
+	}
+	return c.probabilities(F)
+}
+
+// StagedClassProbabilities returns the estimated class probabilities
+// for input `x` after each boosting round.  Element m-1 of the result
+// gives the estimate obtained using the first m weak learners.
+func (c *Classifier) StagedClassProbabilities(x []float64) []data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	res := make([]data.Histogram, len(c.Trees))
+	F := make([]float64, c.NumClasses)
+	for m := range c.Trees {
+		c.addDecision(F, m, x)
+		res[m] = c.probabilities(F)
+	}
+	return res
+}
+
+// StagedLoss returns the average loss on the data set `d` after each
+// boosting round.  Element m-1 of the result gives the loss obtained
+// using the first m weak learners.
+func (c *Classifier) StagedLoss(d *data.Data, L loss.Function) []float64 {
+	res := make([]float64, len(c.Trees))
+	rows := d.GetRows()
+	total := 0.0
+	for _, row := range rows {
+		wi := d.Weight(row)
+		for m, prob := range c.StagedClassProbabilities(d.X.Row(row)) {
+			res[m] += wi * L(d.Y[row], prob)
+		}
+		total += wi
+	}
+	for m := range res {
+		res[m] /= total
+	}
+	return res
+}
+
+// logProbabilities returns the logarithms of the class probabilities
+// estimated by the tree `t`, after clipping small values.
+func logProbabilities(t *tree.Tree, x []float64) []float64 {
+	prob := t.EstimateClassProbabilities(x)
+	res := make([]float64, len(prob))
+	for k, p := range prob {
+		if !(p >= probEpsilon) {
+			p = probEpsilon
+		}
+		res[k] = math.Log(p)
+	}
+	return res
+}
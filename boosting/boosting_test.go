@@ -0,0 +1,64 @@
+package boosting
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestBoosting(c *C) {
+	train := data.NewDiagonal(400, 1)
+	test := data.NewDiagonal(400, 2)
+	for _, alg := range []Algorithm{SAMME, SAMMER} {
+		f := &Factory{
+			Algorithm: alg,
+			Rounds:    60,
+		}
+		cfr := f.BoostFromData(train)
+		c.Assert(cfr.Rounds() > 1, Equals, true)
+
+		staged := cfr.StagedLoss(test, loss.ZeroOne)
+		c.Check(staged, HasLen, cfr.Rounds())
+		first := staged[0]
+		last := staged[len(staged)-1]
+		c.Check(last < first, Equals, true, Commentf("%s: %g >= %g", alg, last, first))
+		c.Check(last < 0.3, Equals, true, Commentf("%s: loss %g", alg, last))
+
+		x := test.X.Row(0)
+		prob := cfr.EstimateClassProbabilities(x)
+		c.Check(prob.Sum() > 0.999 && prob.Sum() < 1.001, Equals, true)
+		stagedProb := cfr.StagedClassProbabilities(x)
+		c.Check(stagedProb[len(stagedProb)-1], DeepEquals, prob)
+		c.Check(cfr.Truncate(1).EstimateClassProbabilities(x), DeepEquals, stagedProb[0])
+	}
+}
+
+func (*Tests) TestWeights(c *C) {
+	// Samples with zero weight must not influence the result.
+	X := matrix.NewFloat64(6, 1, 0, []float64{0, 1, 2, 3, 4, 5})
+	d := &data.Data{
+		NumClasses: 2,
+		X:          X,
+		Y:          []int{0, 0, 1, 0, 1, 1},
+		Weights:    []float64{1, 1, 0, 0, 1, 1},
+	}
+	f := &Factory{
+		Algorithm: SAMME,
+		Rounds:    5,
+	}
+	cfr := f.BoostFromData(d)
+	for _, x := range []float64{0, 1, 4, 5} {
+		prob := cfr.EstimateClassProbabilities([]float64{x})
+		c.Check(prob.ArgMax(), Equals, d.Y[int(x)])
+	}
+}
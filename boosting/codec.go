@@ -0,0 +1,52 @@
+package boosting
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/model"
+	"seehuhn.de/go/classification/tree"
+)
+
+// maxTrees limits the number of weak learners accepted when decoding.
+const maxTrees = 1 << 20
+
+func init() {
+	model.Register(&Classifier{}, &model.Codec{
+		Tag:     "boosting",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			b := c.(*Classifier)
+			w.Int(int(b.Algorithm))
+			w.Int(b.NumClasses)
+			w.Int(len(b.Trees))
+			for _, t := range b.Trees {
+				w.Classifier(t)
+			}
+			w.Float64s(b.Alpha)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &Classifier{
+				Algorithm:  Algorithm(r.Int()),
+				NumClasses: r.Int(),
+				Info:       info,
+			}
+			n := r.Len(maxTrees)
+			for i := 0; i < n && r.Err() == nil; i++ {
+				t, ok := r.Classifier().(*tree.Tree)
+				if !ok {
+					r.Fail(model.ErrEncoding)
+				}
+				res.Trees = append(res.Trees, t)
+			}
+			res.Alpha = r.Float64s()
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			if res.Algorithm != SAMME && res.Algorithm != SAMMER ||
+				res.NumClasses < 1 || len(res.Alpha) != n {
+				return nil, model.ErrEncoding
+			}
+			return res, nil
+		},
+	})
+}
@@ -0,0 +1,216 @@
+package classification
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+const bootstrapSeed = 1906276581
+
+// BootstrapEstimator selects the estimate of the expected loss
+// computed by AssessBootstrap.
+type BootstrapEstimator int
+
+const (
+	// OutOfBootstrap averages, for every sample, the losses of the
+	// classifiers trained on bootstrap samples which do not contain
+	// this sample.  This is the "leave-one-out bootstrap" estimate
+	// Err^(1) of Efron and Tibshirani.  Since every bootstrap sample
+	// only contains about 63.2% of the distinct samples, the estimate
+	// is biased upwards.
+	OutOfBootstrap BootstrapEstimator = iota + 1
+
+	// Bootstrap632 is Efron's .632 estimate, which combines the
+	// out-of-bootstrap loss with the training loss of the classifier
+	// trained on the full data, to correct for the upward bias:
+	// 0.368*training + 0.632*out-of-bootstrap.
+	Bootstrap632
+
+	// Bootstrap632Plus is the .632+ estimate by Efron and Tibshirani
+	// (1997), which gives more weight to the out-of-bootstrap loss
+	// when the classifier overfits, as measured by the relative
+	// overfitting rate with respect to the no-information loss.
+	Bootstrap632Plus
+)
+
+func (e BootstrapEstimator) String() string {
+	switch e {
+	case OutOfBootstrap:
+		return "out-of-bootstrap"
+	case Bootstrap632:
+		return ".632"
+	case Bootstrap632Plus:
+		return ".632+"
+	default:
+		return fmt.Sprintf("BootstrapEstimator(%d)", int(e))
+	}
+}
+
+// AssessBootstrap estimates the quality of a classifier using `B`
+// bootstrap samples drawn from `samples`.  A classifier is trained on
+// every bootstrap sample and is tested on the samples not contained
+// in the bootstrap sample.  The estimator `est` determines how these
+// losses are combined into an estimate of the expected loss.
+//
+// The standard error is computed from the per-sample estimates,
+// treating these as independent.  The Report and Predictions fields
+// of the result are not set.
+func AssessBootstrap(cf Factory, samples *data.Data, L loss.Function, B int, est BootstrapEstimator) *Result {
+	res := &Result{}
+	if B < 1 {
+		res.Err = errors.New("need at least one bootstrap sample")
+		return res
+	}
+	switch est {
+	case OutOfBootstrap, Bootstrap632, Bootstrap632Plus:
+		// pass
+	default:
+		res.Err = errors.New("unknown bootstrap estimator " + est.String())
+		return res
+	}
+
+	rows := samples.GetRows()
+	n := len(rows)
+	if n < 2 {
+		res.Err = errors.New("not enough samples for the bootstrap")
+		return res
+	}
+
+	// out-of-bootstrap losses, for every position in rows
+	oobSum := make([]float64, n)
+	oobCount := make([]int, n)
+
+	rng := rand.New(rand.NewSource(bootstrapSeed))
+	inBag := make([]bool, len(samples.Y))
+	for b := 0; b < B; b++ {
+		boot := samples.SampleWithReplacement(n, rng)
+		for i := range inBag {
+			inBag[i] = false
+		}
+		for _, row := range boot.Rows {
+			inBag[row] = true
+		}
+
+		start := time.Now()
+		c, err := Checked(cf).CheckedFromData(boot)
+		res.TrainingTime += time.Since(start)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+
+		start = time.Now()
+		for i, row := range rows {
+			if inBag[row] {
+				continue
+			}
+			prob := c.EstimateClassProbabilities(samples.X.Row(row))
+			oobSum[i] += L(samples.Y[row], prob)
+			oobCount[i]++
+		}
+		res.TestTime += time.Since(start)
+	}
+
+	// The per-sample values used to compute the estimate.  Samples
+	// which were contained in every bootstrap sample are ignored.
+	var oob, apparent []float64
+	var errOOB float64
+	for i := range rows {
+		if oobCount[i] == 0 {
+			continue
+		}
+		e := oobSum[i] / float64(oobCount[i])
+		oob = append(oob, e)
+		apparent = append(apparent, math.NaN())
+		errOOB += e
+	}
+	m := len(oob)
+	if m < 2 {
+		res.Err = errors.New("not enough out-of-bootstrap samples")
+		return res
+	}
+	errOOB /= float64(m)
+
+	if est == OutOfBootstrap {
+		res.MeanLoss = errOOB
+		res.StdErr = perSampleStdErr(oob)
+		return res
+	}
+
+	// training loss and no-information loss of the classifier trained
+	// on the full data
+	start := time.Now()
+	c, err := Checked(cf).CheckedFromData(samples)
+	res.TrainingTime += time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	start = time.Now()
+	classFreq := make([]float64, samples.NumClasses)
+	for _, row := range rows {
+		classFreq[samples.Y[row]] += 1 / float64(n)
+	}
+	errTrain := 0.0
+	noInfo := 0.0
+	j := 0
+	for i, row := range rows {
+		prob := c.EstimateClassProbabilities(samples.X.Row(row))
+		l := L(samples.Y[row], prob)
+		errTrain += l
+		for k, pk := range classFreq {
+			if pk > 0 {
+				noInfo += pk * L(k, prob)
+			}
+		}
+		if oobCount[i] > 0 {
+			apparent[j] = l
+			j++
+		}
+	}
+	errTrain /= float64(n)
+	noInfo /= float64(n)
+	res.TestTime += time.Since(start)
+
+	// weight of the out-of-bootstrap loss
+	w := 0.632
+	if est == Bootstrap632Plus {
+		errOOB = math.Min(errOOB, noInfo)
+		R := 0.0
+		if errOOB > errTrain && noInfo > errTrain {
+			R = (errOOB - errTrain) / (noInfo - errTrain)
+		}
+		w = 0.632 / (1 - 0.368*R)
+	}
+	res.MeanLoss = (1-w)*errTrain + w*errOOB
+
+	combined := make([]float64, m)
+	for i := range combined {
+		combined[i] = (1-w)*apparent[i] + w*oob[i]
+	}
+	res.StdErr = perSampleStdErr(combined)
+
+	return res
+}
+
+// perSampleStdErr returns the standard error of the mean of x.
+func perSampleStdErr(x []float64) float64 {
+	n := float64(len(x))
+	mean := 0.0
+	for _, xi := range x {
+		mean += xi
+	}
+	mean /= n
+	ss := 0.0
+	for _, xi := range x {
+		ss += (xi - mean) * (xi - mean)
+	}
+	return math.Sqrt(ss / (n - 1) / n)
+}
@@ -0,0 +1,327 @@
+// Package calibrate implements methods to improve the class
+// probabilities estimated by a classifier.
+//
+// Many classifiers rank samples well but return badly calibrated
+// probabilities; for example, the leaf frequencies of decision trees
+// are often too extreme.  The Factory in this package wraps any
+// classification.Factory and learns a map from the estimated to
+// calibrated probabilities, using probabilities for held-out data.
+package calibrate
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+const calibrateSeed = 1251788324
+
+// Method specifies how probabilities are calibrated.
+type Method int
+
+const (
+	// Platt fits a logistic function to the log-odds of every class,
+	// against all other classes.
+	Platt Method = iota + 1
+
+	// Isotonic fits a non-decreasing function to the probabilities of
+	// every class, against all other classes.  This is more flexible
+	// than Platt scaling but needs more data.
+	Isotonic
+
+	// Temperature divides the log-probabilities by a single, fitted
+	// constant before normalising.  This preserves the ranking of the
+	// classes.
+	Temperature
+
+	// Dirichlet fits a multinomial logistic regression model with the
+	// log-probabilities as inputs, as described in "Beyond Temperature
+	// Scaling: Obtaining Well-Calibrated Multi-Class Probabilities with
+	// Dirichlet Calibration" by Kull et al. (2019).
+	Dirichlet
+)
+
+func (m Method) String() string {
+	switch m {
+	case Platt:
+		return "Platt"
+	case Isotonic:
+		return "isotonic"
+	case Temperature:
+		return "temperature"
+	case Dirichlet:
+		return "Dirichlet"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// Factory stores the parameters for calibrating a classifier.  Any
+// zero field values, other than `Base`, are interpreted as the
+// corresponding values from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Base is the factory for the classifier to be calibrated.
+	Base classification.Factory
+
+	// Method specifies the calibration method.
+	Method Method
+
+	// Folds gives the number of cross-validation folds used to obtain
+	// probabilities for held-out data.  The returned classifier uses a
+	// base classifier trained on the full training data.  Folds must
+	// be at least 2, and the training data must have at least Folds
+	// samples.  Folds is ignored if HoldoutFraction is positive.
+	Folds int
+
+	// HoldoutFraction, if positive, gives the fraction of the training
+	// data which is held out to fit the calibration map.  In this
+	// case, the returned classifier uses a base classifier trained on
+	// the remaining data only.  HoldoutFraction must be smaller than
+	// 1, and both parts of the data must be non-empty.
+	HoldoutFraction float64
+
+	// Seed is used to split the data into folds or into the holdout
+	// set.
+	Seed int64
+}
+
+// DefaultFactory specifies the default parameters for calibration.
+var DefaultFactory = &Factory{
+	Method: Platt,
+	Folds:  5,
+	Seed:   calibrateSeed,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	return fmt.Sprintf("%s, %s calibration", f.Base.GetName(), f.Method)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.Method == 0 {
+		res.Method = DefaultFactory.Method
+	}
+	if res.Folds == 0 {
+		res.Folds = DefaultFactory.Folds
+	}
+	if res.Seed == 0 {
+		res.Seed = DefaultFactory.Seed
+	}
+	return &res
+}
+
+// FromData trains the base classifier and the calibration map.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// CheckedFromData trains the base classifier and the calibration map.
+// This implements the classification.CheckedFactory interface.
+func (f *Factory) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClassifierFromData trains the base classifier and the calibration
+// map.  The method panics if the classifier cannot be constructed; use
+// CheckedFromData to get an error instead.
+func (f *Factory) ClassifierFromData(d *data.Data) *Classifier {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (f *Factory) checkedClassifierFromData(d *data.Data) (*Classifier, error) {
+	f = f.setDefaults()
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	if f.Method < Platt || f.Method > Dirichlet {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("unknown calibration method %s: %w",
+				f.Method, classification.ErrInvalidParameter),
+		}
+	}
+	checkedBase := classification.Checked(f.Base)
+
+	var base classification.Classifier
+	var held *heldOut
+	if f.HoldoutFraction > 0 {
+		if f.HoldoutFraction >= 1 {
+			return nil, &classification.FactoryError{
+				Factory: f.GetName(),
+				Err: fmt.Errorf("HoldoutFraction=%g: %w",
+					f.HoldoutFraction, classification.ErrInvalidParameter),
+			}
+		}
+		rng := rand.New(rand.NewSource(f.Seed))
+		rows := append([]int(nil), d.GetRows()...)
+		rng.Shuffle(len(rows), func(i, j int) {
+			rows[i], rows[j] = rows[j], rows[i]
+		})
+		nHold := int(f.HoldoutFraction * float64(len(rows)))
+		if nHold < 1 || nHold >= len(rows) {
+			return nil, &classification.FactoryError{
+				Factory: f.GetName(),
+				Err: fmt.Errorf("%d samples for HoldoutFraction=%g: %w",
+					len(rows), f.HoldoutFraction, data.ErrTooFewSamples),
+			}
+		}
+		train := *d // make a shallow copy
+		train.Rows = rows[nHold:]
+		hold := *d
+		hold.Rows = rows[:nHold]
+		var err error
+		base, err = checkedBase.CheckedFromData(&train)
+		if err != nil {
+			return nil, err
+		}
+		held = newHeldOut(d.NumClasses)
+		held.add(base, &hold)
+	} else {
+		if f.Folds < 2 {
+			return nil, &classification.FactoryError{
+				Factory: f.GetName(),
+				Err: fmt.Errorf("Folds=%d: %w",
+					f.Folds, classification.ErrInvalidParameter),
+			}
+		}
+		if n := d.NRow(); n < f.Folds {
+			return nil, &classification.FactoryError{
+				Factory: f.GetName(),
+				Err: fmt.Errorf("%d samples for %d folds: %w",
+					n, f.Folds, data.ErrTooFewSamples),
+			}
+		}
+
+		// The folds and the final base classifier are trained in
+		// parallel.
+		parts := make([]*heldOut, f.Folds)
+		errs := make([]error, f.Folds)
+		var wg sync.WaitGroup
+		for k := 0; k < f.Folds; k++ {
+			wg.Add(1)
+			go func(k int) {
+				defer wg.Done()
+				train, test, err := d.XValSplit(f.Seed, f.Folds, k)
+				if err != nil {
+					errs[k] = err
+					return
+				}
+				c, err := checkedBase.CheckedFromData(train)
+				if err != nil {
+					errs[k] = err
+					return
+				}
+				parts[k] = newHeldOut(d.NumClasses)
+				parts[k].add(c, test)
+			}(k)
+		}
+		var err error
+		base, err = checkedBase.CheckedFromData(d)
+		wg.Wait()
+		if err != nil {
+			return nil, err
+		}
+		held = newHeldOut(d.NumClasses)
+		for k, part := range parts {
+			if errs[k] != nil {
+				return nil, errs[k]
+			}
+			held.prob = append(held.prob, part.prob...)
+			held.y = append(held.y, part.y...)
+			held.w = append(held.w, part.w...)
+		}
+	}
+
+	var cal calibrator
+	switch f.Method {
+	case Platt:
+		cal = fitPlattCalibrator(held)
+	case Isotonic:
+		cal = fitIsotonicCalibrator(held)
+	case Temperature:
+		cal = fitTemperature(held)
+	case Dirichlet:
+		cal = fitDirichlet(held)
+	}
+
+	return &Classifier{
+		Base:   base,
+		Method: f.Method,
+		cal:    cal,
+		info:   classification.NewInfo(d),
+	}, nil
+}
+
+// heldOut stores the estimated class probabilities for held-out
+// samples.
+type heldOut struct {
+	numClasses int
+	prob       []data.Histogram
+	y          []int
+	w          []float64
+}
+
+func newHeldOut(numClasses int) *heldOut {
+	return &heldOut{numClasses: numClasses}
+}
+
+func (h *heldOut) add(c classification.Classifier, d *data.Data) {
+	for _, row := range d.GetRows() {
+		h.prob = append(h.prob, c.EstimateClassProbabilities(d.X.Row(row)))
+		h.y = append(h.y, d.Y[row])
+		w := d.Weight(row)
+		h.w = append(h.w, w)
+	}
+}
+
+// calibrator maps estimated class probabilities to calibrated ones.
+type calibrator interface {
+	apply(prob data.Histogram) data.Histogram
+}
+
+// Classifier is a classifier with calibrated class probabilities.
+type Classifier struct {
+	// Base is the underlying, uncalibrated classifier.
+	Base classification.Classifier
+
+	// Method is the calibration method used.
+	Method Method
+
+	cal  calibrator
+	info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.info
+}
+
+// EstimateClassProbabilities returns the calibrated class
+// probabilities for input `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	return c.cal.apply(c.Base.EstimateClassProbabilities(x))
+}
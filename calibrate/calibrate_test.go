@@ -0,0 +1,211 @@
+package calibrate
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// sharpen is a factory for classifiers which return the probabilities
+// of the base classifier raised to the given power and renormalised.
+// For power > 1, this leads to overconfident classifiers.
+type sharpen struct {
+	base  classification.Factory
+	power float64
+}
+
+func (f *sharpen) GetName() string {
+	return "sharpened " + f.base.GetName()
+}
+
+func (f *sharpen) FromData(d *data.Data) classification.Classifier {
+	return &sharpened{f.base.FromData(d), f.power}
+}
+
+type sharpened struct {
+	base  classification.Classifier
+	power float64
+}
+
+func (c *sharpened) EstimateClassProbabilities(x []float64) data.Histogram {
+	return softmaxScaled(c.base.EstimateClassProbabilities(x), c.power)
+}
+
+func (*Tests) TestSigmoid(c *C) {
+	c.Check(Sigmoid(0), Equals, 0.5)
+	c.Check(Sigmoid(1000), Equals, 1.0)
+	c.Check(Sigmoid(-1000), Equals, 0.0)
+	for _, z := range []float64{-3, -0.5, 0.5, 3} {
+		c.Check(math.Abs(Sigmoid(z)-1/(1+math.Exp(-z))) < 1e-15, Equals, true)
+		c.Check(math.Abs(Sigmoid(z)+Sigmoid(-z)-1) < 1e-15, Equals, true)
+	}
+}
+
+func (*Tests) TestPlatt(c *C) {
+	// Generate decision values from a known logistic model.
+	rng := rand.New(rand.NewSource(4))
+	n := 20000
+	dec := make([]float64, n)
+	label := make([]bool, n)
+	w := make([]float64, n)
+	for i := range dec {
+		f := 4*rng.Float64() - 2
+		dec[i] = f
+		label[i] = rng.Float64() < Sigmoid(1.5*f-0.5)
+		w[i] = 1
+	}
+	A, B := FitPlatt(dec, label, w)
+	c.Check(math.Abs(A+1.5) < 0.1, Equals, true, Commentf("A = %g", A))
+	c.Check(math.Abs(B-0.5) < 0.1, Equals, true, Commentf("B = %g", B))
+}
+
+func (*Tests) TestIsotonicRegression(c *C) {
+	x := []float64{4, 1, 3, 2, 3}
+	y := []float64{4, 1, 2, 3, 2}
+	w := []float64{1, 1, 1, 2, 1}
+	xs, ys := IsotonicRegression(x, y, w)
+	c.Check(xs, DeepEquals, []float64{1, 2, 3, 4})
+	// The values at x=2 (weight 2, mean 3) and x=3 (weight 2, mean 2)
+	// are pooled.
+	c.Check(ys, DeepEquals, []float64{1, 2.5, 2.5, 4})
+
+	m := &isotonicMap{xs, ys}
+	c.Check(m.eval(0), Equals, 1.0)
+	c.Check(m.eval(1.5), Equals, 1.75)
+	c.Check(m.eval(10), Equals, 4.0)
+}
+
+func (*Tests) TestBinary(c *C) {
+	set := data.NewNormals(1.0, 4000, 4000)
+	truth := set.(data.Posterior)
+	raw := &sharpen{&baseline.Oracle{Truth: truth}, 3}
+	// Isotonic regression can return probabilities 0 and 1, so we use
+	// clipped log-loss instead of the deviance.
+	L := loss.ClippedLogLoss(1e-6)
+	rawRes := classification.Assess(raw, set, L)
+	c.Assert(rawRes.Err, IsNil)
+
+	for _, method := range []Method{Platt, Isotonic, Temperature, Dirichlet} {
+		for _, holdout := range []float64{0, 0.3} {
+			f := &Factory{Base: raw, Method: method, HoldoutFraction: holdout}
+			res := classification.Assess(f, set, L)
+			c.Assert(res.Err, IsNil)
+			c.Check(res.MeanLoss < rawRes.MeanLoss-0.05, Equals, true,
+				Commentf("%s: %g vs. %g", f.GetName(), res.MeanLoss, rawRes.MeanLoss))
+			c.Check(res.ExcessLoss < 0.02, Equals, true,
+				Commentf("%s: excess loss %g", f.GetName(), res.ExcessLoss))
+		}
+	}
+
+	// For a sharpened oracle, temperature scaling recovers the power.
+	train, _ := set.TrainingData()
+	cal := (&Factory{Base: raw, Method: Temperature}).ClassifierFromData(train)
+	T := cal.cal.(*temperature).T
+	c.Check(math.Abs(T-3) < 0.3, Equals, true, Commentf("T = %g", T))
+}
+
+func (*Tests) TestMulticlass(c *C) {
+	rng := rand.New(rand.NewSource(5))
+	n := 3000
+	d := data.NewEmpty(3, n, 2)
+	for i := range d.Y {
+		y := rng.Intn(3)
+		x := d.X.Row(i)
+		x[0] = rng.NormFloat64() + float64(y)
+		x[1] = rng.NormFloat64()
+		d.Y[i] = y
+	}
+	train := *d // make a shallow copy
+	test := *d
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			train.Rows = append(train.Rows, i)
+		} else {
+			test.Rows = append(test.Rows, i)
+		}
+	}
+	set := data.MakeSet("three classes", &train, &test)
+
+	raw := &knn.Factory{K: 10}
+	L := loss.ClippedLogLoss(1e-6)
+	rawRes := classification.Assess(raw, set, L)
+	for _, method := range []Method{Platt, Isotonic, Temperature, Dirichlet} {
+		f := &Factory{Base: raw, Method: method}
+		res := classification.Assess(f, set, L)
+		c.Assert(res.Err, IsNil)
+		c.Check(res.MeanLoss < rawRes.MeanLoss, Equals, true,
+			Commentf("%s: %g vs. %g", f.GetName(), res.MeanLoss, rawRes.MeanLoss))
+		p := f.ClassifierFromData(&train).EstimateClassProbabilities([]float64{1, 0})
+		c.Check(math.Abs(p.Sum()-1) < 1e-12, Equals, true)
+	}
+}
+
+func (*Tests) TestFewSamples(c *C) {
+	set := data.NewNormals(1.0, 3, 0)
+	train, _ := set.TrainingData()
+	f := &Factory{Base: &baseline.Prior{}, Method: Temperature, Folds: 2}
+	prob := f.FromData(train).EstimateClassProbabilities(train.X.Row(0))
+	c.Check(prob, HasLen, 2)
+
+	train.Rows = train.GetRows()[:1]
+	_, err := f.CheckedFromData(train)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+
+	f = &Factory{Base: &baseline.Prior{}, HoldoutFraction: 0.1}
+	_, err = f.CheckedFromData(train)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+}
+
+func (*Tests) TestInvalidParameters(c *C) {
+	set := data.NewNormals(1.0, 20, 0)
+	train, _ := set.TrainingData()
+	for _, f := range []*Factory{
+		{Base: &baseline.Prior{}, Folds: 1},
+		{Base: &baseline.Prior{}, HoldoutFraction: 1},
+		{Base: &baseline.Prior{}, Method: Dirichlet + 1},
+	} {
+		_, err := f.CheckedFromData(train)
+		c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true,
+			Commentf("%v", err))
+	}
+}
+
+// failing is a factory which panics when trained on fewer than `min`
+// samples, to check that errors from the cross-validation folds, which
+// are trained in separate goroutines, are reported to the caller.
+type failing struct {
+	min int
+}
+
+func (f *failing) GetName() string { return "failing" }
+
+func (f *failing) FromData(d *data.Data) classification.Classifier {
+	if d.NRow() < f.min {
+		panic("too few samples")
+	}
+	return (&baseline.Prior{}).FromData(d)
+}
+
+func (*Tests) TestBaseError(c *C) {
+	set := data.NewNormals(1.0, 20, 0)
+	train, _ := set.TrainingData()
+	f := &Factory{Base: &failing{min: train.NRow()}}
+	_, err := f.CheckedFromData(train)
+	var pErr *classification.PanicError
+	c.Check(errors.As(err, &pErr), Equals, true)
+}
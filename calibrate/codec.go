@@ -0,0 +1,111 @@
+package calibrate
+
+import (
+	"math"
+	"sort"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/model"
+)
+
+// maxClasses limits the number of per-class maps accepted when
+// decoding.
+const maxClasses = 1 << 16
+
+func init() {
+	model.Register(&Classifier{}, &model.Codec{
+		Tag:     "calibrate",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			cc := c.(*Classifier)
+			w.Int(int(cc.Method))
+			w.Classifier(cc.Base)
+			switch cal := cc.cal.(type) {
+			case *oneVsRest:
+				w.Int(len(cal.maps))
+				for _, m := range cal.maps {
+					switch m := m.(type) {
+					case nil:
+						w.Bool(false)
+					case plattMap:
+						w.Bool(true)
+						w.Float64(m.A)
+						w.Float64(m.B)
+					case *isotonicMap:
+						w.Bool(true)
+						w.Float64s(m.x)
+						w.Float64s(m.y)
+					}
+				}
+			case *temperature:
+				w.Float64(cal.T)
+			case *dirichlet:
+				w.Classifier(cal.model)
+			default:
+				w.Fail(model.ErrEncoding)
+			}
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &Classifier{
+				Method: Method(r.Int()),
+				Base:   r.Classifier(),
+				info:   info,
+			}
+			switch res.Method {
+			case Platt, Isotonic:
+				res.cal = readOneVsRest(r, res.Method)
+			case Temperature:
+				T := r.Float64()
+				if !(T > 0) || math.IsInf(T, 1) {
+					r.Fail(model.ErrEncoding)
+				}
+				res.cal = &temperature{T: T}
+			case Dirichlet:
+				m, ok := r.Classifier().(*logit.Model)
+				if !ok {
+					r.Fail(model.ErrEncoding)
+				}
+				res.cal = &dirichlet{m}
+			default:
+				r.Fail(model.ErrEncoding)
+			}
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			return res, nil
+		},
+	})
+}
+
+func readOneVsRest(r *model.Reader, method Method) *oneVsRest {
+	n := r.Len(maxClasses)
+	if n < 2 {
+		r.Fail(model.ErrEncoding)
+		return nil
+	}
+	res := &oneVsRest{
+		maps: make([]scalarMap, n),
+	}
+	for k := 0; k < n && r.Err() == nil; k++ {
+		if !r.Bool() {
+			// For two classes, only the map for class 1 is used.
+			if n != 2 || k != 0 {
+				r.Fail(model.ErrEncoding)
+			}
+			continue
+		}
+		if method == Platt {
+			res.maps[k] = plattMap{A: r.Float64(), B: r.Float64()}
+			continue
+		}
+		x := r.Float64s()
+		y := r.Float64s()
+		if len(x) != len(y) || !sort.Float64sAreSorted(x) {
+			r.Fail(model.ErrEncoding)
+		}
+		res.maps[k] = &isotonicMap{x, y}
+	}
+	return res
+}
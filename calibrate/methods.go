@@ -0,0 +1,285 @@
+package calibrate
+
+import (
+	"math"
+	"sort"
+
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// eps is used to clip probabilities before taking logarithms.
+const eps = 1e-12
+
+func logOdds(p float64) float64 {
+	p = math.Min(math.Max(p, eps), 1-eps)
+	return math.Log(p / (1 - p))
+}
+
+// scalarMap maps the estimated probability of one class to a
+// calibrated probability.
+type scalarMap interface {
+	eval(p float64) float64
+}
+
+// oneVsRest calibrates the probability of every class separately.  For
+// two classes, only the map for class 1 is used.
+type oneVsRest struct {
+	maps []scalarMap
+}
+
+func fitOneVsRest(h *heldOut, fit func(p []float64, event []bool, w []float64) scalarMap) *oneVsRest {
+	K := h.numClasses
+	first := 0
+	if K == 2 {
+		first = 1
+	}
+	res := &oneVsRest{
+		maps: make([]scalarMap, K),
+	}
+	p := make([]float64, len(h.y))
+	event := make([]bool, len(h.y))
+	for k := first; k < K; k++ {
+		for i, prob := range h.prob {
+			p[i] = prob[k]
+			event[i] = h.y[i] == k
+		}
+		res.maps[k] = fit(p, event, h.w)
+	}
+	return res
+}
+
+func (c *oneVsRest) apply(prob data.Histogram) data.Histogram {
+	res := make(data.Histogram, len(prob))
+	if len(prob) == 2 {
+		p1 := c.maps[1].eval(prob[1])
+		res[0] = 1 - p1
+		res[1] = p1
+		return res
+	}
+	for k, pk := range prob {
+		res[k] = c.maps[k].eval(pk)
+	}
+	if res.Sum() <= 0 {
+		for k := range res {
+			res[k] = 1
+		}
+	}
+	return res.Probabilities()
+}
+
+// plattMap is a logistic function of the log-odds.
+type plattMap struct {
+	A, B float64
+}
+
+func (m plattMap) eval(p float64) float64 {
+	return Sigmoid(-(m.A*logOdds(p) + m.B))
+}
+
+func fitPlattCalibrator(h *heldOut) calibrator {
+	return fitOneVsRest(h, func(p []float64, event []bool, w []float64) scalarMap {
+		f := make([]float64, len(p))
+		for i, pi := range p {
+			f[i] = logOdds(pi)
+		}
+		A, B := FitPlatt(f, event, w)
+		return plattMap{A, B}
+	})
+}
+
+// isotonicMap is a non-decreasing, piecewise linear function,
+// interpolating the points (x[i], y[i]).  The function is constant
+// outside the range of x.
+type isotonicMap struct {
+	x, y []float64
+}
+
+func (m *isotonicMap) eval(p float64) float64 {
+	n := len(m.x)
+	if n == 0 {
+		return 0
+	}
+	i := sort.SearchFloat64s(m.x, p)
+	switch {
+	case i == 0:
+		return m.y[0]
+	case i == n:
+		return m.y[n-1]
+	case m.x[i] == p:
+		return m.y[i]
+	}
+	t := (p - m.x[i-1]) / (m.x[i] - m.x[i-1])
+	return m.y[i-1] + t*(m.y[i]-m.y[i-1])
+}
+
+func fitIsotonicCalibrator(h *heldOut) calibrator {
+	return fitOneVsRest(h, func(p []float64, event []bool, w []float64) scalarMap {
+		target := make([]float64, len(p))
+		for i, e := range event {
+			if e {
+				target[i] = 1
+			}
+		}
+		x, y := IsotonicRegression(p, target, w)
+		return &isotonicMap{x, y}
+	})
+}
+
+// IsotonicRegression computes the weighted isotonic regression of `y` on `x`,
+// using the pool adjacent violators algorithm.  The result is given
+// as the distinct values of `x` in increasing order, together with
+// the fitted, non-decreasing values.
+func IsotonicRegression(x, y, w []float64) ([]float64, []float64) {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return x[idx[i]] < x[idx[j]] })
+
+	// One block for every distinct value of x, with total weight and
+	// weighted mean of y.
+	type block struct {
+		first, last int // range of distinct x values
+		weight      float64
+		mean        float64
+	}
+	var xs []float64
+	var blocks []block
+	for _, i := range idx {
+		if w[i] <= 0 {
+			continue
+		}
+		if n := len(xs); n > 0 && xs[n-1] == x[i] {
+			b := &blocks[len(blocks)-1]
+			b.mean += w[i] / (b.weight + w[i]) * (y[i] - b.mean)
+			b.weight += w[i]
+		} else {
+			xs = append(xs, x[i])
+			blocks = append(blocks, block{len(xs) - 1, len(xs) - 1, w[i], y[i]})
+		}
+
+		// pool adjacent violators
+		for len(blocks) > 1 {
+			n := len(blocks)
+			b1, b2 := blocks[n-2], blocks[n-1]
+			if b1.mean < b2.mean {
+				break
+			}
+			total := b1.weight + b2.weight
+			blocks[n-2] = block{
+				first:  b1.first,
+				last:   b2.last,
+				weight: total,
+				mean:   (b1.weight*b1.mean + b2.weight*b2.mean) / total,
+			}
+			blocks = blocks[:n-1]
+		}
+	}
+
+	ys := make([]float64, len(xs))
+	for _, b := range blocks {
+		for i := b.first; i <= b.last; i++ {
+			ys[i] = b.mean
+		}
+	}
+	return xs, ys
+}
+
+// temperature divides the log-probabilities by T.
+type temperature struct {
+	T float64
+}
+
+func (c *temperature) apply(prob data.Histogram) data.Histogram {
+	return softmaxScaled(prob, 1/c.T)
+}
+
+// softmaxScaled returns the normalised values of prob^a.
+func softmaxScaled(prob data.Histogram, a float64) data.Histogram {
+	res := make(data.Histogram, len(prob))
+	max := math.Inf(-1)
+	for k, pk := range prob {
+		res[k] = a * math.Log(math.Max(pk, eps))
+		if res[k] > max {
+			max = res[k]
+		}
+	}
+	sum := 0.0
+	for k, zk := range res {
+		res[k] = math.Exp(zk - max)
+		sum += res[k]
+	}
+	for k := range res {
+		res[k] /= sum
+	}
+	return res
+}
+
+func fitTemperature(h *heldOut) calibrator {
+	nll := func(logT float64) float64 {
+		a := math.Exp(-logT)
+		res := 0.0
+		for i, prob := range h.prob {
+			q := softmaxScaled(prob, a)
+			res -= h.w[i] * math.Log(math.Max(q[h.y[i]], 1e-300))
+		}
+		return res
+	}
+
+	// golden section search for log T
+	const invPhi = 0.6180339887498949
+	a, b := -5.0, 5.0
+	x1 := b - invPhi*(b-a)
+	x2 := a + invPhi*(b-a)
+	f1, f2 := nll(x1), nll(x2)
+	for b-a > 1e-6 {
+		if f1 < f2 {
+			b, x2, f2 = x2, x1, f1
+			x1 = b - invPhi*(b-a)
+			f1 = nll(x1)
+		} else {
+			a, x1, f1 = x1, x2, f2
+			x2 = a + invPhi*(b-a)
+			f2 = nll(x2)
+		}
+	}
+	return &temperature{T: math.Exp((a + b) / 2)}
+}
+
+// dirichlet applies a multinomial logistic regression model to the
+// log-probabilities.
+type dirichlet struct {
+	model *logit.Model
+}
+
+func (c *dirichlet) apply(prob data.Histogram) data.Histogram {
+	return c.model.EstimateClassProbabilities(logProb(prob, nil))
+}
+
+func logProb(prob data.Histogram, res []float64) []float64 {
+	if res == nil {
+		res = make([]float64, len(prob))
+	}
+	for k, pk := range prob {
+		res[k] = math.Log(math.Max(pk, eps))
+	}
+	return res
+}
+
+func fitDirichlet(h *heldOut) calibrator {
+	K := h.numClasses
+	n := len(h.y)
+	d := &data.Data{
+		NumClasses: K,
+		X:          matrix.NewFloat64(n, K, 0, nil),
+		Y:          h.y,
+		Weights:    h.w,
+	}
+	for i, prob := range h.prob {
+		logProb(prob, d.X.Row(i))
+	}
+	model := (&logit.Factory{L2: 1e-3}).ModelFromData(d)
+	return &dirichlet{model}
+}
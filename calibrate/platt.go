@@ -0,0 +1,105 @@
+package calibrate
+
+import "math"
+
+// FitPlatt fits the parameters A and B of the Platt scaling
+// 1 / (1 + exp(A*f + B)) to the decision values `dec` and labels
+// `label`, with sample weights `w`, using the regularised targets from
+// Platt's paper.  The Newton method with backtracking line search from
+// "A Note on Platt's Probabilistic Outputs for Support Vector Machines"
+// by Lin, Lin and Weng (2007) is used.
+func FitPlatt(dec []float64, label []bool, w []float64) (float64, float64) {
+	nPos := 0.0
+	nNeg := 0.0
+	for i, isPos := range label {
+		if isPos {
+			nPos += w[i]
+		} else {
+			nNeg += w[i]
+		}
+	}
+	hiTarget := (nPos + 1) / (nPos + 2)
+	loTarget := 1 / (nNeg + 2)
+	t := make([]float64, len(dec))
+	for i, isPos := range label {
+		if isPos {
+			t[i] = hiTarget
+		} else {
+			t[i] = loTarget
+		}
+	}
+
+	const (
+		maxIter = 100
+		minStep = 1e-10
+		sigma   = 1e-12 // ensures a positive definite Hessian
+		eps     = 1e-5
+	)
+
+	objective := func(A, B float64) float64 {
+		res := 0.0
+		for i, f := range dec {
+			fApB := f*A + B
+			if fApB >= 0 {
+				res += w[i] * (t[i]*fApB + math.Log1p(math.Exp(-fApB)))
+			} else {
+				res += w[i] * ((t[i]-1)*fApB + math.Log1p(math.Exp(fApB)))
+			}
+		}
+		return res
+	}
+
+	A := 0.0
+	B := math.Log((nNeg + 1) / (nPos + 1))
+	fval := objective(A, B)
+	for iter := 0; iter < maxIter; iter++ {
+		h11, h22, h21 := sigma, sigma, 0.0
+		g1, g2 := 0.0, 0.0
+		for i, f := range dec {
+			p := Sigmoid(-(f*A + B)) // probability of the positive class
+			q := 1 - p
+			d2 := w[i] * p * q
+			h11 += f * f * d2
+			h22 += d2
+			h21 += f * d2
+			d1 := w[i] * (t[i] - p)
+			g1 += f * d1
+			g2 += d1
+		}
+		if math.Abs(g1) < eps && math.Abs(g2) < eps {
+			break
+		}
+
+		det := h11*h22 - h21*h21
+		dA := -(h22*g1 - h21*g2) / det
+		dB := -(-h21*g1 + h11*g2) / det
+		gd := g1*dA + g2*dB
+
+		step := 1.0
+		for step >= minStep {
+			newA := A + step*dA
+			newB := B + step*dB
+			newf := objective(newA, newB)
+			if newf < fval+1e-4*step*gd {
+				A, B, fval = newA, newB, newf
+				break
+			}
+			step /= 2
+		}
+		if step < minStep {
+			break
+		}
+	}
+	return A, B
+}
+
+// Sigmoid returns the logistic function 1 / (1 + exp(-z)), avoiding
+// overflow.  For the Platt scaling, the probability of the positive
+// class is Sigmoid(-(A*f + B)).
+func Sigmoid(z float64) float64 {
+	if z >= 0 {
+		return 1 / (1 + math.Exp(-z))
+	}
+	e := math.Exp(z)
+	return e / (1 + e)
+}
@@ -1,11 +1,13 @@
 package classification
 
 import (
+	"context"
 	"math"
 	"time"
 
 	"seehuhn.de/go/classification/data"
 	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/resampling"
 )
 
 // Classifier represents an algorithm for classification, together
@@ -30,6 +32,25 @@ type Result struct {
 
 	TrainingTime time.Duration
 	TestTime     time.Duration
+
+	// Report gives the confusion matrix and per-class metrics for the
+	// test data.
+	Report *Report
+
+	// Predictions stores the estimated class probabilities for all
+	// test samples.
+	Predictions *Predictions
+
+	// If the data set implements the data.Posterior interface,
+	// HasBayes is set and BayesLoss gives the mean loss of the Bayes
+	// classifier, which uses the true class probabilities, on the
+	// test data.  ExcessLoss is MeanLoss minus BayesLoss, and
+	// ExcessStdErr is the standard error of ExcessLoss, computed from
+	// the paired losses of the two classifiers.
+	HasBayes     bool
+	BayesLoss    float64
+	ExcessLoss   float64
+	ExcessStdErr float64
 }
 
 // Assess estimates the quality of a classifier by computing the
@@ -38,6 +59,13 @@ type Result struct {
 // classifier from training data, and `L` specifies the loss function
 // to assess the cost of wrong classifications.
 func Assess(cf Factory, samples data.Set, L loss.Function) *Result {
+	return AssessContext(context.Background(), cf, samples, L)
+}
+
+// AssessContext is like Assess, but training can be cancelled using
+// `ctx` and progress is reported to the Progress value attached to
+// `ctx`, if the factory implements ContextFactory.
+func AssessContext(ctx context.Context, cf Factory, samples data.Set, L loss.Function) *Result {
 	res := &Result{}
 
 	trainingData, err := samples.TrainingData()
@@ -46,8 +74,12 @@ func Assess(cf Factory, samples data.Set, L loss.Function) *Result {
 		return res
 	}
 	start := time.Now()
-	c := cf.FromData(trainingData)
+	c, err := FromDataContext(ctx, cf, trainingData)
 	res.TrainingTime = time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
 
 	testData, err := samples.TestData()
 	if err != nil {
@@ -57,15 +89,29 @@ func Assess(cf Factory, samples data.Set, L loss.Function) *Result {
 	cumLoss := 0.0
 	cumLoss2 := 0.0
 	rows := testData.GetRows()
+	losses := make([]float64, len(rows))
+	report := NewReport(testData.NumClasses)
+	preds := newPredictions(testData.NumClasses, len(rows), testData.Weights != nil)
 	start = time.Now()
-	for _, i := range rows {
-		sample := testData.X.Row(i)
-		prob := c.EstimateClassProbabilities(sample)
+	probs, err := estimateAll(c, testData)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for k, i := range rows {
+		prob := data.Histogram(probs.Row(k))
 		l := L(testData.Y[i], prob)
+		losses[k] = l
 		cumLoss += l
 		cumLoss2 += l * l
+		w := testData.Weight(i)
+		report.Add(testData.Y[i], Decide(c, prob), w)
+		preds.add(testData.Y[i], prob, w)
 	}
 	res.TestTime = time.Since(start)
+	report.Update()
+	res.Report = report
+	res.Predictions = preds
 	nn := float64(len(rows))
 	cumLoss /= nn
 	cumLoss2 /= nn
@@ -73,27 +119,84 @@ func Assess(cf Factory, samples data.Set, L loss.Function) *Result {
 
 	res.MeanLoss = cumLoss
 	res.StdErr = stdErr
+
+	if truth, ok := samples.(data.Posterior); ok {
+		bayesLoss := 0.0
+		cumDiff := 0.0
+		cumDiff2 := 0.0
+		for k, i := range rows {
+			prob := truth.TrueClassProbabilities(testData.X.Row(i))
+			b := L(testData.Y[i], prob)
+			bayesLoss += b
+			diff := losses[k] - b
+			cumDiff += diff
+			cumDiff2 += diff * diff
+		}
+		cumDiff /= nn
+		cumDiff2 /= nn
+		res.HasBayes = true
+		res.BayesLoss = bayesLoss / nn
+		res.ExcessLoss = cumDiff
+		res.ExcessStdErr = math.Sqrt(math.Max(cumDiff2-cumDiff*cumDiff, 0) / (nn - 1))
+	}
 	return res
 }
 
+// AssessXVal estimates the quality of a classifier using `K`-fold
+// cross-validation on the data `samples`.  This is equivalent to
+// calling AssessResampled with a resampling.KFold scheme.
 func AssessXVal(cf Factory, samples *data.Data, L loss.Function, K int) *Result {
+	return AssessResampled(cf, samples, L, &resampling.KFold{K: K, Seed: xValSeed})
+}
+
+// AssessResampled estimates the quality of a classifier by training
+// and testing it on all training/test splits generated by `scheme`.
+// The mean loss is taken over all test samples of all splits; if a
+// sample occurs in several test sets, it is counted once per
+// occurrence.
+func AssessResampled(cf Factory, samples *data.Data, L loss.Function, scheme resampling.Scheme) *Result {
+	return AssessResampledContext(context.Background(), cf, samples, L, scheme)
+}
+
+// AssessResampledContext is like AssessResampled, but can be cancelled
+// using `ctx`.  A FoldDone event is reported after every split.
+func AssessResampledContext(ctx context.Context, cf Factory, samples *data.Data, L loss.Function, scheme resampling.Scheme) *Result {
 	res := &Result{}
 
+	splits, err := scheme.Splits(samples)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	total := 0
+	for _, split := range splits {
+		testData, err := split.TestData()
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		total += testData.NRow()
+	}
+
 	var testTime time.Duration
 	var trainingTime time.Duration
 	cumLoss := 0.0
 	cumLoss2 := 0.0
-	for k := 0; k < K; k++ {
-		split := samples.GetXValSet(xValSeed, K, k)
-
+	report := NewReport(samples.NumClasses)
+	preds := newPredictions(samples.NumClasses, total, samples.Weights != nil)
+	for k, split := range splits {
 		trainingData, err := split.TrainingData()
 		if err != nil {
 			res.Err = err
 			return res
 		}
 		start := time.Now()
-		c := cf.FromData(trainingData)
+		c, err := FromDataContext(ctx, cf, trainingData)
 		trainingTime += time.Since(start)
+		if err != nil {
+			res.Err = err
+			return res
+		}
 
 		testData, err := split.TestData()
 		if err != nil {
@@ -102,17 +205,33 @@ func AssessXVal(cf Factory, samples *data.Data, L loss.Function, K int) *Result
 		}
 		rows := testData.GetRows()
 		start = time.Now()
-		for _, i := range rows {
-			sample := testData.X.Row(i)
-			prob := c.EstimateClassProbabilities(sample)
+		probs, err := estimateAll(c, testData)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		for j, i := range rows {
+			prob := data.Histogram(probs.Row(j))
 			l := L(testData.Y[i], prob)
 			cumLoss += l
 			cumLoss2 += l * l
+			w := testData.Weight(i)
+			report.Add(testData.Y[i], Decide(c, prob), w)
+			preds.add(testData.Y[i], prob, w)
 		}
 		testTime += time.Since(start)
+		ReportProgress(ctx, ProgressEvent{
+			Kind:   FoldDone,
+			Source: cf.GetName(),
+			Done:   k + 1,
+			Total:  len(splits),
+		})
 	}
+	report.Update()
+	res.Report = report
+	res.Predictions = preds
 
-	nn := float64(samples.NRow())
+	nn := float64(total)
 	cumLoss /= nn
 	cumLoss2 /= nn
 	stdErr := math.Sqrt((cumLoss2 - cumLoss*cumLoss) / (nn - 1))
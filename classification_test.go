@@ -0,0 +1,407 @@
+package classification_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/bagging"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/boosting"
+	"seehuhn.de/go/classification/calibrate"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/forest"
+	"seehuhn.de/go/classification/gaussian"
+	"seehuhn.de/go/classification/gbm"
+	"seehuhn.de/go/classification/impurity"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/matrix"
+	"seehuhn.de/go/classification/mlp"
+	"seehuhn.de/go/classification/multiclass"
+	"seehuhn.de/go/classification/resampling"
+	"seehuhn.de/go/classification/stacking"
+	"seehuhn.de/go/classification/svm"
+	"seehuhn.de/go/classification/tree"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+// threshold classifies one-dimensional inputs by comparing them to
+// fixed thresholds.
+type threshold []float64
+
+func (t threshold) GetName() string {
+	return "threshold"
+}
+
+func (t threshold) FromData(d *data.Data) classification.Classifier {
+	return t
+}
+
+func (t threshold) EstimateClassProbabilities(x []float64) data.Histogram {
+	prob := make(data.Histogram, len(t)+1)
+	k := 0
+	for k < len(t) && x[0] >= t[k] {
+		k++
+	}
+	prob[k] = 1
+	return prob
+}
+
+func (*Tests) TestReport(c *C) {
+	r := classification.NewReport(3)
+	// true class 0: 5x correct, 1x predicted as 1
+	// true class 1: 3x correct, 2x predicted as 2
+	// true class 2: 4x correct
+	counts := [][]float64{{5, 1, 0}, {0, 3, 2}, {0, 0, 4}}
+	for i, row := range counts {
+		for j, n := range row {
+			r.Add(i, j, n)
+		}
+	}
+	r.Update()
+
+	c.Check(r.Total, Equals, 15.0)
+	c.Check(r.Support, DeepEquals, []float64{6, 5, 4})
+	c.Check(near(r.Accuracy, 12./15), Equals, true)
+	c.Check(near(r.Precision[1], 3./4), Equals, true)
+	c.Check(near(r.Recall[1], 3./5), Equals, true)
+	c.Check(near(r.F1[1], 2./3), Equals, true)
+	c.Check(near(r.MacroRecall, (5./6+3./5+1)/3), Equals, true)
+	c.Check(near(r.BalancedAccuracy, r.MacroRecall), Equals, true)
+	c.Check(near(r.MicroF1, r.Accuracy), Equals, true)
+	pe := (6*5 + 5*4 + 4*6) / 225.
+	c.Check(near(r.Kappa, (12./15-pe)/(1-pe)), Equals, true)
+
+	s := r.Format([]string{"a", "b", "c"})
+	c.Check(strings.Contains(s, "balanced accuracy"), Equals, true)
+	c.Check(strings.Contains(s, "macro avg"), Equals, true)
+}
+
+func (*Tests) TestReportMissingClass(c *C) {
+	r := classification.NewReport(3)
+	r.Add(0, 0, 2)
+	r.Add(1, 0, 1)
+	r.Add(1, 1, 1)
+	r.Update()
+	// Class 2 neither occurs nor is predicted, and is ignored in the
+	// macro averages.
+	c.Check(r.MacroRecall, Equals, 0.75)
+	c.Check(r.BalancedAccuracy, Equals, 0.75)
+	c.Check(r.Precision[2], Equals, 0.0)
+}
+
+func (*Tests) TestAssessReport(c *C) {
+	d := data.NewEmpty(2, 6, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		if i >= 3 {
+			d.Y[i] = 1
+		}
+	}
+	d.Weights = []float64{1, 1, 1, 2, 1, 1}
+	set := data.MakeSet("test", d, d)
+
+	// Sample 2 is misclassified, sample 3 (weight 2) is correct.
+	res := classification.Assess(threshold{2}, set, loss.ZeroOne)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.Report.Confusion.Row(0), DeepEquals, []float64{2, 1})
+	c.Check(res.Report.Confusion.Row(1), DeepEquals, []float64{0, 4})
+	c.Check(res.Predictions.Len(), Equals, 6)
+	c.Check(res.Predictions.Prob.Row(2), DeepEquals, []float64{0, 1})
+	c.Check(res.Predictions.Weight(3), Equals, 2.0)
+
+	res = classification.AssessXVal(threshold{2}, d, loss.ZeroOne, 3)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.Report.Total, Equals, 7.0)
+
+	res = classification.AssessXVal(threshold{2}, d, loss.ZeroOne, 0)
+	c.Check(errors.Is(res.Err, resampling.ErrInvalidScheme), Equals, true)
+}
+
+func (*Tests) TestAssessResampled(c *C) {
+	d := data.NewEmpty(2, 6, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		if i >= 3 {
+			d.Y[i] = 1
+		}
+	}
+
+	// Leave-one-out tests every sample once; only sample 2 is
+	// misclassified.
+	res := classification.AssessResampled(threshold{2}, d, loss.ZeroOne,
+		&resampling.LeaveOneOut{})
+	c.Assert(res.Err, IsNil)
+	c.Check(res.Predictions.Len(), Equals, 6)
+	c.Check(near(res.MeanLoss, 1./6), Equals, true)
+
+	// Repeated cross-validation tests every sample once per repetition.
+	res = classification.AssessResampled(threshold{2}, d, loss.ZeroOne,
+		&resampling.Repeated{K: 3, Repeats: 2})
+	c.Assert(res.Err, IsNil)
+	c.Check(res.Predictions.Len(), Equals, 12)
+	c.Check(near(res.MeanLoss, 1./6), Equals, true)
+
+	res = classification.AssessResampled(threshold{2}, d, loss.ZeroOne,
+		&resampling.KFold{K: 10})
+	c.Check(res.Err, NotNil)
+}
+
+func (*Tests) TestAssessResampledContext(c *C) {
+	d := data.NewEmpty(2, 6, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		if i >= 3 {
+			d.Y[i] = 1
+		}
+	}
+
+	var folds []int
+	ctx := classification.WithProgress(context.Background(),
+		classification.ProgressFunc(func(e classification.ProgressEvent) {
+			if e.Kind == classification.FoldDone {
+				c.Check(e.Total, Equals, 6)
+				folds = append(folds, e.Done)
+			}
+		}))
+	res := classification.AssessResampledContext(ctx, threshold{2}, d,
+		loss.ZeroOne, &resampling.LeaveOneOut{})
+	c.Assert(res.Err, IsNil)
+	c.Check(folds, DeepEquals, []int{1, 2, 3, 4, 5, 6})
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	res = classification.AssessResampledContext(ctx, threshold{2}, d,
+		loss.ZeroOne, &resampling.LeaveOneOut{})
+	c.Check(errors.Is(res.Err, context.Canceled), Equals, true)
+}
+
+func (*Tests) TestBatch(c *C) {
+	x := matrix.NewFloat64(4, 1, 0, []float64{0, 1, 2, 3})
+	prob := matrix.NewFloat64(4, 2, 0, nil)
+	classification.Batch(threshold{1}).EstimateClassProbabilitiesBatch(x, prob)
+	for i := 0; i < 4; i++ {
+		c.Check(prob.Row(i), DeepEquals, []float64(threshold{1}.EstimateClassProbabilities(x.Row(i))))
+	}
+
+	c.Check(func() {
+		classification.Batch(threshold{1}).EstimateClassProbabilitiesBatch(x, matrix.NewFloat64(3, 2, 0, nil))
+	}, PanicMatches, "wrong number of rows.*")
+}
+
+func (*Tests) TestMetadata(c *C) {
+	d := data.NewEmpty(2, 60, 3)
+	d.FeatureNames = []string{"a", "b", "c"}
+	d.ClassNames = []string{"no", "yes"}
+	rng := rand.New(rand.NewSource(1))
+	for i := range d.Y {
+		for j := 0; j < 3; j++ {
+			d.X.Set(i, j, rng.Float64())
+		}
+		if d.X.At(i, 0)+0.2*rng.NormFloat64() > 0.5 {
+			d.Y[i] = 1
+		}
+	}
+
+	factories := []classification.Factory{
+		&baseline.Prior{},
+		&baseline.Uniform{},
+		&baseline.Stratified{},
+		tree.CART,
+		bagging.New(tree.CART, 3, 0),
+		(&forest.RandomForestFactory{
+			RandomTree: forest.RandomTree{
+				NumSamples: 0.7,
+				NumLeaves:  4,
+				SplitScore: impurity.Gini,
+			},
+			NumTrees: 3,
+		}).New(),
+		&boosting.Factory{},
+		&gbm.Factory{Rounds: 5},
+		&knn.Factory{K: 3},
+		&logit.Factory{},
+		&gaussian.NaiveBayes{},
+		&gaussian.LDA{},
+		&gaussian.QDA{Shrinkage: 0.1},
+		&svm.Factory{},
+		&mlp.Factory{Hidden: []int{4}, Epochs: 5},
+		&multiclass.OneVsRest{Base: &logit.Factory{}},
+		&multiclass.OneVsOne{Base: &logit.Factory{}},
+		&calibrate.Factory{Base: &logit.Factory{}},
+		&stacking.Factory{
+			Base: []classification.Factory{&logit.Factory{}, &knn.Factory{K: 3}},
+		},
+	}
+	for _, f := range factories {
+		cfr := f.FromData(d)
+		info := classification.GetInfo(cfr)
+		c.Assert(info, NotNil, Commentf("%s", f.GetName()))
+		c.Check(info.NumClasses, Equals, 2)
+		c.Check(info.NumInputs, Equals, 3)
+		c.Check(info.FeatureNames, DeepEquals, d.FeatureNames)
+		c.Check(info.ClassNames, DeepEquals, d.ClassNames)
+		c.Check(func() { cfr.EstimateClassProbabilities([]float64{0.5}) },
+			PanicMatches, ".*expected 3.*", Commentf("%s", f.GetName()))
+
+		x := matrix.NewFloat64(2, 2, 0, nil)
+		err := classification.CheckInputs(cfr, x)
+		c.Check(errors.Is(err, data.ErrShape), Equals, true)
+	}
+
+	c.Check(classification.GetInfo(threshold{1}), IsNil)
+	c.Check(classification.CheckInputs(threshold{1}, matrix.NewFloat64(2, 7, 0, nil)), IsNil)
+}
+
+func (*Tests) TestAssessBootstrap(c *C) {
+	set := data.NewNormals(1.0, 200, 20000)
+	train, _ := set.TrainingData()
+
+	// A fixed classifier does not overfit, so all three estimates
+	// should agree with the test loss.
+	test := classification.Assess(threshold{0.5}, set, loss.ZeroOne)
+	for _, est := range []classification.BootstrapEstimator{
+		classification.OutOfBootstrap,
+		classification.Bootstrap632,
+		classification.Bootstrap632Plus,
+	} {
+		res := classification.AssessBootstrap(threshold{0.5}, train, loss.ZeroOne, 50, est)
+		c.Assert(res.Err, IsNil)
+		c.Check(res.StdErr > 0, Equals, true)
+		c.Check(math.Abs(res.MeanLoss-test.MeanLoss) < 3*res.StdErr, Equals, true,
+			Commentf("%s: %g vs. %g", est, res.MeanLoss, test.MeanLoss))
+	}
+
+	// The nearest neighbour classifier has training loss 0.  The .632
+	// estimate is too optimistic in this case, and .632+ gives more
+	// weight to the out-of-bootstrap loss.
+	nn := &knn.Factory{K: 1}
+	oob := classification.AssessBootstrap(nn, train, loss.ZeroOne, 50,
+		classification.OutOfBootstrap)
+	e632 := classification.AssessBootstrap(nn, train, loss.ZeroOne, 50,
+		classification.Bootstrap632)
+	e632p := classification.AssessBootstrap(nn, train, loss.ZeroOne, 50,
+		classification.Bootstrap632Plus)
+	c.Check(near(e632.MeanLoss, 0.632*oob.MeanLoss), Equals, true)
+	c.Check(e632p.MeanLoss > e632.MeanLoss, Equals, true)
+	c.Check(e632p.MeanLoss <= oob.MeanLoss, Equals, true)
+
+	res := classification.AssessBootstrap(nn, train, loss.ZeroOne, 0,
+		classification.OutOfBootstrap)
+	c.Check(res.Err, NotNil)
+}
+
+// recorder records the training rows passed to FromData.
+type recorder struct {
+	rows [][]int
+}
+
+func (r *recorder) GetName() string {
+	return "recorder"
+}
+
+func (r *recorder) FromData(d *data.Data) classification.Classifier {
+	r.rows = append(r.rows, append([]int(nil), d.GetRows()...))
+	return threshold{0.5}
+}
+
+func (*Tests) TestLearningCurve(c *C) {
+	set := data.NewNormals(2.0, 1000, 1000)
+
+	r := &recorder{}
+	lc, err := classification.AssessLearningCurve(r, set, loss.ZeroOne, []int{100, 1000, 500})
+	c.Assert(err, IsNil)
+	c.Assert(lc.Points, HasLen, 3)
+	c.Check(lc.Points[0].Size, Equals, 100)
+	c.Check(lc.Points[2].Size, Equals, 1000)
+	c.Check(lc.Points[0].ModelSize, Equals, -1)
+	// The subsamples are drawn from largest to smallest, and are
+	// nested.
+	c.Assert(r.rows, HasLen, 3)
+	for i := 1; i < 3; i++ {
+		larger := make(map[int]bool)
+		for _, row := range r.rows[i-1] {
+			larger[row] = true
+		}
+		for _, row := range r.rows[i] {
+			c.Check(larger[row], Equals, true)
+		}
+	}
+
+	lc, err = classification.AssessLearningCurve(tree.CART, set, loss.ZeroOne, nil)
+	c.Assert(err, IsNil)
+	c.Assert(lc.Points, HasLen, 10)
+	first, last := lc.Points[0], lc.Points[9]
+	c.Check(first.Size, Equals, 100)
+	c.Check(last.Size, Equals, 1000)
+	c.Check(last.ModelSize > 0, Equals, true)
+	c.Check(last.StdErr > 0, Equals, true)
+
+	fname := filepath.Join(c.MkDir(), "curve.csv")
+	c.Assert(lc.WriteCSV(fname), IsNil)
+	body, err := ioutil.ReadFile(fname)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	c.Check(lines, HasLen, 11)
+	c.Check(lines[0], Equals, "size,loss,stderr,training_time,test_time,model_size")
+
+	_, err = classification.AssessLearningCurve(tree.CART, set, loss.ZeroOne, []int{2000})
+	c.Check(err, NotNil)
+}
+
+// panicky is a factory which always panics.
+type panicky struct{}
+
+func (panicky) GetName() string {
+	return "panicky"
+}
+
+func (panicky) FromData(d *data.Data) classification.Classifier {
+	panic(data.ErrTooFewSamples)
+}
+
+func (*Tests) TestChecked(c *C) {
+	d := data.NewEmpty(2, 6, 1)
+
+	cf := classification.Checked(panicky{})
+	_, err := cf.CheckedFromData(d)
+	var pe *classification.PanicError
+	c.Check(errors.As(err, &pe), Equals, true)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+	c.Check(err.Error(), Equals, "panicky: panic: not enough samples")
+
+	cf = classification.Checked(threshold{2})
+	_, err = cf.CheckedFromData(d)
+	c.Check(err, IsNil)
+	d.Y[0] = 7
+	_, err = cf.CheckedFromData(d)
+	c.Check(errors.Is(err, data.ErrClass), Equals, true)
+
+	// Checked factories are not wrapped again.
+	tf := &tree.Factory{Name: "t"}
+	c.Check(classification.Checked(tf), Equals, tf)
+	c.Check(func() { classification.Unchecked(cf).FromData(d) }, Panics, err)
+
+	set := data.MakeSet("test", d, d)
+	res := classification.Assess(panicky{}, set, loss.ZeroOne)
+	c.Check(res.Err, NotNil)
+}
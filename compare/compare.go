@@ -0,0 +1,369 @@
+// Package compare implements paired statistical tests to decide
+// whether one classification method performs better than another.
+//
+// The two methods are always evaluated on identical training and test
+// data, so that the per-sample losses can be compared directly.  This
+// removes the variation caused by the choice of test samples, and
+// gives much more powerful tests than comparing the standard errors
+// reported by classification.Assess.
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+const compareSeed = 1743394051
+
+// TestResult describes the outcome of a statistical test of the
+// hypothesis that both methods perform equally well.
+type TestResult struct {
+	// Statistic gives the value of the test statistic.
+	Statistic float64
+
+	// DF gives the degrees of freedom of the reference distribution,
+	// where applicable.
+	DF float64
+
+	// PValue gives the two-sided p-value of the test.
+	PValue float64
+}
+
+func (t *TestResult) String() string {
+	if t == nil {
+		return "-"
+	}
+	if t.DF > 0 {
+		return fmt.Sprintf("%.4g (df %.4g, p = %.4g)", t.Statistic, t.DF, t.PValue)
+	}
+	return fmt.Sprintf("%.4g (p = %.4g)", t.Statistic, t.PValue)
+}
+
+// Comparison describes the paired comparison of two classification
+// methods A and B.  Differences are always given as A minus B, so that
+// negative differences indicate that A performs better.
+type Comparison struct {
+	NameA, NameB string
+
+	// N gives the number of paired test samples.
+	N int
+
+	// MeanLossA and MeanLossB give the mean losses of the two methods,
+	// MeanDiff gives the mean of the per-sample loss differences, and
+	// StdErr gives the paired standard error of MeanDiff.
+	MeanLossA, MeanLossB float64
+	MeanDiff             float64
+	StdErr               float64
+
+	// OnlyA gives the number of samples which are classified
+	// correctly by A but not by B, and OnlyB gives the number of
+	// samples classified correctly by B but not by A.  A sample is
+	// classified correctly, if the true class has the largest
+	// estimated probability.
+	OnlyA, OnlyB int
+
+	// McNemar gives McNemar's test for the zero-one loss, based on
+	// OnlyA and OnlyB.  For OnlyA+OnlyB < 25, the exact binomial test
+	// is used and Statistic gives min(OnlyA, OnlyB).  Otherwise, the
+	// chi-squared approximation with continuity correction is used.
+	McNemar *TestResult
+
+	// PairedT gives the paired t-test for the per-sample losses.
+	PairedT *TestResult
+
+	// Wilcoxon gives the Wilcoxon signed-rank test for the per-sample
+	// losses, using the normal approximation with a correction for
+	// ties.  Statistic is the standardised rank sum.
+	Wilcoxon *TestResult
+
+	// CorrectedT gives the corrected resampled t-test by Nadeau and
+	// Bengio (2003), computed from the mean loss differences on the
+	// individual cross-validation folds.  This is only set by XVal.
+	CorrectedT *TestResult
+
+	Err error
+}
+
+// String formats the result as a short, human-readable summary.
+func (r *Comparison) String() string {
+	if r.Err != nil {
+		return "error: " + r.Err.Error()
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "A = %s\nB = %s\n", r.NameA, r.NameB)
+	fmt.Fprintf(b, "mean loss A %.6g, B %.6g, A-B %.6g (se %.4g, n = %d)\n",
+		r.MeanLossA, r.MeanLossB, r.MeanDiff, r.StdErr, r.N)
+	fmt.Fprintf(b, "only A correct %d, only B correct %d\n", r.OnlyA, r.OnlyB)
+	fmt.Fprintf(b, "McNemar     %s\n", r.McNemar)
+	fmt.Fprintf(b, "paired t    %s\n", r.PairedT)
+	fmt.Fprintf(b, "Wilcoxon    %s\n", r.Wilcoxon)
+	if r.CorrectedT != nil {
+		fmt.Fprintf(b, "corrected t %s\n", r.CorrectedT)
+	}
+	return b.String()
+}
+
+// Holdout trains classifiers for both factories on the training data
+// of `samples` and compares their losses on the test data.
+func Holdout(a, b classification.Factory, samples data.Set, L loss.Function) *Comparison {
+	resA := classification.Assess(a, samples, L)
+	if resA.Err != nil {
+		return &Comparison{NameA: a.GetName(), NameB: b.GetName(), Err: resA.Err}
+	}
+	resB := classification.Assess(b, samples, L)
+	if resB.Err != nil {
+		return &Comparison{NameA: a.GetName(), NameB: b.GetName(), Err: resB.Err}
+	}
+	res := FromPredictions(resA.Predictions, resB.Predictions, L)
+	res.NameA = a.GetName()
+	res.NameB = b.GetName()
+	return res
+}
+
+// FromPredictions compares two sets of predictions for the same test
+// data.  This can be used to compare the results of two calls to
+// classification.Assess for the same data set, without training the
+// classifiers again.
+func FromPredictions(pa, pb *classification.Predictions, L loss.Function) *Comparison {
+	n := pa.Len()
+	if pb.Len() != n {
+		return &Comparison{Err: errors.New("predictions have different lengths")}
+	}
+	p := newPaired(n)
+	for i := 0; i < n; i++ {
+		y := pa.Y[i]
+		if pb.Y[i] != y {
+			return &Comparison{Err: errors.New("predictions are for different test data")}
+		}
+		p.add(y, pa.Prob.Row(i), pb.Prob.Row(i), L)
+	}
+	res := &Comparison{}
+	p.fill(res)
+	return res
+}
+
+// XVal compares two factories using `R` repetitions of `K`-fold
+// cross-validation on the data `d`.  Both methods are trained and
+// tested on identical folds.  The per-sample tests use the
+// out-of-fold losses of the first repetition, where every sample is
+// used exactly once.  The corrected resampled t-test uses the mean
+// loss differences of all R*K folds.  Nadeau and Bengio recommend
+// R = 10 repetitions of K = 10 folds.
+func XVal(a, b classification.Factory, d *data.Data, L loss.Function, K, R int) *Comparison {
+	res := &Comparison{
+		NameA: a.GetName(),
+		NameB: b.GetName(),
+	}
+	if K < 2 || R < 1 {
+		res.Err = errors.New("invalid number of folds or repetitions")
+		return res
+	}
+	if n := d.NRow(); n < K {
+		res.Err = fmt.Errorf("%d samples for %d folds: %w",
+			n, K, data.ErrTooFewSamples)
+		return res
+	}
+
+	first := newPaired(d.NRow())
+	var foldDiff []float64
+	var ratio float64
+	for r := 0; r < R; r++ {
+		seed := compareSeed + int64(r)
+		for k := 0; k < K; k++ {
+			train, test, err := d.XValSplit(seed, K, k)
+			if err != nil {
+				res.Err = err
+				return res
+			}
+			ca := a.FromData(train)
+			cb := b.FromData(train)
+
+			rows := test.GetRows()
+			sum := 0.0
+			for _, row := range rows {
+				x := test.X.Row(row)
+				y := test.Y[row]
+				probA := ca.EstimateClassProbabilities(x)
+				probB := cb.EstimateClassProbabilities(x)
+				sum += L(y, probA) - L(y, probB)
+				if r == 0 {
+					first.add(y, probA, probB, L)
+				}
+			}
+			foldDiff = append(foldDiff, sum/float64(len(rows)))
+			ratio += float64(len(rows)) / float64(train.NRow())
+		}
+	}
+	first.fill(res)
+
+	J := len(foldDiff)
+	ratio /= float64(J)
+	mean, variance := meanVar(foldDiff)
+	t := mean / math.Sqrt((1/float64(J)+ratio)*variance)
+	if mean == 0 {
+		t = 0
+	}
+	res.CorrectedT = &TestResult{
+		Statistic: t,
+		DF:        float64(J - 1),
+		PValue:    tTwoSided(t, float64(J-1)),
+	}
+	return res
+}
+
+// paired collects the per-sample results of both methods.
+type paired struct {
+	lossA, lossB       []float64
+	correctA, correctB []bool
+}
+
+func newPaired(capacity int) *paired {
+	return &paired{
+		lossA:    make([]float64, 0, capacity),
+		lossB:    make([]float64, 0, capacity),
+		correctA: make([]bool, 0, capacity),
+		correctB: make([]bool, 0, capacity),
+	}
+}
+
+func (p *paired) add(y int, probA, probB data.Histogram, L loss.Function) {
+	p.lossA = append(p.lossA, L(y, probA))
+	p.lossB = append(p.lossB, L(y, probB))
+	p.correctA = append(p.correctA, probA.ArgMax() == y)
+	p.correctB = append(p.correctB, probB.ArgMax() == y)
+}
+
+// fill computes the mean losses and the per-sample tests.
+func (p *paired) fill(res *Comparison) {
+	n := len(p.lossA)
+	res.N = n
+	diff := make([]float64, n)
+	for i := range diff {
+		res.MeanLossA += p.lossA[i]
+		res.MeanLossB += p.lossB[i]
+		diff[i] = p.lossA[i] - p.lossB[i]
+		switch {
+		case p.correctA[i] && !p.correctB[i]:
+			res.OnlyA++
+		case p.correctB[i] && !p.correctA[i]:
+			res.OnlyB++
+		}
+	}
+	res.MeanLossA /= float64(n)
+	res.MeanLossB /= float64(n)
+
+	mean, variance := meanVar(diff)
+	res.MeanDiff = mean
+	res.StdErr = math.Sqrt(variance / float64(n))
+
+	res.McNemar = mcNemar(res.OnlyA, res.OnlyB)
+
+	t := 0.0
+	if mean != 0 {
+		t = mean / res.StdErr
+	}
+	res.PairedT = &TestResult{
+		Statistic: t,
+		DF:        float64(n - 1),
+		PValue:    tTwoSided(t, float64(n-1)),
+	}
+
+	res.Wilcoxon = wilcoxon(diff)
+}
+
+// meanVar returns the mean and the sample variance of x.
+func meanVar(x []float64) (float64, float64) {
+	n := float64(len(x))
+	mean := 0.0
+	for _, xi := range x {
+		mean += xi
+	}
+	mean /= n
+	ss := 0.0
+	for _, xi := range x {
+		ss += (xi - mean) * (xi - mean)
+	}
+	return mean, ss / (n - 1)
+}
+
+func mcNemar(onlyA, onlyB int) *TestResult {
+	n := onlyA + onlyB
+	if n < 25 {
+		k := onlyA
+		if onlyB < k {
+			k = onlyB
+		}
+		return &TestResult{
+			Statistic: float64(k),
+			PValue:    binomTwoSided(k, n),
+		}
+	}
+	d := math.Max(math.Abs(float64(onlyA-onlyB))-1, 0)
+	chi2 := d * d / float64(n)
+	return &TestResult{
+		Statistic: chi2,
+		DF:        1,
+		PValue:    chi2SF1(chi2),
+	}
+}
+
+// wilcoxon computes the Wilcoxon signed-rank test for the differences
+// `diff`.  Zero differences are discarded and tied absolute values get
+// the average rank.
+func wilcoxon(diff []float64) *TestResult {
+	var abs []float64
+	var pos []bool
+	for _, d := range diff {
+		if d != 0 {
+			abs = append(abs, math.Abs(d))
+			pos = append(pos, d > 0)
+		}
+	}
+	n := len(abs)
+	if n == 0 {
+		return &TestResult{Statistic: 0, PValue: 1}
+	}
+
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return abs[idx[i]] < abs[idx[j]] })
+
+	wPlus := 0.0
+	tieCorr := 0.0
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && abs[idx[j]] == abs[idx[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1, ..., j
+		for _, k := range idx[i:j] {
+			if pos[k] {
+				wPlus += rank
+			}
+		}
+		t := float64(j - i)
+		tieCorr += t*t*t - t
+		i = j
+	}
+
+	nn := float64(n)
+	mean := nn * (nn + 1) / 4
+	variance := nn*(nn+1)*(2*nn+1)/24 - tieCorr/48
+	if variance <= 0 {
+		return &TestResult{Statistic: 0, PValue: 1}
+	}
+	z := (wPlus - mean) / math.Sqrt(variance)
+	return &TestResult{
+		Statistic: z,
+		PValue:    2 * normalSF(math.Abs(z)),
+	}
+}
@@ -0,0 +1,111 @@
+package compare
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/tree"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestDistributions(c *C) {
+	// critical values from standard tables
+	c.Check(tTwoSided(2.228, 10), Not(Equals), 0.0)
+	c.Check(math.Abs(tTwoSided(2.228, 10)-0.05) < 1e-3, Equals, true)
+	c.Check(math.Abs(tTwoSided(-1.96, 1e6)-0.05) < 1e-3, Equals, true)
+	c.Check(math.Abs(tTwoSided(12.706, 1)-0.05) < 1e-4, Equals, true)
+	c.Check(tTwoSided(0, 5), Equals, 1.0)
+	c.Check(math.Abs(chi2SF1(3.841)-0.05) < 1e-4, Equals, true)
+	c.Check(math.Abs(2*normalSF(1.959964)-0.05) < 1e-6, Equals, true)
+
+	c.Check(math.Abs(binomTwoSided(0, 5)-2.0/32) < 1e-12, Equals, true)
+	c.Check(math.Abs(binomTwoSided(4, 5)-12.0/32) < 1e-12, Equals, true)
+	c.Check(binomTwoSided(3, 6), Equals, 1.0)
+	c.Check(binomTwoSided(0, 0), Equals, 1.0)
+
+	for _, x := range []float64{0.1, 0.3, 0.5, 0.9} {
+		// I_x(a, b) = 1 - I_{1-x}(b, a)
+		d := incompleteBeta(x, 2.5, 0.5) + incompleteBeta(1-x, 0.5, 2.5) - 1
+		c.Check(math.Abs(d) < 1e-12, Equals, true)
+		// I_x(1, 1) = x
+		c.Check(math.Abs(incompleteBeta(x, 1, 1)-x) < 1e-12, Equals, true)
+	}
+}
+
+func (*Tests) TestWilcoxon(c *C) {
+	// Without ties or zeros, the positive ranks are 2, 4 and 5.
+	diff := []float64{-0.1, 0.2, -0.3, 0.4, 0.5}
+	res := wilcoxon(diff)
+	z := (11 - 7.5) / math.Sqrt(5*6*11/24.0)
+	c.Check(math.Abs(res.Statistic-z) < 1e-12, Equals, true)
+
+	// Ties get the average rank, zeros are discarded.
+	diff = []float64{0, 1, -1, 1, 2}
+	res = wilcoxon(diff)
+	// ranks: 2, 2, 2, 4; positive ranks sum to 8
+	v := 4*5*9/24.0 - (27-3)/48.0
+	z = (8 - 5) / math.Sqrt(v)
+	c.Check(math.Abs(res.Statistic-z) < 1e-12, Equals, true)
+
+	res = wilcoxon([]float64{0, 0})
+	c.Check(res.PValue, Equals, 1.0)
+}
+
+func (*Tests) TestHoldout(c *C) {
+	set := data.NewNormals(2.0, 2000, 2000)
+
+	res := Holdout(tree.CART, &baseline.Prior{}, set, loss.ZeroOne)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.N, Equals, 2000)
+	c.Check(res.MeanDiff < 0, Equals, true)
+	c.Check(math.Abs(res.MeanDiff-(res.MeanLossA-res.MeanLossB)) < 1e-12, Equals, true)
+	c.Check(res.OnlyA > res.OnlyB, Equals, true)
+	c.Check(res.McNemar.PValue < 1e-6, Equals, true)
+	c.Check(res.PairedT.PValue < 1e-6, Equals, true)
+	c.Check(res.Wilcoxon.PValue < 1e-6, Equals, true)
+	c.Check(res.CorrectedT, IsNil)
+
+	res = Holdout(tree.CART, tree.CART, set, loss.ClippedLogLoss(1e-6))
+	c.Assert(res.Err, IsNil)
+	c.Check(res.MeanDiff, Equals, 0.0)
+	c.Check(res.OnlyA+res.OnlyB, Equals, 0)
+	c.Check(res.McNemar.PValue, Equals, 1.0)
+	c.Check(res.PairedT.PValue, Equals, 1.0)
+	c.Check(res.Wilcoxon.PValue, Equals, 1.0)
+}
+
+func (*Tests) TestXVal(c *C) {
+	set := data.NewNormals(2.0, 500, 0)
+	d, _ := set.TrainingData()
+
+	res := XVal(&knn.Factory{K: 10}, &baseline.Prior{}, d, loss.ZeroOne, 5, 2)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.N, Equals, d.NRow())
+	c.Assert(res.CorrectedT, NotNil)
+	c.Check(res.CorrectedT.DF, Equals, 9.0)
+	c.Check(res.CorrectedT.Statistic < 0, Equals, true)
+	c.Check(res.CorrectedT.PValue < 0.01, Equals, true)
+
+	// The corrected test is more conservative than the naive paired
+	// t-test.
+	c.Check(res.CorrectedT.PValue > res.PairedT.PValue, Equals, true)
+
+	res = XVal(&knn.Factory{K: 10}, &baseline.Prior{}, d, loss.ZeroOne, 1, 1)
+	c.Check(res.Err, NotNil)
+
+	small, _ := data.NewNormals(2.0, 3, 0).TrainingData()
+	res = XVal(&baseline.Uniform{}, &baseline.Prior{}, small, loss.ZeroOne, 5, 1)
+	c.Check(errors.Is(res.Err, data.ErrTooFewSamples), Equals, true)
+}
@@ -0,0 +1,107 @@
+package classification
+
+import (
+	"context"
+	"fmt"
+
+	"seehuhn.de/go/classification/data"
+)
+
+// ContextFactory is implemented by factories which support
+// cancellation and progress reporting during training.  Training
+// stops as soon as possible after `ctx` is cancelled, and the error
+// from ctx.Err() is returned.  Progress is reported to the Progress
+// value attached to `ctx` using WithProgress, if any.
+type ContextFactory interface {
+	GetName() string
+	FromDataContext(ctx context.Context, d *data.Data) (Classifier, error)
+}
+
+// FromDataContext trains a classifier using the factory `f`.  If `f`
+// implements ContextFactory, training can be cancelled using `ctx`.
+// Otherwise, the context is only checked before training starts, and
+// any errors are reported as for Checked.
+func FromDataContext(ctx context.Context, f Factory, d *data.Data) (Classifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cf, ok := f.(ContextFactory); ok {
+		return cf.FromDataContext(ctx, d)
+	}
+	return Checked(f).CheckedFromData(d)
+}
+
+// ProgressKind describes the type of a progress event.
+type ProgressKind int
+
+const (
+	// NodeGrown is reported when a node is added to a decision tree.
+	// Done gives the number of nodes grown so far; Total is 0.
+	NodeGrown ProgressKind = iota + 1
+
+	// TreeDone is reported when a member of an ensemble has been
+	// trained.  Done and Total give the number of completed and the
+	// total number of members.
+	TreeDone
+
+	// FoldDone is reported when a cross-validation fold has been
+	// completed.  Done and Total give the number of completed folds
+	// and the total number of folds.
+	FoldDone
+)
+
+func (k ProgressKind) String() string {
+	switch k {
+	case NodeGrown:
+		return "node grown"
+	case TreeDone:
+		return "tree done"
+	case FoldDone:
+		return "fold done"
+	default:
+		return fmt.Sprintf("ProgressKind(%d)", int(k))
+	}
+}
+
+// ProgressEvent describes the progress of a training run.
+type ProgressEvent struct {
+	Kind ProgressKind
+
+	// Source gives the name of the factory which reported the event.
+	Source string
+
+	// Done gives the number of steps completed so far, and Total
+	// gives the total number of steps, or 0 if this is not known.
+	Done, Total int
+}
+
+// Progress receives progress events during training.  Events can be
+// reported concurrently from several goroutines, so implementations
+// must be safe for concurrent use.
+type Progress interface {
+	Report(e ProgressEvent)
+}
+
+// ProgressFunc allows to use an ordinary function as a Progress value.
+type ProgressFunc func(e ProgressEvent)
+
+// Report calls f(e).
+func (f ProgressFunc) Report(e ProgressEvent) {
+	f(e)
+}
+
+type progressKey struct{}
+
+// WithProgress returns a copy of `ctx` which reports progress events to
+// `p`.
+func WithProgress(ctx context.Context, p Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ReportProgress reports the event `e` to the Progress value attached
+// to `ctx`.  If no Progress value is attached, the event is ignored.
+func ReportProgress(ctx context.Context, e ProgressEvent) {
+	if p, ok := ctx.Value(progressKey{}).(Progress); ok && p != nil {
+		p.Report(e)
+	}
+}
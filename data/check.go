@@ -0,0 +1,69 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// These errors describe problems with a data set.  Errors returned by
+// Check wrap one of these values, so that errors.Is can be used to
+// test for them.  Other packages use the same values to report
+// problems with their training data.
+var (
+	ErrNoSamples     = errors.New("no samples")
+	ErrTooFewSamples = errors.New("not enough samples")
+	ErrShape         = errors.New("inconsistent data dimensions")
+	ErrClass         = errors.New("class out of range")
+	ErrWeight        = errors.New("invalid sample weight")
+	ErrFolds         = errors.New("invalid cross-validation fold")
+)
+
+// Check verifies that the data set is consistent: the number of
+// classes must be positive, X, Y and Weights must have matching
+// sizes, all rows must be valid, all classes must be in the range 0,
+// ..., NumClasses-1, and all weights must be non-negative and finite.
+// If feature or class names are given, there must be one name per
+// column or class, respectively.  The data set must contain at least
+// one sample.
+func (data *Data) Check() error {
+	if data.NumClasses < 1 {
+		return fmt.Errorf("%d classes: %w", data.NumClasses, ErrClass)
+	}
+	if data.ClassNames != nil && len(data.ClassNames) != data.NumClasses {
+		return fmt.Errorf("%d class names for %d classes: %w",
+			len(data.ClassNames), data.NumClasses, ErrShape)
+	}
+	if data.X == nil {
+		return fmt.Errorf("missing inputs: %w", ErrShape)
+	}
+	n, p := data.X.Shape()
+	if data.FeatureNames != nil && len(data.FeatureNames) != p {
+		return fmt.Errorf("%d feature names for %d columns: %w",
+			len(data.FeatureNames), p, ErrShape)
+	}
+	if len(data.Y) != n {
+		return fmt.Errorf("%d responses for %d inputs: %w", len(data.Y), n, ErrShape)
+	}
+	if data.Weights != nil && len(data.Weights) != n {
+		return fmt.Errorf("%d weights for %d inputs: %w", len(data.Weights), n, ErrShape)
+	}
+	if data.NRow() == 0 {
+		return ErrNoSamples
+	}
+	for _, row := range data.GetRows() {
+		if row < 0 || row >= n {
+			return fmt.Errorf("invalid row %d: %w", row, ErrShape)
+		}
+		if y := data.Y[row]; y < 0 || y >= data.NumClasses {
+			return fmt.Errorf("row %d has class %d: %w", row, y, ErrClass)
+		}
+		if data.Weights != nil {
+			w := data.Weights[row]
+			if !(w >= 0) || math.IsInf(w, +1) {
+				return fmt.Errorf("row %d has weight %g: %w", row, w, ErrWeight)
+			}
+		}
+	}
+	return nil
+}
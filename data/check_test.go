@@ -0,0 +1,43 @@
+package data
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/matrix"
+)
+
+func (*Tests) TestCheck(c *C) {
+	d := NewEmpty(2, 4, 1)
+	c.Check(d.Check(), IsNil)
+
+	d.Y[2] = 2
+	c.Check(errors.Is(d.Check(), ErrClass), Equals, true)
+	d.Y[2] = 1
+
+	d.Weights = []float64{1, 1, 1}
+	c.Check(errors.Is(d.Check(), ErrShape), Equals, true)
+	d.Weights = []float64{1, 1, -1, 1}
+	c.Check(errors.Is(d.Check(), ErrWeight), Equals, true)
+	d.Weights = nil
+
+	d.Rows = []int{0, 4}
+	c.Check(errors.Is(d.Check(), ErrShape), Equals, true)
+	d.Rows = []int{}
+	c.Check(errors.Is(d.Check(), ErrNoSamples), Equals, true)
+	d.Rows = nil
+
+	d.FeatureNames = []string{"x", "y"}
+	c.Check(errors.Is(d.Check(), ErrShape), Equals, true)
+	d.FeatureNames = []string{"x"}
+	d.ClassNames = []string{"a"}
+	c.Check(errors.Is(d.Check(), ErrShape), Equals, true)
+	d.ClassNames = []string{"a", "b"}
+	c.Check(d.Check(), IsNil)
+
+	d.Y = d.Y[:3]
+	c.Check(errors.Is(d.Check(), ErrShape), Equals, true)
+
+	d = &Data{NumClasses: 2, X: matrix.NewFloat64(0, 1, 0, nil)}
+	c.Check(errors.Is(d.Check(), ErrNoSamples), Equals, true)
+}
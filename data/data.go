@@ -47,6 +47,14 @@ type Data struct {
 	// the data set consists of the listed rows.  If `Rows` is nil,
 	// the data set consists of all rows of `X`.
 	Rows []int
+
+	// FeatureNames optionally gives names for the columns of X.  If
+	// the slice is non-nil, it must have one entry per column.
+	FeatureNames []string
+
+	// ClassNames optionally gives names for the classes.  If the
+	// slice is non-nil, it must have NumClasses entries.
+	ClassNames []string
 }
 
 // NRow returns the number of samples in the data set.
@@ -76,3 +84,12 @@ func (data *Data) GetRows() []int {
 	}
 	return res
 }
+
+// Weight returns the weight of the sample in the given row of `X`, or
+// 1 if the data set has no sample weights.
+func (data *Data) Weight(row int) float64 {
+	if data.Weights == nil {
+		return 1
+	}
+	return data.Weights[row]
+}
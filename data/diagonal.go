@@ -0,0 +1,31 @@
+package data
+
+import (
+	"math/rand"
+)
+
+// NewDiagonal returns a data set with `n` samples, uniformly
+// distributed on the unit square.  The three classes are separated by
+// the lines x0+x1 = 0.8 and x0+x1 = 1.2, which are not parallel to the
+// coordinate axes.  This makes the data set a useful test case for
+// ensembles of decision trees.
+func NewDiagonal(n int, seed int64) *Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := NewEmpty(3, n, 2)
+	for i := 0; i < n; i++ {
+		x0 := rng.Float64()
+		x1 := rng.Float64()
+		d.X.Set(i, 0, x0)
+		d.X.Set(i, 1, x1)
+		s := x0 + x1
+		switch {
+		case s < 0.8:
+			d.Y[i] = 0
+		case s < 1.2:
+			d.Y[i] = 1
+		default:
+			d.Y[i] = 2
+		}
+	}
+	return d
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 
 	"github.com/seehuhn/mt19937"
@@ -10,6 +11,7 @@ import (
 
 type twoNormals struct {
 	name      string
+	delta     float64
 	X         *matrix.Float64
 	Y         []int
 	trainRows []int
@@ -19,7 +21,8 @@ type twoNormals struct {
 // NewNormals is a factory function which can generate data sets.  The
 // resulting data sets consist of mixtures of two one-dimensional
 // normal distributions, with variance 1, where the means are
-// separated by `delta`.
+// separated by `delta`.  Both classes have probability 1/2.  The
+// returned set implements the Posterior interface.
 func NewNormals(delta float64, nTrain, nTest int) Set {
 	nTotal := nTrain + nTest
 
@@ -38,6 +41,7 @@ func NewNormals(delta float64, nTrain, nTest int) Set {
 
 	return &twoNormals{
 		name:      fmt.Sprintf("normals/%g/%d/%d", delta, nTrain, nTest),
+		delta:     delta,
 		X:         matrix.NewFloat64(nTotal, 1, 0, X),
 		Y:         Y,
 		trainRows: rows[:nTrain],
@@ -53,6 +57,14 @@ func (ss *twoNormals) String() string {
 	return "<data set " + ss.name + ">"
 }
 
+// TrueClassProbabilities returns the conditional class probabilities
+// given the input `x`.
+func (ss *twoNormals) TrueClassProbabilities(x []float64) Histogram {
+	// The log-odds of class 1 are delta*x.
+	p1 := 1 / (1 + math.Exp(-ss.delta*x[0]))
+	return Histogram{1 - p1, p1}
+}
+
 func (ss *twoNormals) TrainingData() (data *Data, err error) {
 	return &Data{
 		NumClasses: 2,
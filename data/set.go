@@ -9,6 +9,14 @@ type Set interface {
 	TestData() (data *Data, err error)
 }
 
+// Posterior is implemented by data sets where the conditional class
+// probabilities given the inputs are known, for example because the
+// data are generated synthetically.  This allows to compare classifiers
+// to the Bayes classifier.
+type Posterior interface {
+	TrueClassProbabilities(x []float64) Histogram
+}
+
 func MakeSet(name string, train, test *Data) Set {
 	return &set{name, train, test}
 }
@@ -41,16 +41,46 @@ func (xv *xvalSet) TestData() (data *Data, err error) {
 // different data sets.
 //
 // The .TrainingData() and .TestData() methods of the returned data
-// set are guaranteed to never return an error.
+// set are guaranteed to never return an error.  GetXValSet panics if
+// the arguments are invalid or if the data has fewer than K samples;
+// use XValSplit to get an error instead.
 func (data *Data) GetXValSet(seed int64, K, k int) Set {
+	res, err := data.xvalSet(seed, K, k)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// XValSplit returns the training data and the test data for fold `k`
+// of `K`-fold cross-validation.  The split is the same as for
+// GetXValSet.  An error wrapping ErrFolds is returned if K < 2 or if
+// k is not in the range 0, ..., K-1, and an error wrapping
+// ErrTooFewSamples is returned if the data has fewer than K samples.
+func (data *Data) XValSplit(seed int64, K, k int) (train, test *Data, err error) {
+	xv, err := data.xvalSet(seed, K, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	train, _ = xv.TrainingData()
+	test, _ = xv.TestData()
+	return train, test, nil
+}
+
+func (data *Data) xvalSet(seed int64, K, k int) (*xvalSet, error) {
 	if K < 2 {
-		panic("need at least K=2 groups for cross-validation")
+		return nil, fmt.Errorf("need at least K=2 groups for cross-validation, got %d: %w",
+			K, ErrFolds)
+	}
+	if k < 0 || k >= K {
+		return nil, fmt.Errorf("fold %d of %d: %w", k, K, ErrFolds)
 	}
 
 	rows := data.GetRows()
 	n := len(rows)
 	if n < K {
-		panic("not enough samples for cross-validation")
+		return nil, fmt.Errorf("%d samples for %d-fold cross-validation: %w",
+			n, K, ErrTooFewSamples)
 	}
 
 	rng := rand.New(rand.NewSource(seed))
@@ -81,5 +111,5 @@ func (data *Data) GetXValSet(seed int64, K, k int) Set {
 		trainingSet: trainingSet,
 		testSet:     testSet,
 	}
-	return res
+	return res, nil
 }
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"errors"
 	"sort"
 
 	. "gopkg.in/check.v1"
@@ -49,3 +50,22 @@ func (*Tests) TestXValClasses(c *C) {
 		}
 	}
 }
+
+func (*Tests) TestXValSplit(c *C) {
+	data := dummyData(10)
+	train, test, err := data.XValSplit(1, 3, 1)
+	c.Assert(err, IsNil)
+	set := data.GetXValSet(1, 3, 1)
+	train2, _ := set.TrainingData()
+	test2, _ := set.TestData()
+	c.Check(train.GetRows(), DeepEquals, train2.GetRows())
+	c.Check(test.GetRows(), DeepEquals, test2.GetRows())
+
+	_, _, err = data.XValSplit(1, 1, 0)
+	c.Check(errors.Is(err, ErrFolds), Equals, true)
+	_, _, err = data.XValSplit(1, 3, 3)
+	c.Check(errors.Is(err, ErrFolds), Equals, true)
+	_, _, err = data.XValSplit(1, 11, 0)
+	c.Check(errors.Is(err, ErrTooFewSamples), Equals, true)
+	c.Check(func() { data.GetXValSet(1, 11, 0) }, PanicMatches, ".*not enough samples")
+}
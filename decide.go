@@ -0,0 +1,24 @@
+package classification
+
+import "seehuhn.de/go/classification/data"
+
+// Decider is implemented by classifiers which use a decision rule
+// other than choosing the most probable class, for example to take
+// the costs of different kinds of errors into account.  The package
+// seehuhn.de/go/classification/decision can be used to attach a
+// decision rule to any classifier.
+type Decider interface {
+	// Decide returns the class chosen for the estimated class
+	// probabilities `prob`.
+	Decide(prob []float64) int
+}
+
+// Decide returns the class chosen by `c` for the estimated class
+// probabilities `prob`.  If `c` implements Decider, c.Decide is used.
+// Otherwise the most probable class is returned.
+func Decide(c Classifier, prob data.Histogram) int {
+	if d, ok := c.(Decider); ok {
+		return d.Decide(prob)
+	}
+	return prob.ArgMax()
+}
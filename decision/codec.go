@@ -0,0 +1,78 @@
+package decision
+
+import (
+	"fmt"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/model"
+)
+
+// maxClasses limits the size of cost matrices accepted when decoding.
+const maxClasses = 1 << 12
+
+const (
+	ruleArgMax = iota
+	ruleThreshold
+	ruleCostMatrix
+)
+
+func init() {
+	model.Register(&Classifier{}, &model.Codec{
+		Tag:     "decision",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			dc := c.(*Classifier)
+			switch rule := dc.Rule.(type) {
+			case argMax:
+				w.Int(ruleArgMax)
+			case Threshold:
+				w.Int(ruleThreshold)
+				w.Float64(float64(rule))
+			case loss.CostMatrix:
+				w.Int(ruleCostMatrix)
+				w.Int(len(rule))
+				for _, row := range rule {
+					w.Float64s(row)
+				}
+			default:
+				return fmt.Errorf("rule %T: %w", rule, model.ErrUnknownType)
+			}
+			w.Classifier(dc.Classifier)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &Classifier{}
+			switch r.Int() {
+			case ruleArgMax:
+				res.Rule = ArgMax
+			case ruleThreshold:
+				res.Rule = Threshold(r.Float64())
+			case ruleCostMatrix:
+				K := r.Len(maxClasses)
+				C := make(loss.CostMatrix, K)
+				for y := range C {
+					C[y] = r.Float64s()
+					if len(C[y]) != K {
+						r.Fail(model.ErrEncoding)
+						break
+					}
+				}
+				res.Rule = C
+			default:
+				r.Fail(model.ErrEncoding)
+			}
+			res.Classifier = r.Classifier()
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			if info == nil {
+				info = classification.GetInfo(res.Classifier)
+			}
+			if info != nil && CheckRule(res.Rule, info.NumClasses) != nil {
+				return nil, model.ErrEncoding
+			}
+			return res, nil
+		},
+	})
+}
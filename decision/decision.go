@@ -0,0 +1,232 @@
+// Package decision implements rules which turn estimated class
+// probabilities into decisions.
+//
+// By default, a classifier decides for the most probable class.  This
+// minimises the number of errors, but is not optimal if different
+// kinds of errors have different costs.  A Classifier in this package
+// combines any classification.Classifier with a different Rule, for
+// example a threshold for binary problems or a cost matrix (see
+// loss.CostMatrix).  The functions in the classification package use
+// this rule when computing reports.
+package decision
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/matrix"
+	"seehuhn.de/go/classification/metrics"
+)
+
+// Rule describes how a class is chosen from the estimated class
+// probabilities.  Apart from the rules in this package, values of type
+// loss.CostMatrix can be used as rules.
+type Rule interface {
+	Decide(prob []float64) int
+}
+
+var _ Rule = loss.CostMatrix(nil)
+
+// ArgMax is the rule which decides for the most probable class.
+var ArgMax Rule = argMax{}
+
+type argMax struct{}
+
+func (argMax) Decide(prob []float64) int {
+	return data.Histogram(prob).ArgMax()
+}
+
+func (argMax) String() string {
+	return "arg max"
+}
+
+// Threshold is a rule for binary problems, which decides for class 1
+// if the estimated probability of class 1 is greater than or equal to
+// the threshold, and for class 0 otherwise.
+type Threshold float64
+
+// Decide implements the Rule interface.
+func (t Threshold) Decide(prob []float64) int {
+	if prob[1] >= float64(t) {
+		return 1
+	}
+	return 0
+}
+
+func (t Threshold) String() string {
+	return fmt.Sprintf("threshold %g", float64(t))
+}
+
+// CostThreshold returns the Bayes-optimal threshold for a binary
+// problem, if a false positive incurs the cost `costFP` and a false
+// negative incurs the cost `costFN`.  This assumes that the estimated
+// probabilities are well calibrated.
+func CostThreshold(costFP, costFN float64) Threshold {
+	return Threshold(costFP / (costFP + costFN))
+}
+
+// OptimalThreshold chooses the threshold which maximises `crit`, for
+// example metrics.F1, metrics.YoudenJ or metrics.NegCost, for the
+// predictions `p` of a binary classifier.  Class 1 is considered
+// positive.  The predictions should be computed for validation data
+// which was not used to train the classifier.  The function returns
+// the threshold together with the corresponding value of `crit`.
+func OptimalThreshold(p *classification.Predictions, crit metrics.Criterion) (Threshold, float64, error) {
+	if p.NumClasses != 2 {
+		return 0, 0, fmt.Errorf("%d classes, expected 2: %w",
+			p.NumClasses, data.ErrClass)
+	}
+	t, score, err := metrics.OptimalThreshold(p, 1, crit)
+	return Threshold(t), score, err
+}
+
+// Classifier combines a classifier with a decision rule.
+type Classifier struct {
+	classification.Classifier
+	Rule
+}
+
+// New returns a classifier which estimates class probabilities using
+// `c` and makes decisions using `rule`.  If the metadata of `c` is
+// available, an error is returned if the rule does not match the
+// number of classes.
+func New(c classification.Classifier, rule Rule) (*Classifier, error) {
+	info := classification.GetInfo(c)
+	if info != nil {
+		err := CheckRule(rule, info.NumClasses)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := &Classifier{
+		Classifier: c,
+		Rule:       rule,
+	}
+	return res, nil
+}
+
+// CheckRule verifies that `rule` can be used for a problem with the
+// given number of classes.  A Threshold can only be used for binary
+// problems and a loss.CostMatrix must have size K×K with finite
+// entries.  Other rules are not checked.
+func CheckRule(rule Rule, numClasses int) error {
+	switch rule := rule.(type) {
+	case nil:
+		return fmt.Errorf("missing decision rule: %w",
+			classification.ErrInvalidParameter)
+	case Threshold:
+		if numClasses != 2 {
+			return fmt.Errorf("threshold rule for %d classes: %w",
+				numClasses, data.ErrClass)
+		}
+		if math.IsNaN(float64(rule)) {
+			return fmt.Errorf("threshold %g: %w",
+				float64(rule), classification.ErrInvalidParameter)
+		}
+	case loss.CostMatrix:
+		if len(rule) != numClasses {
+			return fmt.Errorf("%d×%d cost matrix for %d classes: %w",
+				len(rule), len(rule), numClasses, data.ErrShape)
+		}
+		for _, row := range rule {
+			if len(row) != numClasses {
+				return fmt.Errorf("cost matrix row of length %d for %d classes: %w",
+					len(row), numClasses, data.ErrShape)
+			}
+			for _, cost := range row {
+				if math.IsNaN(cost) || math.IsInf(cost, 0) {
+					return fmt.Errorf("cost %g: %w",
+						cost, classification.ErrInvalidParameter)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Predict returns the class chosen for the input `x`.
+func (c *Classifier) Predict(x []float64) int {
+	return c.Decide(c.EstimateClassProbabilities(x))
+}
+
+// EstimateClassProbabilitiesBatch implements the
+// classification.BatchClassifier interface, using the batch method of
+// the underlying classifier if available.
+func (c *Classifier) EstimateClassProbabilitiesBatch(x, prob *matrix.Float64) {
+	classification.Batch(c.Classifier).EstimateClassProbabilitiesBatch(x, prob)
+}
+
+// GetInfo implements the classification.Metadata interface, by
+// returning the metadata of the underlying classifier.
+func (c *Classifier) GetInfo() *classification.Info {
+	return classification.GetInfo(c.Classifier)
+}
+
+// Factory attaches a decision rule to the classifiers constructed by
+// another factory.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Base is the factory for the underlying classifier.
+	Base classification.Factory
+
+	// Rule is the decision rule used by the constructed classifiers.
+	// If this is nil, ArgMax is used.
+	Rule Rule
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	switch rule := f.Rule.(type) {
+	case nil:
+		return f.Base.GetName()
+	case fmt.Stringer:
+		return fmt.Sprintf("%s, %s", f.Base.GetName(), rule)
+	default:
+		return f.Base.GetName() + ", cost-sensitive"
+	}
+}
+
+// FromData trains the underlying classifier on `d` and attaches the
+// decision rule.  This implements the classification.Factory
+// interface.  FromData panics if the rule does not match the number of
+// classes in `d`; use CheckedFromData to get an error instead.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	c, err := f.CheckedFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// CheckedFromData is like FromData, but returns an error if the
+// training data is invalid or the rule does not match the number of
+// classes.  This implements the classification.CheckedFactory
+// interface.
+func (f *Factory) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	rule := f.Rule
+	if rule == nil {
+		rule = ArgMax
+	}
+	err := CheckRule(rule, d.NumClasses)
+	if err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	base, err := classification.Checked(f.Base).CheckedFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	c, err := New(base, rule)
+	if err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	return c, nil
+}
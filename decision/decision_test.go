@@ -0,0 +1,148 @@
+package decision
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/metrics"
+	"seehuhn.de/go/classification/model"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestRules(c *C) {
+	prob := []float64{0.7, 0.3}
+	c.Check(ArgMax.Decide(prob), Equals, 0)
+	c.Check(Threshold(0.3).Decide(prob), Equals, 1)
+	c.Check(Threshold(0.4).Decide(prob), Equals, 0)
+
+	// The threshold derived from the costs agrees with the decision
+	// based on the corresponding cost matrix.
+	t := CostThreshold(1, 4)
+	c.Check(t, Equals, Threshold(0.2))
+	C := loss.CostMatrix{{0, 1}, {4, 0}}
+	for _, p1 := range []float64{0, 0.1, 0.19, 0.21, 0.5, 1} {
+		prob := []float64{1 - p1, p1}
+		c.Check(C.Decide(prob), Equals, t.Decide(prob))
+	}
+}
+
+func (*Tests) TestAssess(c *C) {
+	set := data.NewNormals(1, 400, 1000)
+	base := &logit.Factory{}
+	f := &Factory{
+		Base: base,
+		Rule: CostThreshold(1, 9),
+	}
+	c.Check(f.GetName(), Equals, base.GetName()+", threshold 0.1")
+
+	// A low threshold reduces the number of false negatives at the
+	// cost of more false positives.
+	r0 := classification.Assess(base, set, loss.ZeroOne)
+	c.Assert(r0.Err, IsNil)
+	r1 := classification.Assess(f, set, loss.ZeroOne)
+	c.Assert(r1.Err, IsNil)
+	c.Check(r1.Report.Confusion.At(1, 0) < r0.Report.Confusion.At(1, 0), Equals, true)
+	c.Check(r1.Report.Confusion.At(0, 1) > r0.Report.Confusion.At(0, 1), Equals, true)
+
+	// The Bayes-optimal threshold gives a lower average cost than the
+	// default decisions.
+	C := loss.CostMatrix{{0, 1}, {9, 0}}
+	cost1 := classification.Assess(base, set, loss.Cost(C))
+	c.Assert(cost1.Err, IsNil)
+	costOf := func(r *classification.Report) float64 {
+		return (r.Confusion.At(0, 1) + 9*r.Confusion.At(1, 0)) / r.Total
+	}
+	c.Check(costOf(r1.Report) < costOf(r0.Report), Equals, true)
+	c.Check(cost1.MeanLoss, Equals, costOf(r1.Report))
+}
+
+func (*Tests) TestOptimalThreshold(c *C) {
+	set := data.NewNormals(1, 400, 1000)
+	train, err := set.TrainingData()
+	c.Assert(err, IsNil)
+	valid, err := set.TestData()
+	c.Assert(err, IsNil)
+
+	cl := (&logit.Factory{}).FromData(train)
+	p := classification.Predict(cl, valid)
+	t, cost, err := OptimalThreshold(p, metrics.NegCost(1, 9))
+	c.Assert(err, IsNil)
+	c.Check(t > 0 && t < 0.5, Equals, true)
+
+	dc, err := New(cl, t)
+	c.Assert(err, IsNil)
+	total := 0.0
+	for _, i := range valid.GetRows() {
+		k := dc.Predict(valid.X.Row(i))
+		switch {
+		case k == 1 && valid.Y[i] == 0:
+			total++
+		case k == 0 && valid.Y[i] == 1:
+			total += 9
+		}
+	}
+	c.Check(-total/float64(valid.NRow()), Equals, cost)
+
+	p.NumClasses = 3
+	_, _, err = OptimalThreshold(p, metrics.F1)
+	c.Check(err, NotNil)
+}
+
+func (*Tests) TestModel(c *C) {
+	set := data.NewNormals(1, 100, 100)
+	train, err := set.TrainingData()
+	c.Assert(err, IsNil)
+	cl := (&logit.Factory{}).FromData(train)
+
+	rules := []Rule{ArgMax, Threshold(0.25), loss.CostMatrix{{0, 2}, {5, 0}}}
+	for _, rule := range rules {
+		dc1, err := New(cl, rule)
+		c.Assert(err, IsNil)
+		body, err := model.Marshal(dc1)
+		c.Assert(err, IsNil)
+		dc2, err := model.Unmarshal(body)
+		c.Assert(err, IsNil)
+		c.Check(dc2.(*Classifier).Rule, DeepEquals, rule)
+		c.Check(classification.GetInfo(dc2), DeepEquals, classification.GetInfo(dc1))
+	}
+}
+
+func (*Tests) TestCheckRule(c *C) {
+	set := data.NewNormals(1, 100, 0)
+	train, err := set.TrainingData()
+	c.Assert(err, IsNil)
+	cl := (&logit.Factory{}).FromData(train)
+
+	_, err = New(cl, loss.CostMatrix{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}})
+	c.Check(errors.Is(err, data.ErrShape), Equals, true)
+	_, err = New(cl, loss.CostMatrix{{0, 1}, {1}})
+	c.Check(errors.Is(err, data.ErrShape), Equals, true)
+	_, err = New(cl, loss.CostMatrix{{0, math.NaN()}, {1, 0}})
+	c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true)
+
+	// Threshold rules only apply to binary problems.
+	train.NumClasses = 3
+	f := &Factory{Base: &logit.Factory{}, Rule: Threshold(0.5)}
+	_, err = f.CheckedFromData(train)
+	c.Check(errors.Is(err, data.ErrClass), Equals, true)
+	c.Check(func() { f.FromData(train) }, PanicMatches, ".*class out of range")
+
+	// Invalid rules are rejected when a container is decoded.
+	bad := &Classifier{Classifier: cl, Rule: loss.CostMatrix{{0}}}
+	body, err := model.Marshal(bad)
+	c.Assert(err, IsNil)
+	_, err = model.Unmarshal(body)
+	c.Check(err, Equals, model.ErrEncoding)
+}
@@ -0,0 +1,110 @@
+package classification
+
+import (
+	"errors"
+	"fmt"
+
+	"seehuhn.de/go/classification/data"
+)
+
+// ErrInvalidParameter is wrapped by errors which are caused by invalid
+// parameter values in a factory.
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+// CheckedFactory is a variant of the Factory interface, where problems
+// with the training data or the parameters are reported as errors.
+// In contrast, Factory.FromData panics in these cases.
+type CheckedFactory interface {
+	GetName() string
+	CheckedFromData(*data.Data) (Classifier, error)
+}
+
+// FactoryError is returned by CheckedFromData if a classifier cannot
+// be constructed.  Use errors.Is to test for the underlying cause, for
+// example data.ErrTooFewSamples or ErrInvalidParameter.
+type FactoryError struct {
+	// Factory gives the name of the factory.
+	Factory string
+
+	Err error
+}
+
+func (err *FactoryError) Error() string {
+	return err.Factory + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *FactoryError) Unwrap() error {
+	return err.Err
+}
+
+// PanicError describes a panic in a Factory.FromData method, which
+// was converted to an error by a factory obtained from Checked.
+type PanicError struct {
+	Value interface{}
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprint("panic: ", err.Value)
+}
+
+// Unwrap returns the panic value, if this is an error.
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// Checked converts a Factory into a CheckedFactory.  If `f` already
+// implements CheckedFactory, it is returned unchanged.  Otherwise, the
+// returned factory checks the training data using data.Data.Check and
+// converts panics in f.FromData into errors of type *PanicError.
+func Checked(f Factory) CheckedFactory {
+	if cf, ok := f.(CheckedFactory); ok {
+		return cf
+	}
+	return checked{f}
+}
+
+type checked struct {
+	f Factory
+}
+
+func (f checked) GetName() string {
+	return f.f.GetName()
+}
+
+func (f checked) CheckedFromData(d *data.Data) (c Classifier, err error) {
+	if err := d.Check(); err != nil {
+		return nil, &FactoryError{Factory: f.GetName(), Err: err}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c = nil
+			err = &FactoryError{Factory: f.GetName(), Err: &PanicError{r}}
+		}
+	}()
+	return f.f.FromData(d), nil
+}
+
+// Unchecked converts a CheckedFactory into a Factory, where FromData
+// panics if an error occurs.
+func Unchecked(f CheckedFactory) Factory {
+	if uf, ok := f.(Factory); ok {
+		return uf
+	}
+	return unchecked{f}
+}
+
+type unchecked struct {
+	CheckedFactory
+}
+
+func (f unchecked) FromData(d *data.Data) Classifier {
+	c, err := f.CheckedFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -92,7 +92,10 @@ func main() {
 		PruneScore: impurity.MisclassificationError,
 	}
 
-	_, estLoss := b.TreeFromData(d)
+	_, estLoss, err := b.TreeFromData(d)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Println(n, estLoss)
 }
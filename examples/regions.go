@@ -6,6 +6,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
 	"math/rand"
 	"os"
 	"runtime/pprof"
@@ -200,7 +201,10 @@ func main() {
 		Y:          y,
 	}
 
-	tree, estLoss := tree.TreeFromData(d)
+	tree, estLoss, err := tree.TreeFromData(d)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println(estLoss)
 	tree.ForeachLeafRegion(func(a, b []float64, hist data.Histogram, depth int) {
 		fmt.Println(a[0], b[0], a[1], b[1], hist.Probabilities())
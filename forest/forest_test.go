@@ -1,9 +1,18 @@
 package forest
 
 import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync/atomic"
 	"testing"
 
 	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/impurity"
+	"seehuhn.de/go/classification/matrix"
 )
 
 // Hook up gocheck into the "go test" runner.
@@ -12,3 +21,115 @@ func Test(t *testing.T) { TestingT(t) }
 type Tests struct{}
 
 var _ = Suite(&Tests{})
+
+func (*Tests) TestErrors(c *C) {
+	d := data.NewEmpty(2, 20, 3)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		d.Y[i] = i % 2
+	}
+	f := &RandomForestFactory{
+		RandomTree: RandomTree{
+			NumSamples: 0.5,
+			NumLeaves:  4,
+			SplitScore: impurity.Gini,
+		},
+		NumTrees: 5,
+	}
+	cf := classification.Checked(f.New())
+	_, err := cf.CheckedFromData(d)
+	c.Check(err, IsNil)
+
+	// Too many columns used to panic in subset().
+	f.NumColumns = 4
+	cf = classification.Checked(f.New())
+	_, err = cf.CheckedFromData(d)
+	c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true)
+	c.Check(func() { f.New().FromData(d) }, PanicMatches, ".*invalid parameter")
+
+	f.NumColumns = 0
+	f.NumSamples = 0.01
+	_, err = classification.Checked(f.New()).CheckedFromData(d)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+
+	f.NumSamples = 0.5
+	f.NumTrees = 0
+	_, err = classification.Checked(f.New()).CheckedFromData(d)
+	c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true)
+}
+
+func (*Tests) TestContext(c *C) {
+	d := data.NewEmpty(2, 20, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		d.Y[i] = i % 2
+	}
+	f := &RandomForestFactory{
+		RandomTree: RandomTree{
+			NumSamples: 0.5,
+			NumLeaves:  4,
+			SplitScore: impurity.Gini,
+		},
+		NumTrees: 7,
+	}
+
+	var trees int64
+	ctx := classification.WithProgress(context.Background(),
+		classification.ProgressFunc(func(e classification.ProgressEvent) {
+			if e.Kind == classification.TreeDone {
+				c.Check(e.Total, Equals, 7)
+				atomic.AddInt64(&trees, 1)
+			}
+		}))
+	_, err := classification.FromDataContext(ctx, f.New(), d)
+	c.Assert(err, IsNil)
+	c.Check(trees, Equals, int64(7))
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = classification.FromDataContext(ctx, f.New(), d)
+	c.Check(errors.Is(err, context.Canceled), Equals, true)
+
+	// The context is also checked while a single tree is grown.
+	_, err = f.RandomTree.FromDataRandomContext(ctx, d, rand.New(rand.NewSource(1)))
+	c.Check(errors.Is(err, context.Canceled), Equals, true)
+}
+
+func (*Tests) TestBatch(c *C) {
+	d := data.NewEmpty(2, 50, 2)
+	rng := rand.New(rand.NewSource(1))
+	for i := range d.Y {
+		d.X.Set(i, 0, rng.Float64())
+		d.X.Set(i, 1, rng.Float64())
+		if d.X.At(i, 0)+d.X.At(i, 1) > 1 {
+			d.Y[i] = 1
+		}
+	}
+	f := &RandomForestFactory{
+		RandomTree: RandomTree{
+			NumSamples: 0.7,
+			NumLeaves:  5,
+			SplitScore: impurity.Gini,
+		},
+		NumTrees: 9,
+	}
+	cfr := f.New().FromData(d)
+	bc, ok := cfr.(classification.BatchClassifier)
+	c.Assert(ok, Equals, true)
+
+	prob := matrix.NewFloat64(d.NRow(), 2, 0, nil)
+	bc.EstimateClassProbabilitiesBatch(d.X, prob)
+	for i := 0; i < d.NRow(); i++ {
+		expected := cfr.EstimateClassProbabilities(d.X.Row(i))
+		for k, p := range prob.Row(i) {
+			c.Check(math.Abs(p-expected[k]) < 1e-12, Equals, true)
+		}
+	}
+
+	c.Check(func() {
+		bc.EstimateClassProbabilitiesBatch(d.X, matrix.NewFloat64(d.NRow()-1, 2, 0, nil))
+	}, PanicMatches, "wrong shape.*")
+	c.Check(func() {
+		bc.EstimateClassProbabilitiesBatch(d.X, matrix.NewFloat64(d.NRow(), 3, 0, nil))
+	}, PanicMatches, "wrong shape.*")
+}
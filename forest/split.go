@@ -9,6 +9,9 @@ import (
 	"seehuhn.de/go/classification/matrix"
 )
 
+// findBestSplit returns the best split of the data `d`, using a
+// random subset of the columns.  If none of the selected columns
+// allows to split the data, nil is returned.
 func (f *RandomTree) findBestSplit(rng *rand.Rand, d *data.Data, hist data.Histogram) *searchResult {
 	best := &searchResult{
 		Left: &data.Data{
@@ -37,9 +40,11 @@ func (f *RandomTree) findBestSplit(rng *rand.Rand, d *data.Data, hist data.Histo
 		leftHist := make(data.Histogram, len(hist))
 		var rightHist = copyFloatSlice(hist)
 		for i := 1; i < len(rows); i++ {
-			yi := d.Y[rows[i-1]]
-			leftHist[yi]++ // TODO(voss): use the weights here!
-			rightHist[yi]--
+			row := rows[i-1]
+			yi := d.Y[row]
+			wi := d.Weight(row)
+			leftHist[yi] += wi
+			rightHist[yi] -= wi
 
 			left := d.X.At(rows[i-1], col)
 			right := d.X.At(rows[i], col)
@@ -64,6 +69,9 @@ func (f *RandomTree) findBestSplit(rng *rand.Rand, d *data.Data, hist data.Histo
 			}
 		}
 	}
+	if first {
+		return nil
+	}
 	return best
 }
 
@@ -102,6 +110,7 @@ func copyFloatSlice(src []float64) []float64 {
 }
 
 // subset returns a random subset of {0, 1, ..., p-1} with m elements.
+// The caller must ensure that m <= p.
 func subset(r *rand.Rand, m, p int) []int {
 	if m > p {
 		panic("invalid subset size")
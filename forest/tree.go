@@ -1,6 +1,7 @@
 package forest
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -27,11 +28,59 @@ func (f *RandomTree) GetName() string {
 		f.NumSamples, f.NumLeaves, f.NumColumns)
 }
 
+// FromDataRandom grows a random tree.  The method panics if the data
+// or the parameters are invalid; use CheckedFromDataRandom to get an
+// error instead.
 func (f *RandomTree) FromDataRandom(d *data.Data, rng *rand.Rand) classification.Classifier {
+	c, err := f.CheckedFromDataRandom(d, rng)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// check verifies the data and the parameters.
+func (f *RandomTree) check(d *data.Data) error {
+	if err := d.Check(); err != nil {
+		return err
+	}
+	switch {
+	case !(f.NumSamples > 0 && f.NumSamples <= 1):
+		return fmt.Errorf("NumSamples=%g: %w", f.NumSamples, classification.ErrInvalidParameter)
+	case f.NumLeaves < 1:
+		return fmt.Errorf("NumLeaves=%d: %w", f.NumLeaves, classification.ErrInvalidParameter)
+	case f.NumColumns < 0 || f.NumColumns > d.NCol():
+		return fmt.Errorf("NumColumns=%d for %d columns: %w",
+			f.NumColumns, d.NCol(), classification.ErrInvalidParameter)
+	case f.SplitScore == nil:
+		return fmt.Errorf("missing SplitScore: %w", classification.ErrInvalidParameter)
+	}
+	if numSamples := int(float64(d.NRow()) * f.NumSamples); numSamples < 1 {
+		return fmt.Errorf("%d samples with NumSamples=%g: %w",
+			d.NRow(), f.NumSamples, data.ErrTooFewSamples)
+	}
+	return nil
+}
+
+// CheckedFromDataRandom grows a random tree.  This implements the
+// bagging.CheckedRandomFactory interface.
+func (f *RandomTree) CheckedFromDataRandom(d *data.Data, rng *rand.Rand) (classification.Classifier, error) {
+	return f.FromDataRandomContext(context.Background(), d, rng)
+}
+
+// FromDataRandomContext grows a random tree, stopping early if `ctx`
+// is cancelled.  This implements the bagging.ContextRandomFactory
+// interface.
+func (f *RandomTree) FromDataRandomContext(ctx context.Context, d *data.Data, rng *rand.Rand) (classification.Classifier, error) {
+	if err := f.check(d); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+
 	numSamples := int(float64(d.NRow()) * f.NumSamples)
 	sample := d.SampleWithoutReplacement(numSamples, rng)
 	root := &tree.Tree{
 		Hist: sample.GetHist(),
+		Info: classification.NewInfo(d),
 	}
 	todo := f.NumLeaves - 1
 
@@ -40,11 +89,22 @@ func (f *RandomTree) FromDataRandom(d *data.Data, rng *rand.Rand) classification
 	}
 	var next []leaf
 	for todo > 0 && len(current) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		i := rng.Intn(len(current))
 		this := current[i]
 		current = append(current[:i], current[i+1:]...)
 
 		best := f.findBestSplit(rng, this.d, this.node.Hist)
+		if best == nil {
+			// The node cannot be split and remains a leaf.
+			if len(current) == 0 {
+				current = next
+				next = nil
+			}
+			continue
+		}
 
 		leftChild := &tree.Tree{
 			Hist: best.LeftHist,
@@ -71,5 +131,5 @@ func (f *RandomTree) FromDataRandom(d *data.Data, rng *rand.Rand) classification
 		}
 	}
 
-	return root
+	return root, nil
 }
@@ -0,0 +1,327 @@
+package gaussian
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// defaultVarSmoothing is the default value for NaiveBayes.VarSmoothing.
+const defaultVarSmoothing = 1e-9
+
+// NaiveBayes is a classification.Factory for Gaussian naive Bayes
+// classifiers, which assume the input variables to be independent
+// within each class.
+type NaiveBayes struct {
+	// Priors, if non-nil, gives the prior class probabilities.  The
+	// default is to use the class frequencies in the training data.
+	Priors []float64
+
+	// VarSmoothing is added to all variances, as a multiple of the
+	// largest variance of the input variables.  This avoids problems
+	// with input variables which are constant within a class.  The
+	// default is 1e-9.
+	VarSmoothing float64
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *NaiveBayes) GetName() string {
+	return "Gaussian naive Bayes"
+}
+
+// FromData fits a Gaussian naive Bayes classifier to the training
+// data.
+func (f *NaiveBayes) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// ClassifierFromData fits a Gaussian naive Bayes classifier to the
+// training data.
+func (f *NaiveBayes) ClassifierFromData(d *data.Data) *Classifier {
+	s := getClassStats(d)
+	p := s.p
+
+	variances := make([][]float64, d.NumClasses)
+	for k, mean := range s.means {
+		if mean != nil {
+			variances[k] = make([]float64, p)
+		}
+	}
+	for _, row := range d.GetRows() {
+		y := d.Y[row]
+		if variances[y] == nil {
+			continue
+		}
+		w := d.Weight(row)
+		for j, xj := range d.X.Row(row) {
+			delta := xj - s.means[y][j]
+			variances[y][j] += w * delta * delta
+		}
+	}
+	epsilon := f.VarSmoothing
+	if epsilon == 0 {
+		epsilon = defaultVarSmoothing
+	}
+	largest := 0.0
+	for _, v := range overallVariances(d, p) {
+		if v > largest {
+			largest = v
+		}
+	}
+	if largest <= 0 {
+		largest = 1
+	}
+	for k, v := range variances {
+		for j := range v {
+			v[j] = v[j]/s.weight[k] + epsilon*largest
+		}
+	}
+
+	return &Classifier{
+		Priors:    getPriors(d, f.Priors),
+		Means:     s.means,
+		variances: variances,
+		info:      classification.NewInfo(d),
+	}
+}
+
+// overallVariances returns the variances of the input variables,
+// ignoring class information.
+func overallVariances(d *data.Data, p int) []float64 {
+	mean := make([]float64, p)
+	total := 0.0
+	for _, row := range d.GetRows() {
+		w := d.Weight(row)
+		for j, xj := range d.X.Row(row) {
+			mean[j] += w * xj
+		}
+		total += w
+	}
+	for j := range mean {
+		mean[j] /= total
+	}
+	res := make([]float64, p)
+	for _, row := range d.GetRows() {
+		w := d.Weight(row)
+		for j, xj := range d.X.Row(row) {
+			delta := xj - mean[j]
+			res[j] += w * delta * delta
+		}
+	}
+	for j := range res {
+		res[j] /= total
+	}
+	return res
+}
+
+// LDA is a classification.Factory for linear discriminant analysis,
+// which assumes the input variables to be normally distributed with
+// a class-dependent mean and a covariance matrix shared by all
+// classes.
+type LDA struct {
+	// Priors, if non-nil, gives the prior class probabilities.  The
+	// default is to use the class frequencies in the training data.
+	Priors []float64
+
+	// Shrinkage, between 0 and 1, shrinks the estimated covariance
+	// matrix S towards a multiple of the identity matrix, using
+	// (1-Shrinkage)*S + Shrinkage*mu*I where mu is the average
+	// variance.  If the resulting matrix is still singular, a small
+	// multiple of the identity matrix is added.
+	Shrinkage float64
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *LDA) GetName() string {
+	if f.Shrinkage > 0 {
+		return fmt.Sprintf("LDA, shrinkage %g", f.Shrinkage)
+	}
+	return "LDA"
+}
+
+// FromData fits a linear discriminant analysis classifier to the
+// training data.
+func (f *LDA) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// ClassifierFromData fits a linear discriminant analysis classifier to
+// the training data.
+func (f *LDA) ClassifierFromData(d *data.Data) *Classifier {
+	s := getClassStats(d)
+	cov := matrix.NewFloat64(s.p, s.p, 0, nil)
+	total := 0.0
+	for _, row := range d.GetRows() {
+		y := d.Y[row]
+		w := d.Weight(row)
+		addOuter(cov, w, d.X.Row(row), s.means[y])
+		total += w
+	}
+	scaleLower(cov, 1/total)
+
+	return &Classifier{
+		Priors: getPriors(d, f.Priors),
+		Means:  s.means,
+		pooled: regularise(cov, f.Shrinkage, averageVariance(cov)),
+		info:   classification.NewInfo(d),
+	}
+}
+
+// QDA is a classification.Factory for quadratic discriminant
+// analysis, which assumes the input variables to be normally
+// distributed with a class-dependent mean and a class-dependent
+// covariance matrix.
+type QDA struct {
+	// Priors, if non-nil, gives the prior class probabilities.  The
+	// default is to use the class frequencies in the training data.
+	Priors []float64
+
+	// Shrinkage, between 0 and 1, shrinks the covariance matrix S of
+	// each class towards a multiple of the identity matrix, using
+	// (1-Shrinkage)*S + Shrinkage*mu*I where mu is the average
+	// variance within classes.  If the resulting matrix is still
+	// singular, a small multiple of the identity matrix is added.
+	Shrinkage float64
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *QDA) GetName() string {
+	if f.Shrinkage > 0 {
+		return fmt.Sprintf("QDA, shrinkage %g", f.Shrinkage)
+	}
+	return "QDA"
+}
+
+// FromData fits a quadratic discriminant analysis classifier to the
+// training data.
+func (f *QDA) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// ClassifierFromData fits a quadratic discriminant analysis classifier
+// to the training data.
+func (f *QDA) ClassifierFromData(d *data.Data) *Classifier {
+	s := getClassStats(d)
+	K := d.NumClasses
+
+	covs := make([]*matrix.Float64, K)
+	for k, mean := range s.means {
+		if mean != nil {
+			covs[k] = matrix.NewFloat64(s.p, s.p, 0, nil)
+		}
+	}
+	for _, row := range d.GetRows() {
+		y := d.Y[row]
+		if covs[y] == nil {
+			continue
+		}
+		addOuter(covs[y], d.Weight(row), d.X.Row(row), s.means[y])
+	}
+
+	// The average within-class variance is used as the scale for
+	// regularisation, so that classes with few samples can still
+	// be handled.
+	mu := 0.0
+	total := 0.0
+	for k, cov := range covs {
+		if cov == nil {
+			continue
+		}
+		mu += averageVariance(cov)
+		total += s.weight[k]
+	}
+	mu /= total
+
+	perClass := make([]*matrix.Cholesky, K)
+	for k, cov := range covs {
+		if cov == nil {
+			continue
+		}
+		scaleLower(cov, 1/s.weight[k])
+		perClass[k] = regularise(cov, f.Shrinkage, mu)
+	}
+
+	return &Classifier{
+		Priors:   getPriors(d, f.Priors),
+		Means:    s.means,
+		perClass: perClass,
+		info:     classification.NewInfo(d),
+	}
+}
+
+// addOuter adds w*(x-mean)(x-mean)^T to the lower triangle of `cov`.
+func addOuter(cov *matrix.Float64, w float64, x, mean []float64) {
+	for i, xi := range x {
+		di := w * (xi - mean[i])
+		row := cov.Row(i)
+		for j := 0; j <= i; j++ {
+			row[j] += di * (x[j] - mean[j])
+		}
+	}
+}
+
+// scaleLower multiplies the lower triangle of `cov` by `a`.
+func scaleLower(cov *matrix.Float64, a float64) {
+	p, _ := cov.Shape()
+	for i := 0; i < p; i++ {
+		row := cov.Row(i)
+		for j := 0; j <= i; j++ {
+			row[j] *= a
+		}
+	}
+}
+
+func averageVariance(cov *matrix.Float64) float64 {
+	p, _ := cov.Shape()
+	if p == 0 {
+		return 0
+	}
+	res := 0.0
+	for i := 0; i < p; i++ {
+		res += cov.At(i, i)
+	}
+	return res / float64(p)
+}
+
+// maxJitterSteps limits the number of attempts to make a covariance
+// matrix positive definite.  The last attempt adds 1e10*mu*I.
+const maxJitterSteps = 21
+
+// regularise applies shrinkage to the covariance matrix `cov` (only
+// the lower triangle is used) and returns its Cholesky decomposition.
+// If the matrix is singular, increasing multiples of mu*I are added
+// until the decomposition succeeds.  The function panics if the
+// covariance matrix is not finite, for example because the inputs
+// contain NaN or infinite values.
+func regularise(cov *matrix.Float64, shrinkage, mu float64) *matrix.Cholesky {
+	p, _ := cov.Shape()
+	if math.IsNaN(mu) || math.IsInf(mu, 0) {
+		panic("covariance matrix is not finite")
+	}
+	if mu <= 0 {
+		mu = 1
+	}
+	if shrinkage > 0 {
+		scaleLower(cov, 1-shrinkage)
+		for i := 0; i < p; i++ {
+			cov.Set(i, i, cov.At(i, i)+shrinkage*mu)
+		}
+	}
+
+	chol, err := matrix.NewCholesky(cov)
+	jitter := 1e-10 * mu
+	for k := 0; err != nil && k < maxJitterSteps; k++ {
+		for i := 0; i < p; i++ {
+			cov.Set(i, i, cov.At(i, i)+jitter)
+		}
+		chol, err = matrix.NewCholesky(cov)
+		jitter *= 10
+	}
+	if err != nil {
+		panic(err)
+	}
+	return chol
+}
@@ -0,0 +1,163 @@
+// Package gaussian implements classifiers which model the
+// distribution of the inputs within each class by a normal
+// distribution: Gaussian naive Bayes, linear discriminant analysis
+// (LDA) and quadratic discriminant analysis (QDA).
+//
+// Class probabilities are obtained from Bayes' rule, using class
+// priors which are either estimated from the training data or
+// supplied by the user.
+package gaussian
+
+import (
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Classifier is a fitted Gaussian generative model.
+type Classifier struct {
+	// Priors gives the prior probability of each class.
+	Priors []float64
+
+	// Means gives the mean input vector for each class.  Classes with
+	// prior probability zero have a nil mean.
+	Means [][]float64
+
+	// Exactly one of the following three fields is used, depending
+	// on the type of model.
+	variances [][]float64        // naive Bayes: one vector per class
+	pooled    *matrix.Cholesky   // LDA: shared covariance
+	perClass  []*matrix.Cholesky // QDA: one covariance per class
+
+	info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.info
+}
+
+// logDensities returns the logarithm of prior times class density at
+// `x`, for every class, up to a common additive constant.
+func (c *Classifier) logDensities(x []float64) []float64 {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	res := make([]float64, len(c.Priors))
+	delta := make([]float64, len(x))
+	for k, prior := range c.Priors {
+		mean := c.Means[k]
+		if prior <= 0 || mean == nil {
+			res[k] = math.Inf(-1)
+			continue
+		}
+		for j, xj := range x {
+			delta[j] = xj - mean[j]
+		}
+
+		var l float64
+		switch {
+		case c.variances != nil:
+			for j, dj := range delta {
+				v := c.variances[k][j]
+				l -= 0.5 * (dj*dj/v + math.Log(v))
+			}
+		case c.pooled != nil:
+			y := c.pooled.SolveLower(delta)
+			for _, yj := range y {
+				l -= 0.5 * yj * yj
+			}
+		default:
+			chol := c.perClass[k]
+			y := chol.SolveLower(delta)
+			for _, yj := range y {
+				l -= 0.5 * yj * yj
+			}
+			l -= 0.5 * chol.LogDet()
+		}
+		res[k] = l + math.Log(prior)
+	}
+	return res
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	l := c.logDensities(x)
+	max := math.Inf(-1)
+	for _, lk := range l {
+		if lk > max {
+			max = lk
+		}
+	}
+	prob := make(data.Histogram, len(l))
+	if math.IsInf(max, -1) {
+		copy(prob, c.Priors)
+		return prob
+	}
+	sum := 0.0
+	for k, lk := range l {
+		prob[k] = math.Exp(lk - max)
+		sum += prob[k]
+	}
+	for k := range prob {
+		prob[k] /= sum
+	}
+	return prob
+}
+
+// classStats holds the weighted class totals and means of a data set.
+type classStats struct {
+	p      int
+	weight []float64   // total weight per class
+	means  [][]float64 // nil for classes without samples
+}
+
+func getClassStats(d *data.Data) *classStats {
+	K := d.NumClasses
+	p := d.NCol()
+	s := &classStats{
+		p:      p,
+		weight: make([]float64, K),
+		means:  make([][]float64, K),
+	}
+	for _, row := range d.GetRows() {
+		w := d.Weight(row)
+		y := d.Y[row]
+		if s.means[y] == nil {
+			s.means[y] = make([]float64, p)
+		}
+		for j, xj := range d.X.Row(row) {
+			s.means[y][j] += w * xj
+		}
+		s.weight[y] += w
+	}
+	for k, mean := range s.means {
+		if mean == nil {
+			continue
+		}
+		if s.weight[k] <= 0 {
+			s.means[k] = nil
+			continue
+		}
+		for j := range mean {
+			mean[j] /= s.weight[k]
+		}
+	}
+	return s
+}
+
+// getPriors returns the class priors, either taken from `priors` or
+// estimated from the class frequencies of `d`.
+func getPriors(d *data.Data, priors []float64) []float64 {
+	if priors == nil {
+		return d.GetHist().Probabilities()
+	}
+	if len(priors) != d.NumClasses {
+		panic("wrong number of class priors")
+	}
+	return data.Histogram(priors).Probabilities()
+}
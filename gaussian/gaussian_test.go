@@ -0,0 +1,110 @@
+package gaussian
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// twoClasses returns samples from two normal distributions in the
+// plane, with means (0,0) and (2,1) and correlated coordinates.  The
+// third input variable is constant.
+func twoClasses(n int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(2, n, 3)
+	for i := 0; i < n; i++ {
+		y := rng.Intn(2)
+		z0 := rng.NormFloat64()
+		z1 := rng.NormFloat64()
+		x := d.X.Row(i)
+		x[0] = z0 + 2*float64(y)
+		x[1] = 0.8*z0 + 0.6*z1 + float64(y)
+		x[2] = 7
+		d.Y[i] = y
+	}
+	return d
+}
+
+func (*Tests) TestFactories(c *C) {
+	train := twoClasses(2000, 1)
+	test := twoClasses(2000, 2)
+	set := data.MakeSet("test", train, test)
+
+	methods := []classification.Factory{
+		&NaiveBayes{},
+		&LDA{},
+		&QDA{},
+		&LDA{Shrinkage: 0.1},
+		&QDA{Shrinkage: 0.1},
+	}
+	for _, m := range methods {
+		res := classification.Assess(m, set, loss.ZeroOne)
+		c.Assert(res.Err, IsNil)
+		// The Bayes error rate is Phi(-1) = 0.159.
+		c.Check(res.MeanLoss < 0.2, Equals, true,
+			Commentf("%s: loss %g", m.GetName(), res.MeanLoss))
+	}
+}
+
+func (*Tests) TestLDAEqualsQDA(c *C) {
+	// With equal covariance matrices, LDA and QDA should agree
+	// closely for large samples.
+	train := twoClasses(20000, 3)
+	lda := (&LDA{}).ClassifierFromData(train)
+	qda := (&QDA{}).ClassifierFromData(train)
+	x := []float64{1, 0.5, 7}
+	p1 := lda.EstimateClassProbabilities(x)
+	p2 := qda.EstimateClassProbabilities(x)
+	c.Check(math.Abs(p1[0]-p2[0]) < 0.05, Equals, true)
+	c.Check(math.Abs(p1[0]-0.5) < 0.05, Equals, true)
+}
+
+func (*Tests) TestPriors(c *C) {
+	train := twoClasses(500, 4)
+	x := []float64{1, 0.5, 7}
+	for _, prior := range []float64{0.1, 0.5, 0.9} {
+		priors := []float64{prior, 1 - prior}
+		nb := (&NaiveBayes{Priors: priors}).ClassifierFromData(train)
+		c.Check(nb.Priors, DeepEquals, priors)
+		lda := (&LDA{Priors: priors}).ClassifierFromData(train)
+		if prior < 0.5 {
+			c.Check(lda.EstimateClassProbabilities(x).ArgMax(), Equals, 1)
+		} else if prior > 0.5 {
+			c.Check(lda.EstimateClassProbabilities(x).ArgMax(), Equals, 0)
+		}
+	}
+}
+
+func (*Tests) TestEmptyClass(c *C) {
+	train := twoClasses(100, 5)
+	train.NumClasses = 3
+	for _, m := range []classification.Factory{&NaiveBayes{}, &LDA{}, &QDA{}} {
+		prob := m.FromData(train).EstimateClassProbabilities([]float64{0, 0, 7})
+		c.Check(prob, HasLen, 3)
+		c.Check(prob[2], Equals, 0.0)
+		c.Check(math.Abs(prob.Sum()-1) < 1e-12, Equals, true)
+	}
+}
+
+func (*Tests) TestNonFinite(c *C) {
+	for _, bad := range []float64{math.NaN(), math.Inf(+1)} {
+		train := twoClasses(100, 6)
+		train.X.Set(3, 1, bad)
+		for _, m := range []classification.Factory{&LDA{}, &QDA{}} {
+			_, err := classification.Checked(m).CheckedFromData(train)
+			c.Check(err, NotNil)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package gbm
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/model"
+)
+
+func init() {
+	model.Register(&Classifier{}, &model.Codec{
+		Tag:     "gbm",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			body, err := c.(*Classifier).MarshalBinary()
+			if err != nil {
+				return err
+			}
+			w.Bytes(body)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			body := r.Bytes()
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			c := &Classifier{}
+			err := c.UnmarshalBinary(body)
+			if err != nil {
+				return nil, err
+			}
+			// The encoded body includes the metadata, so `info` is
+			// not used here.
+			return c, nil
+		},
+	})
+}
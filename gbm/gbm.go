@@ -0,0 +1,410 @@
+// Package gbm implements gradient boosting of regression trees for
+// classification, using the multinomial deviance as the loss function.
+//
+// The algorithm follows "Greedy Function Approximation: A Gradient
+// Boosting Machine" by J. H. Friedman (Annals of Statistics, 2001):
+// in every round, one regression tree per class is fitted to the
+// gradient of the multinomial deviance, and the leaf values are
+// chosen using a single Newton-Raphson step.
+package gbm
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/matrix"
+)
+
+const gbmSeed = 1517409153
+
+// Factory stores the parameters for gradient boosting.  Any zero field
+// values are interpreted as the corresponding values from the
+// [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Rounds gives the maximal number of boosting rounds.  Every round
+	// adds one regression tree per class.
+	Rounds int
+
+	// LearningRate ("shrinkage") is used to scale the contribution of
+	// each tree.
+	LearningRate float64
+
+	// MaxDepth limits the depth of the regression trees.
+	MaxDepth int
+
+	// MinLeafSize gives the minimal (weighted) number of samples in
+	// each leaf of the regression trees.
+	MinLeafSize float64
+
+	// RowFraction gives the fraction of the training samples used to
+	// fit each tree (stochastic gradient boosting).  The samples are
+	// chosen without replacement, independently for each round.
+	RowFraction float64
+
+	// ColumnFraction gives the fraction of input variables considered
+	// when fitting each tree.
+	ColumnFraction float64
+
+	// ValidationFraction, if positive, gives the fraction of the
+	// training samples which are held out as a validation set.  The
+	// validation set is used for early stopping.  If the validation
+	// set would be empty or contain all samples, no samples are held
+	// out.  The default is to use all samples for training and to not
+	// stop early.
+	ValidationFraction float64
+
+	// Patience gives the number of rounds without improvement of the
+	// validation loss after which boosting is stopped.  This is only
+	// used if `ValidationFraction` is positive.
+	Patience int
+
+	// Seed is used to initialise the random number generator for
+	// subsampling and for the choice of validation set.
+	Seed int64
+}
+
+// DefaultFactory specifies the default parameters for gradient
+// boosting.
+var DefaultFactory = &Factory{
+	Name:           "gradient boosting",
+	Rounds:         100,
+	LearningRate:   0.1,
+	MaxDepth:       3,
+	MinLeafSize:    5,
+	RowFraction:    1,
+	ColumnFraction: 1,
+	Patience:       10,
+	Seed:           gbmSeed,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	return fmt.Sprintf("gradient boosting %d/%g/%d",
+		f.Rounds, f.LearningRate, f.MaxDepth)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.Rounds == 0 {
+		res.Rounds = DefaultFactory.Rounds
+	}
+	if res.LearningRate == 0 {
+		res.LearningRate = DefaultFactory.LearningRate
+	}
+	if res.MaxDepth == 0 {
+		res.MaxDepth = DefaultFactory.MaxDepth
+	}
+	if res.MinLeafSize == 0 {
+		res.MinLeafSize = DefaultFactory.MinLeafSize
+	}
+	if res.RowFraction == 0 {
+		res.RowFraction = DefaultFactory.RowFraction
+	}
+	if res.ColumnFraction == 0 {
+		res.ColumnFraction = DefaultFactory.ColumnFraction
+	}
+	if res.Patience == 0 {
+		res.Patience = DefaultFactory.Patience
+	}
+	if res.Seed == 0 {
+		res.Seed = DefaultFactory.Seed
+	}
+	return &res
+}
+
+// FromData constructs a new gradient boosting classifier from
+// training data.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.BoostFromData(d)
+}
+
+// BoostFromData constructs a new gradient boosting classifier from
+// training data.  If early stopping is used, the returned classifier
+// is truncated to the round with the smallest validation loss.
+func (f *Factory) BoostFromData(d *data.Data) *Classifier {
+	f = f.setDefaults()
+	rng := rand.New(rand.NewSource(f.Seed))
+
+	K := d.NumClasses
+	p := d.NCol()
+	rows := d.GetRows()
+
+	var trainRows, validRows []int
+	nValid := int(f.ValidationFraction * float64(len(rows)))
+	if nValid > 0 && nValid < len(rows) {
+		shuffled := make([]int, len(rows))
+		copy(shuffled, rows)
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		validRows = shuffled[:nValid]
+		trainRows = shuffled[nValid:]
+	} else {
+		trainRows = rows
+	}
+
+	// initial values: log class frequencies
+	prior := make(data.Histogram, K)
+	for _, row := range trainRows {
+		prior[d.Y[row]] += d.Weight(row)
+	}
+	prior = prior.Probabilities()
+	res := &Classifier{
+		NumClasses:   K,
+		LearningRate: f.LearningRate,
+		Init:         make([]float64, K),
+		Info:         classification.NewInfo(d),
+	}
+	for k, pk := range prior {
+		res.Init[k] = math.Log(math.Max(pk, 1e-10))
+	}
+
+	// current values of the decision functions
+	nX := len(d.Y)
+	F := make([][]float64, nX)
+	for _, rr := range [][]int{trainRows, validRows} {
+		for _, row := range rr {
+			F[row] = make([]float64, K)
+			copy(F[row], res.Init)
+		}
+	}
+
+	grower := &treeGrower{
+		sorted:      newPresorted(d.X, trainRows),
+		maxDepth:    f.MaxDepth,
+		minLeafSize: f.MinLeafSize,
+		nodeOf:      make([]int, nX),
+		w:           make([]float64, nX),
+		r:           make([]float64, nX),
+	}
+	prob := make([][]float64, nX)
+	for _, row := range trainRows {
+		prob[row] = make([]float64, K)
+		grower.w[row] = d.Weight(row)
+	}
+
+	allColumns := make([]int, p)
+	for j := range allColumns {
+		allColumns[j] = j
+	}
+	nCols := int(math.Ceil(f.ColumnFraction * float64(p)))
+	if nCols > p {
+		nCols = p
+	}
+	nSample := int(math.Ceil(f.RowFraction * float64(len(trainRows))))
+	if nSample > len(trainRows) {
+		nSample = len(trainRows)
+	}
+
+	bestRound := 0
+	bestValid := math.Inf(+1)
+	kk := float64(K)
+	for m := 0; m < f.Rounds; m++ {
+		for _, row := range trainRows {
+			matrix.Softmax(prob[row], F[row])
+		}
+
+		// select the rows and columns used in this round
+		for i := range grower.nodeOf {
+			grower.nodeOf[i] = -1
+		}
+		if nSample < len(trainRows) {
+			for _, i := range rng.Perm(len(trainRows))[:nSample] {
+				grower.nodeOf[trainRows[i]] = 0
+			}
+		} else {
+			for _, row := range trainRows {
+				grower.nodeOf[row] = 0
+			}
+		}
+		active := make([]int, 0, nSample)
+		for _, row := range trainRows {
+			if grower.nodeOf[row] == 0 {
+				active = append(active, row)
+			}
+		}
+		columns := allColumns
+		if nCols < p {
+			columns = rng.Perm(p)[:nCols]
+		}
+
+		trees := make([]*Node, K)
+		for k := 0; k < K; k++ {
+			for _, row := range active {
+				yk := 0.0
+				if d.Y[row] == k {
+					yk = 1
+				}
+				grower.r[row] = yk - prob[row][k]
+				grower.nodeOf[row] = 0
+			}
+			trees[k] = grower.grow(columns, func(leafRows []int) float64 {
+				num := 0.0
+				den := 0.0
+				for _, row := range leafRows {
+					w := grower.w[row]
+					r := grower.r[row]
+					num += w * r
+					den += w * math.Abs(r) * (1 - math.Abs(r))
+				}
+				if den < 1e-12 {
+					return 0
+				}
+				return (kk - 1) / kk * num / den
+			})
+		}
+		res.Trees = append(res.Trees, trees)
+
+		for _, rr := range [][]int{trainRows, validRows} {
+			for _, row := range rr {
+				x := d.X.Row(row)
+				for k, t := range trees {
+					F[row][k] += f.LearningRate * t.Eval(x)
+				}
+			}
+		}
+
+		res.TrainLoss = append(res.TrainLoss, meanDeviance(d, trainRows, F))
+		if validRows != nil {
+			validLoss := meanDeviance(d, validRows, F)
+			res.ValidLoss = append(res.ValidLoss, validLoss)
+			if validLoss < bestValid {
+				bestValid = validLoss
+				bestRound = m + 1
+			} else if m+1-bestRound >= f.Patience {
+				break
+			}
+		}
+	}
+
+	if validRows != nil {
+		res.Trees = res.Trees[:bestRound]
+	}
+	return res
+}
+
+// meanDeviance returns the weighted average multinomial deviance for
+// the given rows, where `F` gives the values of the decision functions.
+func meanDeviance(d *data.Data, rows []int, F [][]float64) float64 {
+	if len(rows) == 0 {
+		return math.NaN()
+	}
+	prob := make([]float64, d.NumClasses)
+	total := 0.0
+	totalWeight := 0.0
+	for _, row := range rows {
+		w := d.Weight(row)
+		matrix.Softmax(prob, F[row])
+		total += w * loss.Deviance(d.Y[row], prob)
+		totalWeight += w
+	}
+	return total / totalWeight
+}
+
+// Classifier is the result of gradient boosting.
+type Classifier struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// LearningRate is the factor applied to the output of each tree.
+	LearningRate float64
+
+	// Init gives the initial values of the decision functions, one
+	// per class.
+	Init []float64
+
+	// Trees stores the regression trees, one slice of `NumClasses`
+	// trees per boosting round.
+	Trees [][]*Node
+
+	// TrainLoss gives the average multinomial deviance on the training
+	// samples after each round.
+	TrainLoss []float64
+
+	// ValidLoss gives the average multinomial deviance on the
+	// validation samples after each round.  This is nil if no
+	// validation set was used.
+	ValidLoss []float64
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// Rounds returns the number of boosting rounds used by the classifier.
+func (c *Classifier) Rounds() int {
+	return len(c.Trees)
+}
+
+// Truncate returns a new classifier which uses only the trees from the
+// first `m` boosting rounds of `c`.
+func (c *Classifier) Truncate(m int) *Classifier {
+	if m > len(c.Trees) {
+		m = len(c.Trees)
+	}
+	res := *c
+	res.Trees = c.Trees[:m]
+	return &res
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	F := make([]float64, c.NumClasses)
+	copy(F, c.Init)
+	for _, trees := range c.Trees {
+		for k, t := range trees {
+			F[k] += c.LearningRate * t.Eval(x)
+		}
+	}
+	prob := make(data.Histogram, c.NumClasses)
+	matrix.Softmax(prob, F)
+	return prob
+}
+
+// StagedLoss returns the average loss on the data set `d` after each
+// boosting round.  Element m-1 of the result gives the loss obtained
+// using the trees from the first m rounds.
+func (c *Classifier) StagedLoss(d *data.Data, L loss.Function) []float64 {
+	res := make([]float64, len(c.Trees))
+	F := make([]float64, c.NumClasses)
+	prob := make(data.Histogram, c.NumClasses)
+	total := 0.0
+	for _, row := range d.GetRows() {
+		w := d.Weight(row)
+		x := d.X.Row(row)
+		copy(F, c.Init)
+		for m, trees := range c.Trees {
+			for k, t := range trees {
+				F[k] += c.LearningRate * t.Eval(x)
+			}
+			matrix.Softmax(prob, F)
+			res[m] += w * L(d.Y[row], prob)
+		}
+		total += w
+	}
+	for m := range res {
+		res[m] /= total
+	}
+	return res
+}
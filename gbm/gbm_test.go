@@ -0,0 +1,102 @@
+package gbm
+
+import (
+	"bytes"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestBoosting(c *C) {
+	train := data.NewDiagonal(500, 1)
+	test := data.NewDiagonal(500, 2)
+
+	f := &Factory{
+		Rounds:         50,
+		RowFraction:    0.8,
+		ColumnFraction: 0.5,
+	}
+	cfr := f.BoostFromData(train)
+	c.Assert(cfr.Rounds(), Equals, 50)
+	c.Check(cfr.TrainLoss, HasLen, 50)
+	c.Check(cfr.ValidLoss, IsNil)
+	c.Check(cfr.TrainLoss[49] < cfr.TrainLoss[0], Equals, true)
+
+	staged := cfr.StagedLoss(test, loss.ZeroOne)
+	c.Check(staged, HasLen, 50)
+	c.Check(staged[49] < staged[0], Equals, true)
+	c.Check(staged[49] < 0.25, Equals, true, Commentf("loss %g", staged[49]))
+
+	prob := cfr.EstimateClassProbabilities(test.X.Row(0))
+	c.Check(prob.Sum() > 0.999 && prob.Sum() < 1.001, Equals, true)
+	c.Check(cfr.Truncate(49).EstimateClassProbabilities(test.X.Row(0)),
+		Not(DeepEquals), prob)
+}
+
+func (*Tests) TestEarlyStopping(c *C) {
+	train := data.NewDiagonal(300, 3)
+	f := &Factory{
+		Rounds:             1000,
+		LearningRate:       0.5,
+		ValidationFraction: 0.3,
+		Patience:           5,
+	}
+	cfr := f.BoostFromData(train)
+	c.Assert(len(cfr.ValidLoss) < 1000, Equals, true)
+	c.Check(cfr.ValidLoss, HasLen, len(cfr.TrainLoss))
+
+	best := 0
+	for m, l := range cfr.ValidLoss {
+		if l < cfr.ValidLoss[best] {
+			best = m
+		}
+	}
+	c.Check(cfr.Rounds(), Equals, best+1)
+	c.Check(len(cfr.ValidLoss), Equals, best+1+5)
+}
+
+func (*Tests) TestSmallValidationSet(c *C) {
+	// With 9 samples, a validation fraction of 0.1 gives an empty
+	// validation set, so all rounds must be kept.
+	train := data.NewDiagonal(9, 4)
+	f := &Factory{
+		Rounds:             10,
+		ValidationFraction: 0.1,
+	}
+	cfr := f.BoostFromData(train)
+	c.Check(cfr.Rounds(), Equals, 10)
+	c.Check(cfr.ValidLoss, IsNil)
+}
+
+func (*Tests) TestMarshal(c *C) {
+	train := data.NewDiagonal(200, 4)
+	f := &Factory{
+		Rounds:             10,
+		ValidationFraction: 0.2,
+	}
+	cfr := f.BoostFromData(train)
+
+	buf := &bytes.Buffer{}
+	err := cfr.WriteBinary(buf)
+	c.Assert(err, IsNil)
+	cfr2, err := FromFile(buf)
+	c.Assert(err, IsNil)
+	c.Check(cfr2, DeepEquals, cfr)
+
+	raw, err := cfr.MarshalBinary()
+	c.Assert(err, IsNil)
+	cfr3 := &Classifier{}
+	c.Check(cfr3.UnmarshalBinary(raw[:len(raw)-1]), NotNil)
+	c.Check(cfr3.UnmarshalBinary(append(raw, 0)), Equals, ErrEncoding)
+	c.Check(cfr3.UnmarshalBinary(raw), IsNil)
+	c.Check(cfr3, DeepEquals, cfr)
+}
@@ -0,0 +1,411 @@
+package gbm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"seehuhn.de/go/classification"
+)
+
+// ErrEncoding is returned by `Classifier.UnmarshalBinary` if the input
+// is malformed.
+var ErrEncoding = errors.New("cannot decode binary gradient boosting representation")
+
+// ErrVersion is returned by `Classifier.UnmarshalBinary` if an
+// unknown encoding version is encountered.
+var ErrVersion = errors.New("unknown gradient boosting file format version")
+
+const binaryFormatTag = "JVGB"
+const binaryFormatVersion = 1
+
+// limits used to reject malformed input
+const (
+	maxClasses = 10000
+	maxColumns = 1 << 24
+	maxRounds  = 1 << 20
+	maxDepth   = 64
+	maxName    = 1 << 16
+)
+
+// MarshalBinary encodes the classifier `c` into a binary form and
+// returns the result.  This method implements the
+// `encoding.BinaryMarshaler` interface.
+func (c *Classifier) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := c.WriteBinary(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteBinary encodes the classifier `c` into a binary form and writes
+// the result to `w`.  The output of this function can be decoded
+// using the `FromFile` function.
+func (c *Classifier) WriteBinary(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	// 1: tag
+	_, err := buf.WriteString(binaryFormatTag)
+	if err != nil {
+		return err
+	}
+
+	// 2: version
+	err = buf.WriteByte(binaryFormatVersion)
+	if err != nil {
+		return err
+	}
+
+	// 3: number of classes, learning rate and initial values
+	K := c.NumClasses
+	err = appendUvarint(buf, uint64(K))
+	if err != nil {
+		return err
+	}
+	err = appendFloats(buf, []float64{c.LearningRate})
+	if err != nil {
+		return err
+	}
+	err = appendFloats(buf, c.Init)
+	if err != nil {
+		return err
+	}
+
+	// 4: metadata
+	err = appendInfo(buf, c.Info)
+	if err != nil {
+		return err
+	}
+
+	// 5: loss curves
+	for _, curve := range [][]float64{c.TrainLoss, c.ValidLoss} {
+		err = appendUvarint(buf, uint64(len(curve)))
+		if err != nil {
+			return err
+		}
+		err = appendFloats(buf, curve)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 6: number of rounds
+	err = appendUvarint(buf, uint64(len(c.Trees)))
+	if err != nil {
+		return err
+	}
+
+	// 7: the trees, K per round
+	for _, trees := range c.Trees {
+		for _, t := range trees {
+			err = appendNode(buf, t)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return buf.Flush()
+}
+
+func appendInfo(buf *bufio.Writer, info *classification.Info) error {
+	if info == nil {
+		info = &classification.Info{}
+	}
+	err := appendUvarint(buf, uint64(info.NumInputs))
+	if err != nil {
+		return err
+	}
+	for _, names := range [][]string{info.FeatureNames, info.ClassNames} {
+		err = appendUvarint(buf, uint64(len(names)))
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if len(name) > maxName {
+				return errors.New("name too long")
+			}
+			err = appendUvarint(buf, uint64(len(name)))
+			if err != nil {
+				return err
+			}
+			_, err = buf.WriteString(name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func appendNode(buf *bufio.Writer, t *Node) error {
+	if t.IsLeaf() {
+		err := buf.WriteByte(0)
+		if err != nil {
+			return err
+		}
+		return appendFloats(buf, []float64{t.Value})
+	}
+
+	err := buf.WriteByte(1)
+	if err != nil {
+		return err
+	}
+	err = appendUvarint(buf, uint64(t.Column))
+	if err != nil {
+		return err
+	}
+	err = appendFloats(buf, []float64{t.Limit})
+	if err != nil {
+		return err
+	}
+	err = appendNode(buf, t.Left)
+	if err != nil {
+		return err
+	}
+	return appendNode(buf, t.Right)
+}
+
+func appendUvarint(buf *bufio.Writer, x uint64) error {
+	tmp := [16]byte{}
+	n := binary.PutUvarint(tmp[:], x)
+	_, err := buf.Write(tmp[:n])
+	return err
+}
+
+func appendFloats(buf *bufio.Writer, x []float64) error {
+	for _, xi := range x {
+		err := binary.Write(buf, binary.LittleEndian, xi)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalBinary decodes the binary representation of a classifier
+// generated by the `MarshalBinary` method.  `UnmarshalBinary`
+// implements the `encoding.BinaryUnmarshaler` interface.
+func (c *Classifier) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	cc, err := FromFile(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return ErrEncoding
+	}
+	*c = *cc
+	return nil
+}
+
+// FromFile reads a binary representation of a gradient boosting
+// classifier from `r`.  The binary data must be generated using a
+// call to the `WriteBinary` method.
+//
+// The function returns `ErrEncoding` if the data read from `r` is
+// invalid, and `ErrVersion` if the data was generated using an
+// incompatible (i.e. newer) version of the classification library.
+func FromFile(r io.Reader) (*Classifier, error) {
+	// Avoid buffering if possible, so that no data beyond the end of
+	// the encoded classifier is consumed.
+	buf, ok := r.(byteReader)
+	if !ok {
+		buf = bufio.NewReader(r)
+	}
+
+	// 1: tag
+	tag := make([]byte, len(binaryFormatTag))
+	_, err := io.ReadFull(buf, tag)
+	if err != nil {
+		return nil, err
+	}
+	if string(tag) != binaryFormatTag {
+		return nil, ErrEncoding
+	}
+
+	// 2: version
+	version, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != binaryFormatVersion {
+		return nil, ErrVersion
+	}
+
+	// 3: number of classes, learning rate and initial values
+	K, err := readUvarint(buf, maxClasses)
+	if err != nil {
+		return nil, err
+	}
+	if K < 1 {
+		return nil, ErrEncoding
+	}
+	c := &Classifier{
+		NumClasses: int(K),
+	}
+	lr, err := readFloats(buf, 1)
+	if err != nil {
+		return nil, err
+	}
+	c.LearningRate = lr[0]
+	c.Init, err = readFloats(buf, c.NumClasses)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4: metadata
+	c.Info, err = readInfo(buf, c.NumClasses)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5: loss curves
+	for _, curve := range []*[]float64{&c.TrainLoss, &c.ValidLoss} {
+		n, err := readUvarint(buf, maxRounds)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			*curve, err = readFloats(buf, int(n))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 6: number of rounds
+	rounds, err := readUvarint(buf, maxRounds)
+	if err != nil {
+		return nil, err
+	}
+
+	// 7: the trees, K per round
+	for m := 0; m < int(rounds); m++ {
+		trees := make([]*Node, c.NumClasses)
+		for k := range trees {
+			trees[k], err = readNode(buf, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.Trees = append(c.Trees, trees)
+	}
+	return c, nil
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func readInfo(buf byteReader, K int) (*classification.Info, error) {
+	p, err := readUvarint(buf, maxColumns)
+	if err != nil {
+		return nil, err
+	}
+	var names [2][]string
+	for i, n := range []int{int(p), K} {
+		count, err := readUvarint(buf, maxColumns)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+		if count != uint64(n) {
+			return nil, ErrEncoding
+		}
+		names[i] = make([]string, n)
+		for j := range names[i] {
+			l, err := readUvarint(buf, maxName)
+			if err != nil {
+				return nil, err
+			}
+			name := make([]byte, l)
+			_, err = io.ReadFull(buf, name)
+			if err != nil {
+				return nil, err
+			}
+			names[i][j] = string(name)
+		}
+	}
+	if p == 0 && names[0] == nil && names[1] == nil {
+		return nil, nil
+	}
+	return &classification.Info{
+		NumClasses:   K,
+		NumInputs:    int(p),
+		FeatureNames: names[0],
+		ClassNames:   names[1],
+	}, nil
+}
+
+func readNode(buf byteReader, depth int) (*Node, error) {
+	if depth > maxDepth {
+		return nil, ErrEncoding
+	}
+
+	nodeType, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Node{}
+	switch nodeType {
+	case 0:
+		val, err := readFloats(buf, 1)
+		if err != nil {
+			return nil, err
+		}
+		t.Value = val[0]
+	case 1:
+		col, err := readUvarint(buf, maxColumns)
+		if err != nil {
+			return nil, err
+		}
+		t.Column = int(col)
+		limit, err := readFloats(buf, 1)
+		if err != nil {
+			return nil, err
+		}
+		t.Limit = limit[0]
+		t.Left, err = readNode(buf, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		t.Right, err = readNode(buf, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrEncoding
+	}
+	return t, nil
+}
+
+func readUvarint(buf byteReader, max uint64) (uint64, error) {
+	x, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return 0, err
+	}
+	if x > max {
+		return 0, ErrEncoding
+	}
+	return x, nil
+}
+
+func readFloats(buf byteReader, n int) ([]float64, error) {
+	res := make([]float64, n)
+	for i := range res {
+		err := binary.Read(buf, binary.LittleEndian, &res[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
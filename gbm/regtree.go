@@ -0,0 +1,223 @@
+package gbm
+
+import (
+	"sort"
+
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Node represents a node of a regression tree.
+type Node struct {
+	// Column specifies which input variable this node splits the data
+	// at.  This field is unused for leaf nodes.
+	Column int
+
+	// Limit specifies the critical value for the input variable given
+	// by `Column`.  Inputs with values less than or equal to `Limit`
+	// correspond to the left subtree.
+	Limit float64
+
+	// Value gives the output of the tree for leaf nodes.  This field
+	// is unused for internal nodes.
+	Value float64
+
+	// Left and Right point to the subtrees attached to this node.
+	// For leaf nodes these are nil.
+	Left, Right *Node
+}
+
+// IsLeaf returns true if `t` is a terminal node and returns false if
+// `t` has child nodes.
+func (t *Node) IsLeaf() bool {
+	return t.Left == nil
+}
+
+// Eval returns the value of the regression tree at input `x`.
+func (t *Node) Eval(x []float64) float64 {
+	for !t.IsLeaf() {
+		if x[t.Column] <= t.Limit {
+			t = t.Left
+		} else {
+			t = t.Right
+		}
+	}
+	return t.Value
+}
+
+// presorted stores, for every column of the input matrix, the
+// training rows sorted by the values in this column.  The
+// corresponding values are stored in `values`, so that the inner
+// loop of the split search can access memory sequentially.
+type presorted struct {
+	x      *matrix.Float64
+	rows   [][]int
+	values [][]float64
+}
+
+func newPresorted(x *matrix.Float64, rows []int) *presorted {
+	_, p := x.Shape()
+	res := &presorted{
+		x:      x,
+		rows:   make([][]int, p),
+		values: make([][]float64, p),
+	}
+	for col := 0; col < p; col++ {
+		sorted := make([]int, len(rows))
+		copy(sorted, rows)
+		sort.Sort(&colSort{x, sorted, col})
+		res.rows[col] = sorted
+		values := make([]float64, len(rows))
+		for i, row := range sorted {
+			values[i] = x.At(row, col)
+		}
+		res.values[col] = values
+	}
+	return res
+}
+
+type colSort struct {
+	x    *matrix.Float64
+	rows []int
+	col  int
+}
+
+func (c *colSort) Len() int { return len(c.rows) }
+func (c *colSort) Less(i, j int) bool {
+	return c.x.At(c.rows[i], c.col) < c.x.At(c.rows[j], c.col)
+}
+func (c *colSort) Swap(i, j int) {
+	c.rows[i], c.rows[j] = c.rows[j], c.rows[i]
+}
+
+// treeGrower holds the state used while growing one regression tree.
+type treeGrower struct {
+	sorted      *presorted
+	maxDepth    int
+	minLeafSize float64
+
+	// The following slices are indexed by row numbers of the input
+	// matrix.  Rows which do not take part in fitting the current
+	// tree have `nodeOf[row] == -1`.
+	nodeOf []int
+	w      []float64
+	r      []float64
+}
+
+// splitStats accumulates the statistics for one node of the tree.
+type splitStats struct {
+	w, wr float64 // total weight and weighted residual
+
+	leftW, leftWR float64
+	lastValue     float64
+	seen          bool
+
+	bestGain  float64
+	bestCol   int
+	bestLimit float64
+	found     bool
+}
+
+// grow fits a regression tree to the residuals `g.r` for the rows
+// with `g.nodeOf[row] == 0`, using only the given columns.  The
+// function `leafValue` is called for every leaf with the list of rows
+// which end up in this leaf.
+func (g *treeGrower) grow(columns []int, leafValue func(rows []int) float64) *Node {
+	root := &Node{}
+	leaves := []*Node{root}
+
+	for depth := 0; depth < g.maxDepth; depth++ {
+		stats := make([]splitStats, len(leaves))
+		for row, j := range g.nodeOf {
+			if j < 0 {
+				continue
+			}
+			stats[j].w += g.w[row]
+			stats[j].wr += g.w[row] * g.r[row]
+		}
+
+		for _, col := range columns {
+			for j := range stats {
+				s := &stats[j]
+				s.leftW = 0
+				s.leftWR = 0
+				s.seen = false
+			}
+			values := g.sorted.values[col]
+			for i, row := range g.sorted.rows[col] {
+				j := g.nodeOf[row]
+				if j < 0 {
+					continue
+				}
+				s := &stats[j]
+				v := values[i]
+				if s.seen && v > s.lastValue {
+					rightW := s.w - s.leftW
+					if s.leftW >= g.minLeafSize && rightW >= g.minLeafSize {
+						rightWR := s.wr - s.leftWR
+						gain := s.leftWR*s.leftWR/s.leftW +
+							rightWR*rightWR/rightW - s.wr*s.wr/s.w
+						if gain > s.bestGain+1e-12 {
+							s.bestGain = gain
+							s.bestCol = col
+							s.bestLimit = (s.lastValue + v) / 2
+							s.found = true
+						}
+					}
+				}
+				s.leftW += g.w[row]
+				s.leftWR += g.w[row] * g.r[row]
+				s.lastValue = v
+				s.seen = true
+			}
+		}
+
+		var next []*Node
+		newIndex := make([]int, 2*len(leaves))
+		anySplit := false
+		for j, leaf := range leaves {
+			s := &stats[j]
+			if !s.found {
+				newIndex[2*j] = len(next)
+				newIndex[2*j+1] = -1
+				next = append(next, leaf)
+				continue
+			}
+			anySplit = true
+			leaf.Column = s.bestCol
+			leaf.Limit = s.bestLimit
+			leaf.Left = &Node{}
+			leaf.Right = &Node{}
+			newIndex[2*j] = len(next)
+			newIndex[2*j+1] = len(next) + 1
+			next = append(next, leaf.Left, leaf.Right)
+		}
+		if !anySplit {
+			break
+		}
+		for row, j := range g.nodeOf {
+			if j < 0 {
+				continue
+			}
+			leaf := leaves[j]
+			if leaf.IsLeaf() {
+				g.nodeOf[row] = newIndex[2*j]
+			} else if g.sorted.x.At(row, leaf.Column) <= leaf.Limit {
+				g.nodeOf[row] = newIndex[2*j]
+			} else {
+				g.nodeOf[row] = newIndex[2*j+1]
+			}
+		}
+		leaves = next
+	}
+
+	leafRows := make([][]int, len(leaves))
+	for row, j := range g.nodeOf {
+		if j >= 0 {
+			leafRows[j] = append(leafRows[j], row)
+		}
+	}
+	for j, leaf := range leaves {
+		leaf.Value = leafValue(leafRows[j])
+	}
+	return root
+}
@@ -0,0 +1,176 @@
+package knn
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"seehuhn.de/go/classification/matrix"
+)
+
+// kdTree is a spatial index for the rows of a matrix.
+type kdTree struct {
+	x    *matrix.Float64
+	rows []int
+	root *kdNode
+}
+
+// kdNode represents the rows `rows[lo:hi]` of the kd-tree.  For
+// internal nodes, all rows in the left subtree have x[dim] <= val and
+// all rows in the right subtree have x[dim] >= val.
+type kdNode struct {
+	lo, hi      int
+	dim         int
+	val         float64
+	left, right *kdNode
+}
+
+func newKDTree(x *matrix.Float64, rows []int, leafSize int) *kdTree {
+	t := &kdTree{
+		x:    x,
+		rows: make([]int, len(rows)),
+	}
+	copy(t.rows, rows)
+	if leafSize < 1 {
+		leafSize = 1
+	}
+	t.root = t.build(0, len(rows), leafSize)
+	return t
+}
+
+func (t *kdTree) build(lo, hi, leafSize int) *kdNode {
+	node := &kdNode{lo: lo, hi: hi}
+	if hi-lo <= leafSize {
+		return node
+	}
+
+	// split along the coordinate with the largest spread
+	_, p := t.x.Shape()
+	bestDim := -1
+	bestSpread := 0.0
+	for dim := 0; dim < p; dim++ {
+		min := t.x.At(t.rows[lo], dim)
+		max := min
+		for _, row := range t.rows[lo+1 : hi] {
+			v := t.x.At(row, dim)
+			if v < min {
+				min = v
+			} else if v > max {
+				max = v
+			}
+		}
+		if max-min > bestSpread {
+			bestDim = dim
+			bestSpread = max - min
+		}
+	}
+	if bestDim < 0 {
+		// all points coincide
+		return node
+	}
+
+	part := t.rows[lo:hi]
+	sort.Slice(part, func(i, j int) bool {
+		return t.x.At(part[i], bestDim) < t.x.At(part[j], bestDim)
+	})
+	mid := (lo + hi) / 2
+	node.dim = bestDim
+	node.val = t.x.At(t.rows[mid], bestDim)
+	node.left = t.build(lo, mid, leafSize)
+	node.right = t.build(mid, hi, leafSize)
+	return node
+}
+
+// candidate is a potential nearest neighbour of a query point, with
+// its reduced distance.
+type candidate struct {
+	row int
+	rd  float64
+}
+
+// candidateHeap is a max-heap of candidates, ordered by distance.
+type candidateHeap []candidate
+
+func (h candidateHeap) Len() int            { return len(h) }
+func (h candidateHeap) Less(i, j int) bool  { return h[i].rd > h[j].rd }
+func (h candidateHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *candidateHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+type query struct {
+	t      *kdTree
+	x      []float64
+	k      int
+	metric Metric
+	found  candidateHeap
+	offset []float64
+}
+
+// search returns the `k` nearest neighbours of `x`, sorted by
+// increasing distance.
+func (t *kdTree) search(x []float64, k int, metric Metric) []candidate {
+	if k > len(t.rows) {
+		k = len(t.rows)
+	}
+	if k <= 0 {
+		return nil
+	}
+	q := &query{
+		t:      t,
+		x:      x,
+		k:      k,
+		metric: metric,
+		found:  make(candidateHeap, 0, k),
+		offset: make([]float64, len(x)),
+	}
+	q.visit(t.root, 0)
+
+	res := make([]candidate, len(q.found))
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = heap.Pop(&q.found).(candidate)
+	}
+	return res
+}
+
+// visit searches the subtree `node`, where `rd` is a lower bound for
+// the reduced distance between the query point and all points in
+// the subtree.
+func (q *query) visit(node *kdNode, rd float64) {
+	if node.left == nil {
+		for _, row := range q.t.rows[node.lo:node.hi] {
+			bound := math.Inf(+1)
+			if len(q.found) == q.k {
+				bound = q.found[0].rd
+			}
+			d := q.metric.reducedDist(q.x, q.t.x.Row(row), bound)
+			if len(q.found) < q.k {
+				heap.Push(&q.found, candidate{row, d})
+			} else if d < q.found[0].rd {
+				q.found[0] = candidate{row, d}
+				heap.Fix(&q.found, 0)
+			}
+		}
+		return
+	}
+
+	diff := q.x[node.dim] - node.val
+	near, far := node.left, node.right
+	if diff > 0 {
+		near, far = far, near
+	}
+	q.visit(near, rd)
+
+	old := q.offset[node.dim]
+	farRD := q.metric.update(rd, old, diff)
+	if len(q.found) < q.k || farRD < q.found[0].rd {
+		q.offset[node.dim] = diff
+		q.visit(far, farRD)
+		q.offset[node.dim] = old
+	}
+}
@@ -0,0 +1,276 @@
+// Package knn implements k-nearest-neighbour classification.
+//
+// Neighbours are found using a kd-tree, so that queries are exact but
+// much faster than a brute-force search for low-dimensional inputs.
+package knn
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+// Metric specifies how distances between inputs are measured.
+type Metric int
+
+const (
+	// Euclidean is the usual, straight-line distance.
+	Euclidean Metric = iota + 1
+
+	// Manhattan is the sum of the absolute coordinate differences.
+	Manhattan
+
+	// Chebyshev is the largest absolute coordinate difference.
+	Chebyshev
+)
+
+func (m Metric) String() string {
+	switch m {
+	case Euclidean:
+		return "Euclidean"
+	case Manhattan:
+		return "Manhattan"
+	case Chebyshev:
+		return "Chebyshev"
+	default:
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+}
+
+// Internally, distances are represented in "reduced" form: for the
+// Euclidean metric the reduced distance is the squared distance,
+// for the other metrics it equals the distance.
+
+// reducedDist returns the reduced distance between `x` and `y`.  The
+// computation may be abandoned as soon as the result is known to
+// exceed `bound`; in this case some value larger than `bound` is
+// returned.
+func (m Metric) reducedDist(x, y []float64, bound float64) float64 {
+	res := 0.0
+	switch m {
+	case Euclidean:
+		for i, xi := range x {
+			delta := xi - y[i]
+			res += delta * delta
+			if i%16 == 15 && res > bound {
+				break
+			}
+		}
+	case Manhattan:
+		for i, xi := range x {
+			res += math.Abs(xi - y[i])
+			if i%16 == 15 && res > bound {
+				break
+			}
+		}
+	case Chebyshev:
+		for i, xi := range x {
+			if delta := math.Abs(xi - y[i]); delta > res {
+				res = delta
+				if res > bound {
+					break
+				}
+			}
+		}
+	}
+	return res
+}
+
+// update returns a lower bound for the reduced distance, when the
+// offset of a query point from a kd-tree cell in one coordinate
+// changes from `old` to `new`.
+func (m Metric) update(rd, old, new float64) float64 {
+	switch m {
+	case Euclidean:
+		return rd - old*old + new*new
+	case Manhattan:
+		return rd - math.Abs(old) + math.Abs(new)
+	default:
+		return math.Max(rd, math.Abs(new))
+	}
+}
+
+// dist converts a reduced distance into a distance.
+func (m Metric) dist(rd float64) float64 {
+	if m == Euclidean {
+		return math.Sqrt(rd)
+	}
+	return rd
+}
+
+// Weighting specifies how the neighbours of a point contribute to the
+// estimated class probabilities.
+type Weighting int
+
+const (
+	// Uniform gives the same weight to all k neighbours.
+	Uniform Weighting = iota + 1
+
+	// InverseDistance weights each neighbour by the reciprocal of its
+	// distance.  If there are neighbours at distance zero, only these
+	// are used.
+	InverseDistance
+)
+
+// Factory stores the parameters for k-nearest-neighbour
+// classification.  Any zero field values are interpreted as the
+// corresponding values from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// K is the number of neighbours used for each prediction.
+	K int
+
+	// Metric specifies how distances are measured.
+	Metric Metric
+
+	// Weighting specifies how the neighbours are weighted.
+	Weighting Weighting
+
+	// LeafSize gives the maximal number of samples stored in a leaf
+	// of the kd-tree.
+	LeafSize int
+}
+
+// DefaultFactory specifies the default parameters for
+// k-nearest-neighbour classification.
+var DefaultFactory = &Factory{
+	K:         5,
+	Metric:    Euclidean,
+	Weighting: Uniform,
+	LeafSize:  16,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	return fmt.Sprintf("%d-NN, %s", f.K, f.Metric)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.K == 0 {
+		res.K = DefaultFactory.K
+	}
+	if res.Metric == 0 {
+		res.Metric = DefaultFactory.Metric
+	}
+	if res.Weighting == 0 {
+		res.Weighting = DefaultFactory.Weighting
+	}
+	if res.LeafSize == 0 {
+		res.LeafSize = DefaultFactory.LeafSize
+	}
+	return &res
+}
+
+// FromData constructs a new k-nearest-neighbour classifier.  The
+// classifier keeps a reference to the training data, which must not
+// be modified while the classifier is in use.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// ClassifierFromData constructs a new k-nearest-neighbour classifier.
+// The classifier keeps a reference to the training data, which must
+// not be modified while the classifier is in use.
+func (f *Factory) ClassifierFromData(d *data.Data) *Classifier {
+	f = f.setDefaults()
+	return &Classifier{
+		K:         f.K,
+		Metric:    f.Metric,
+		Weighting: f.Weighting,
+		d:         d,
+		index:     newKDTree(d.X, d.GetRows(), f.LeafSize),
+		info:      classification.NewInfo(d),
+	}
+}
+
+// Classifier is a k-nearest-neighbour classifier.
+type Classifier struct {
+	// K is the number of neighbours used for each prediction.
+	K int
+
+	// Metric specifies how distances are measured.
+	Metric Metric
+
+	// Weighting specifies how the neighbours are weighted.
+	Weighting Weighting
+
+	d     *data.Data
+	index *kdTree
+	info  *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.info
+}
+
+// Neighbour describes one of the nearest neighbours of a query point.
+type Neighbour struct {
+	// Row is the row of the training data matrix.
+	Row int
+
+	// Dist is the distance between the query point and the training
+	// sample.
+	Dist float64
+}
+
+// Neighbours returns the `k` training samples closest to `x`, sorted
+// by increasing distance.  If the training data has fewer than `k`
+// samples, all samples are returned.
+func (c *Classifier) Neighbours(x []float64, k int) []Neighbour {
+	if err := c.info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	found := c.index.search(x, k, c.Metric)
+	res := make([]Neighbour, len(found))
+	for i, cand := range found {
+		res[i] = Neighbour{
+			Row:  cand.row,
+			Dist: c.Metric.dist(cand.rd),
+		}
+	}
+	return res
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`, using the (weighted) class frequencies
+// amongst the nearest neighbours of `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	nb := c.Neighbours(x, c.K)
+	hist := make(data.Histogram, c.d.NumClasses)
+
+	exact := false
+	if c.Weighting == InverseDistance && len(nb) > 0 && nb[0].Dist == 0 {
+		exact = true
+	}
+	for _, n := range nb {
+		w := c.d.Weight(n.Row)
+		if c.Weighting == InverseDistance {
+			if exact {
+				if n.Dist > 0 {
+					break
+				}
+			} else {
+				w /= n.Dist
+			}
+		}
+		hist[c.d.Y[n.Row]] += w
+	}
+	if hist.Sum() <= 0 {
+		for k := range hist {
+			hist[k] = 1
+		}
+	}
+	return hist.Probabilities()
+}
@@ -0,0 +1,99 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/data"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func randomData(n, p int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(3, n, p)
+	for i := 0; i < n; i++ {
+		x := d.X.Row(i)
+		for j := range x {
+			// use a coarse grid to get some ties and duplicates
+			x[j] = float64(rng.Intn(20))
+		}
+		d.Y[i] = rng.Intn(3)
+	}
+	return d
+}
+
+func (*Tests) TestNeighbours(c *C) {
+	d := randomData(500, 3, 1)
+	rng := rand.New(rand.NewSource(2))
+	for _, metric := range []Metric{Euclidean, Manhattan, Chebyshev} {
+		f := &Factory{
+			Metric:   metric,
+			LeafSize: 4,
+		}
+		cfr := f.ClassifierFromData(d)
+		for trial := 0; trial < 50; trial++ {
+			x := []float64{
+				rng.Float64() * 20, rng.Float64() * 20, rng.Float64() * 20,
+			}
+			k := 1 + rng.Intn(10)
+
+			all := make([]float64, d.NRow())
+			for i := range all {
+				all[i] = metric.dist(metric.reducedDist(x, d.X.Row(i), math.Inf(+1)))
+			}
+			sort.Float64s(all)
+
+			nb := cfr.Neighbours(x, k)
+			c.Assert(nb, HasLen, k)
+			for i, n := range nb {
+				dist := metric.dist(metric.reducedDist(x, d.X.Row(n.Row), math.Inf(+1)))
+				c.Check(math.Abs(n.Dist-dist) < 1e-9, Equals, true)
+				c.Check(math.Abs(n.Dist-all[i]) < 1e-9, Equals, true,
+					Commentf("%s: neighbour %d at %g, expected %g",
+						metric, i, n.Dist, all[i]))
+			}
+		}
+	}
+}
+
+func (*Tests) TestSubset(c *C) {
+	d := randomData(100, 2, 3)
+	sub := *d
+	sub.Rows = []int{3, 5, 7}
+	cfr := (&Factory{K: 10}).ClassifierFromData(&sub)
+	nb := cfr.Neighbours([]float64{0, 0}, 10)
+	c.Assert(nb, HasLen, 3)
+	rows := []int{nb[0].Row, nb[1].Row, nb[2].Row}
+	sort.Ints(rows)
+	c.Check(rows, DeepEquals, []int{3, 5, 7})
+}
+
+func (*Tests) TestProbabilities(c *C) {
+	d := data.NewEmpty(2, 4, 1)
+	copy(d.X.Row(0), []float64{0})
+	copy(d.X.Row(1), []float64{1})
+	copy(d.X.Row(2), []float64{3})
+	copy(d.X.Row(3), []float64{10})
+	d.Y = []int{0, 1, 1, 0}
+
+	uniform := (&Factory{K: 3}).ClassifierFromData(d)
+	prob := uniform.EstimateClassProbabilities([]float64{0})
+	c.Check(prob[0], Equals, 1.0/3)
+	c.Check(prob[1], Equals, 2.0/3)
+
+	weighted := (&Factory{K: 3, Weighting: InverseDistance}).ClassifierFromData(d)
+	prob = weighted.EstimateClassProbabilities([]float64{0})
+	c.Check(prob, DeepEquals, data.Histogram{1, 0})
+	prob = weighted.EstimateClassProbabilities([]float64{2})
+	// distances 2, 1, 1 for classes 0, 1, 1
+	c.Check(math.Abs(prob[0]-0.5/2.5) < 1e-12, Equals, true)
+}
@@ -0,0 +1,168 @@
+package classification
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+const learningCurveSeed = 1188236402
+
+// LearningCurvePoint describes the performance of a classifier trained
+// on a subsample of the training data.
+type LearningCurvePoint struct {
+	// Size gives the number of training samples.
+	Size int
+
+	// MeanLoss gives the mean loss on the test data, and StdErr gives
+	// the corresponding standard error.
+	MeanLoss float64
+	StdErr   float64
+
+	TrainingTime time.Duration
+	TestTime     time.Duration
+
+	// ModelSize gives the size of the trained classifier in bytes, if
+	// the classifier implements the encoding.BinaryMarshaler
+	// interface.  Otherwise, ModelSize is -1.
+	ModelSize int
+}
+
+// LearningCurve shows how the test loss of a classifier depends on the
+// size of the training data.
+type LearningCurve struct {
+	Name   string
+	Points []LearningCurvePoint
+}
+
+// AssessLearningCurve trains classifiers on subsamples of the training
+// data of `samples`, of the sizes given in `sizes`, and computes the
+// loss on the full test data.  The subsamples are nested, i.e. every
+// subsample contains all smaller subsamples, and are chosen using a
+// fixed seed.  If `sizes` is nil, ten equally spaced sizes up to the
+// size of the training data are used.
+func AssessLearningCurve(cf Factory, samples data.Set, L loss.Function, sizes []int) (*LearningCurve, error) {
+	train, err := samples.TrainingData()
+	if err != nil {
+		return nil, err
+	}
+	test, err := samples.TestData()
+	if err != nil {
+		return nil, err
+	}
+
+	n := train.NRow()
+	if sizes == nil {
+		for i := 1; i <= 10; i++ {
+			if size := i * n / 10; size > 0 {
+				sizes = append(sizes, size)
+			}
+		}
+	} else {
+		sizes = append([]int(nil), sizes...)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	if len(sizes) == 0 || sizes[0] > n || sizes[len(sizes)-1] < 1 {
+		return nil, errors.New("invalid training set sizes")
+	}
+
+	res := &LearningCurve{
+		Name:   cf.GetName(),
+		Points: make([]LearningCurvePoint, len(sizes)),
+	}
+	rng := rand.New(rand.NewSource(learningCurveSeed))
+	sub := train
+	for i, size := range sizes {
+		sub = sub.SampleWithoutReplacement(size, rng)
+
+		pt := &res.Points[len(sizes)-1-i]
+		pt.Size = size
+
+		start := time.Now()
+		c, err := Checked(cf).CheckedFromData(sub)
+		pt.TrainingTime = time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+
+		start = time.Now()
+		rows := test.GetRows()
+		cumLoss := 0.0
+		cumLoss2 := 0.0
+		for _, row := range rows {
+			l := L(test.Y[row], c.EstimateClassProbabilities(test.X.Row(row)))
+			cumLoss += l
+			cumLoss2 += l * l
+		}
+		pt.TestTime = time.Since(start)
+		nn := float64(len(rows))
+		cumLoss /= nn
+		cumLoss2 /= nn
+		pt.MeanLoss = cumLoss
+		pt.StdErr = math.Sqrt(math.Max(cumLoss2-cumLoss*cumLoss, 0) / (nn - 1))
+
+		pt.ModelSize = -1
+		if m, ok := c.(encoding.BinaryMarshaler); ok {
+			if body, err := m.MarshalBinary(); err == nil {
+				pt.ModelSize = len(body)
+			}
+		}
+	}
+	return res, nil
+}
+
+// String formats the learning curve as a table.
+func (c *LearningCurve) String() string {
+	res := fmt.Sprintf("%s\n%8s %10s %10s %11s %9s %10s\n", c.Name,
+		"size", "loss", "std.err.", "training[s]", "test[s]", "model[B]")
+	for _, pt := range c.Points {
+		res += fmt.Sprintf("%8d %10.6f %10.6f %11.3f %9.3f %10d\n",
+			pt.Size, pt.MeanLoss, pt.StdErr, pt.TrainingTime.Seconds(),
+			pt.TestTime.Seconds(), pt.ModelSize)
+	}
+	return res
+}
+
+// WriteCSV writes the learning curve in .csv form, with columns
+// "size", "loss", "stderr", "training_time", "test_time" and
+// "model_size", into the file with name `fname`.  Times are given in
+// seconds.  Any pre-existing file with this name is over-written.
+func (c *LearningCurve) WriteCSV(fname string) error {
+	fd, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(fd)
+	err = w.Write([]string{"size", "loss", "stderr", "training_time",
+		"test_time", "model_size"})
+	for i := 0; err == nil && i < len(c.Points); i++ {
+		pt := c.Points[i]
+		err = w.Write([]string{
+			strconv.Itoa(pt.Size),
+			strconv.FormatFloat(pt.MeanLoss, 'g', -1, 64),
+			strconv.FormatFloat(pt.StdErr, 'g', -1, 64),
+			strconv.FormatFloat(pt.TrainingTime.Seconds(), 'g', -1, 64),
+			strconv.FormatFloat(pt.TestTime.Seconds(), 'g', -1, 64),
+			strconv.Itoa(pt.ModelSize),
+		})
+	}
+	if err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+	err2 := fd.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package logit
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/matrix"
+	"seehuhn.de/go/classification/model"
+)
+
+func init() {
+	model.Register(&Model{}, &model.Codec{
+		Tag:     "logit",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			m := c.(*Model)
+			K, p := m.Coef.Shape()
+			w.Int(K)
+			w.Int(p)
+			for k := 0; k < K; k++ {
+				w.Float64s(m.Coef.Row(k))
+			}
+			w.Float64s(m.Intercept)
+			w.Int(m.Iterations)
+			w.Bool(m.Converged)
+			w.Float64(m.Objective)
+			w.Float64(m.GradientNorm)
+			w.Bool(m.Stalled)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			K := r.Int()
+			p := r.Int()
+			var coef []float64
+			for k := 0; k < K && r.Err() == nil; k++ {
+				row := r.Float64s()
+				if len(row) != p {
+					r.Fail(model.ErrEncoding)
+				}
+				coef = append(coef, row...)
+			}
+			m := &Model{
+				NumClasses:   K,
+				Intercept:    r.Float64s(),
+				Iterations:   r.Int(),
+				Converged:    r.Bool(),
+				Objective:    r.Float64(),
+				GradientNorm: r.Float64(),
+				Stalled:      r.Bool(),
+				Info:         info,
+			}
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			if K < 1 || len(m.Intercept) != K {
+				return nil, model.ErrEncoding
+			}
+			m.Coef = matrix.NewFloat64(K, p, 0, coef)
+			return m, nil
+		},
+	})
+}
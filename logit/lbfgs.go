@@ -0,0 +1,218 @@
+package logit
+
+import (
+	"math"
+
+	"seehuhn.de/go/classification/matrix"
+)
+
+// objective is the type of the smooth part of the function to be
+// minimised.  The function must store the gradient at `x` in `grad`
+// and return the function value.
+type objective func(x, grad []float64) float64
+
+// optimResult describes the outcome of a call to `minimise`.
+type optimResult struct {
+	X          []float64
+	Value      float64
+	GradNorm   float64
+	Iterations int
+	Converged  bool
+	Stalled    bool
+}
+
+// minimise minimises the function f(x) + sum_i l1[i]*|x[i]|, starting
+// at `x0`.  If `l1` is nil or all zero, this is the L-BFGS method;
+// otherwise the orthant-wise limited-memory quasi-Newton method
+// (OWL-QN) by Andrew and Gao (ICML 2007) is used.  Iteration stops
+// once the largest component of the (pseudo-)gradient is smaller
+// than `tol`, after `maxIter` iterations, or once no further progress
+// is made.  Only the first case counts as convergence; the last case
+// is reported via the Stalled field.
+func minimise(f objective, x0, l1 []float64, maxIter int, tol float64) *optimResult {
+	const memory = 10
+
+	n := len(x0)
+	x := make([]float64, n)
+	copy(x, x0)
+	grad := make([]float64, n)
+	value := f(x, grad) + l1Norm(x, l1)
+
+	pg := make([]float64, n)
+	dir := make([]float64, n)
+	xNew := make([]float64, n)
+	gradNew := make([]float64, n)
+	alphaBuf := make([]float64, memory)
+	var sHist, yHist [][]float64
+	var rhoHist []float64
+
+	res := &optimResult{}
+	for iter := 0; ; iter++ {
+		pseudoGradient(pg, x, grad, l1)
+		gradNorm := maxAbs(pg)
+		res.Converged = gradNorm < tol
+		if res.Converged || res.Stalled || iter >= maxIter {
+			res.X = x
+			res.Value = value
+			res.GradNorm = gradNorm
+			res.Iterations = iter
+			return res
+		}
+
+		// two-loop recursion to compute dir = -H*pg
+		for i := range dir {
+			dir[i] = -pg[i]
+		}
+		for j := len(sHist) - 1; j >= 0; j-- {
+			alphaBuf[j] = rhoHist[j] * matrix.Dot(sHist[j], dir)
+			matrix.Axpy(-alphaBuf[j], yHist[j], dir)
+		}
+		if k := len(sHist) - 1; k >= 0 {
+			scale(matrix.Dot(sHist[k], yHist[k])/matrix.Dot(yHist[k], yHist[k]), dir)
+		}
+		for j := range sHist {
+			beta := rhoHist[j] * matrix.Dot(yHist[j], dir)
+			matrix.Axpy(alphaBuf[j]-beta, sHist[j], dir)
+		}
+		if l1 != nil {
+			// only keep components which agree with the steepest
+			// descent direction
+			for i := range dir {
+				if dir[i]*pg[i] >= 0 {
+					dir[i] = 0
+				}
+			}
+		}
+
+		// backtracking line search
+		step := 1.0
+		if len(sHist) == 0 {
+			step = 1 / math.Max(norm(pg), 1)
+		}
+		var valueNew float64
+		accepted := false
+		for k := 0; k < 50; k++ {
+			for i := range xNew {
+				xNew[i] = x[i] + step*dir[i]
+			}
+			if l1 != nil {
+				project(xNew, x, pg, l1)
+			}
+			valueNew = f(xNew, gradNew) + l1Norm(xNew, l1)
+			decrease := 0.0
+			for i := range xNew {
+				decrease += pg[i] * (xNew[i] - x[i])
+			}
+			if valueNew <= value+1e-4*decrease {
+				accepted = true
+				break
+			}
+			step *= 0.5
+		}
+		if !accepted {
+			// no further progress is possible
+			res.X = x
+			res.Value = value
+			res.GradNorm = gradNorm
+			res.Iterations = iter
+			res.Stalled = true
+			return res
+		}
+
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := range s {
+			s[i] = xNew[i] - x[i]
+			y[i] = gradNew[i] - grad[i]
+		}
+		sy := matrix.Dot(s, y)
+		if sy > 1e-12 {
+			if len(sHist) == memory {
+				sHist = sHist[1:]
+				yHist = yHist[1:]
+				rhoHist = rhoHist[1:]
+			}
+			sHist = append(sHist, s)
+			yHist = append(yHist, y)
+			rhoHist = append(rhoHist, 1/sy)
+		}
+
+		relChange := math.Abs(value-valueNew) / math.Max(math.Abs(value), 1)
+		x, xNew = xNew, x
+		grad, gradNew = gradNew, grad
+		value = valueNew
+		if relChange < 1e-14 {
+			res.Stalled = true
+		}
+	}
+}
+
+// pseudoGradient computes the gradient of f(x) + sum_i l1[i]*|x[i]|,
+// using the one-sided derivative which gives the steepest descent
+// where the function is not differentiable.
+func pseudoGradient(pg, x, grad, l1 []float64) {
+	if l1 == nil {
+		copy(pg, grad)
+		return
+	}
+	for i, xi := range x {
+		lambda := l1[i]
+		switch {
+		case lambda == 0:
+			pg[i] = grad[i]
+		case xi < 0:
+			pg[i] = grad[i] - lambda
+		case xi > 0:
+			pg[i] = grad[i] + lambda
+		case grad[i]+lambda < 0:
+			pg[i] = grad[i] + lambda
+		case grad[i]-lambda > 0:
+			pg[i] = grad[i] - lambda
+		default:
+			pg[i] = 0
+		}
+	}
+}
+
+// project sets all components of `xNew` to zero, which have left the
+// orthant of the previous iterate `x`.
+func project(xNew, x, pg, l1 []float64) {
+	for i := range xNew {
+		if l1[i] == 0 {
+			continue
+		}
+		orthant := x[i]
+		if orthant == 0 {
+			orthant = -pg[i]
+		}
+		if xNew[i]*orthant <= 0 {
+			xNew[i] = 0
+		}
+	}
+}
+
+func l1Norm(x, l1 []float64) float64 {
+	res := 0.0
+	for i, lambda := range l1 {
+		res += lambda * math.Abs(x[i])
+	}
+	return res
+}
+func norm(x []float64) float64 {
+	return math.Sqrt(matrix.Dot(x, x))
+}
+
+func maxAbs(x []float64) float64 {
+	res := 0.0
+	for _, xi := range x {
+		if a := math.Abs(xi); a > res {
+			res = a
+		}
+	}
+	return res
+}
+func scale(a float64, x []float64) {
+	for i := range x {
+		x[i] *= a
+	}
+}
@@ -0,0 +1,339 @@
+// Package logit implements multinomial logistic regression.
+//
+// For K classes, the model estimates the class probabilities as
+//
+//	P(Y=k | X=x) = exp(b[k] + sum_j B[k,j] x[j]) / Z(x),
+//
+// where Z(x) normalises the probabilities to sum to one.  The
+// parameters are found by minimising the (weighted) average negative
+// log-likelihood of the training data, plus optional L2 and L1
+// penalties on the coefficients B.  The intercepts b are not
+// penalised.
+package logit
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Factory stores the parameters for fitting logistic regression
+// models.  Any zero field values are interpreted as the corresponding
+// values from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// L2 is the weight of the penalty term L2/2 * sum B[k,j]^2.
+	L2 float64
+
+	// L1 is the weight of the penalty term L1 * sum |B[k,j]|.  If L1
+	// is positive, the model is fitted using the OWL-QN method,
+	// otherwise L-BFGS is used.
+	L1 float64
+
+	// NoIntercept can be set to fit models without intercept terms
+	// b[k].
+	NoIntercept bool
+
+	// Standardize indicates whether the input variables should be
+	// centred and scaled to unit variance before fitting the model.
+	// This affects the penalty terms, but the returned coefficients
+	// always refer to the original, unscaled inputs.
+	Standardize bool
+
+	// MaxIter gives the maximal number of iterations of the
+	// optimisation method.
+	MaxIter int
+
+	// Tolerance is the convergence threshold for the largest
+	// component of the gradient of the objective function.
+	Tolerance float64
+}
+
+// DefaultFactory specifies the default parameters for fitting logistic
+// regression models.  By default, no penalty is used.
+var DefaultFactory = &Factory{
+	Name:      "logistic regression",
+	MaxIter:   500,
+	Tolerance: 1e-6,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	name := DefaultFactory.Name
+	if f.L2 > 0 {
+		name += fmt.Sprintf(", L2=%g", f.L2)
+	}
+	if f.L1 > 0 {
+		name += fmt.Sprintf(", L1=%g", f.L1)
+	}
+	return name
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.MaxIter == 0 {
+		res.MaxIter = DefaultFactory.MaxIter
+	}
+	if res.Tolerance == 0 {
+		res.Tolerance = DefaultFactory.Tolerance
+	}
+	return &res
+}
+
+// FromData fits a logistic regression model to the training data.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.ModelFromData(d)
+}
+
+// ModelFromData fits a logistic regression model to the training data.
+func (f *Factory) ModelFromData(d *data.Data) *Model {
+	f = f.setDefaults()
+
+	K := d.NumClasses
+	p := d.NCol()
+	rows := d.GetRows()
+
+	// column means and scales used for standardisation
+	shift := make([]float64, p)
+	scale := make([]float64, p)
+	for j := range scale {
+		scale[j] = 1
+	}
+	if f.Standardize {
+		totalWeight := 0.0
+		for _, row := range rows {
+			w := d.Weight(row)
+			x := d.X.Row(row)
+			for j, xj := range x {
+				shift[j] += w * xj
+			}
+			totalWeight += w
+		}
+		for j := range shift {
+			shift[j] /= totalWeight
+		}
+		variance := make([]float64, p)
+		for _, row := range rows {
+			w := d.Weight(row)
+			x := d.X.Row(row)
+			for j, xj := range x {
+				delta := xj - shift[j]
+				variance[j] += w * delta * delta
+			}
+		}
+		for j, v := range variance {
+			sd := math.Sqrt(v / totalWeight)
+			if sd > 1e-12 {
+				scale[j] = sd
+			}
+		}
+		if f.NoIntercept {
+			// Without an intercept, centring would change the model.
+			for j := range shift {
+				shift[j] = 0
+			}
+		}
+	}
+
+	prob := &problem{
+		d:           d,
+		rows:        rows,
+		K:           K,
+		p:           p,
+		shift:       shift,
+		scale:       scale,
+		l2:          f.L2,
+		noIntercept: f.NoIntercept,
+	}
+	for _, row := range rows {
+		prob.totalWeight += d.Weight(row)
+	}
+
+	var l1 []float64
+	if f.L1 > 0 {
+		l1 = make([]float64, K*(p+1))
+		for k := 0; k < K; k++ {
+			for j := 0; j < p; j++ {
+				l1[k*(p+1)+j] = f.L1
+			}
+		}
+	}
+	theta0 := make([]float64, K*(p+1))
+	opt := minimise(prob.eval, theta0, l1, f.MaxIter, f.Tolerance)
+
+	// Convert the coefficients back to the original scale.
+	model := &Model{
+		NumClasses:   K,
+		Coef:         matrix.NewFloat64(K, p, 0, nil),
+		Intercept:    make([]float64, K),
+		Iterations:   opt.Iterations,
+		Converged:    opt.Converged,
+		Stalled:      opt.Stalled,
+		Objective:    opt.Value,
+		GradientNorm: opt.GradNorm,
+		Info:         classification.NewInfo(d),
+	}
+	for k := 0; k < K; k++ {
+		b := opt.X[k*(p+1)+p]
+		for j := 0; j < p; j++ {
+			beta := opt.X[k*(p+1)+j] / scale[j]
+			model.Coef.Set(k, j, beta)
+			b -= beta * shift[j]
+		}
+		model.Intercept[k] = b
+	}
+	return model
+}
+
+// problem describes the objective function for fitting a model.  The
+// parameter vector theta stores, for each class k, the p coefficients
+// followed by the intercept, all referring to the standardised inputs.
+type problem struct {
+	d           *data.Data
+	rows        []int
+	K, p        int
+	shift       []float64
+	scale       []float64
+	l2          float64
+	noIntercept bool
+	totalWeight float64
+}
+
+// eval computes the penalised negative log-likelihood (without the L1
+// term) and its gradient.
+func (prob *problem) eval(theta, grad []float64) float64 {
+	K := prob.K
+	p := prob.p
+	d := prob.d
+
+	for i := range grad {
+		grad[i] = 0
+	}
+	z := make([]float64, K)
+	xs := make([]float64, p)
+	value := 0.0
+	for _, row := range prob.rows {
+		w := d.Weight(row) / prob.totalWeight
+		x := d.X.Row(row)
+		for j, xj := range x {
+			xs[j] = (xj - prob.shift[j]) / prob.scale[j]
+		}
+		max := math.Inf(-1)
+		for k := 0; k < K; k++ {
+			base := k * (p + 1)
+			zk := matrix.Dot(theta[base:base+p], xs)
+			if !prob.noIntercept {
+				zk += theta[base+p]
+			}
+			z[k] = zk
+			if zk > max {
+				max = zk
+			}
+		}
+		y := d.Y[row]
+		zy := z[y]
+		sum := 0.0
+		for k, zk := range z {
+			z[k] = math.Exp(zk - max)
+			sum += z[k]
+		}
+		value += w * (max + math.Log(sum) - zy)
+		for k := 0; k < K; k++ {
+			r := z[k] / sum
+			if k == y {
+				r--
+			}
+			r *= w
+			base := k * (p + 1)
+			matrix.Axpy(r, xs, grad[base:base+p])
+			if !prob.noIntercept {
+				grad[base+p] += r
+			}
+		}
+	}
+
+	if prob.l2 > 0 {
+		for k := 0; k < K; k++ {
+			base := k * (p + 1)
+			for j := 0; j < p; j++ {
+				beta := theta[base+j]
+				value += prob.l2 / 2 * beta * beta
+				grad[base+j] += prob.l2 * beta
+			}
+		}
+	}
+	return value
+}
+
+// Model is a fitted multinomial logistic regression model.
+type Model struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Coef stores the coefficients B of the model.  The matrix has
+	// one row per class and one column per input variable.
+	Coef *matrix.Float64
+
+	// Intercept stores the intercepts b of the model, one per class.
+	Intercept []float64
+
+	// Iterations gives the number of iterations used by the
+	// optimisation method.
+	Iterations int
+
+	// Converged indicates whether the optimisation method reached the
+	// convergence threshold for the gradient.
+	Converged bool
+
+	// Stalled indicates that the optimisation method stopped before
+	// convergence, because the objective function could not be
+	// decreased any further.
+	Stalled bool
+
+	// Objective gives the value of the penalised objective function
+	// at the returned parameters.
+	Objective float64
+
+	// GradientNorm gives the largest component of the gradient of the
+	// objective function at the returned parameters.
+	GradientNorm float64
+
+	// Info describes the training data of the model.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the model.  This implements the
+// classification.Metadata interface.
+func (m *Model) GetInfo() *classification.Info {
+	return m.Info
+}
+
+// Decision returns the linear predictors b[k] + sum_j B[k,j] x[j] for
+// input `x`, one per class.
+func (m *Model) Decision(x []float64) []float64 {
+	if err := m.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	z := make([]float64, m.NumClasses)
+	for k := range z {
+		z[k] = m.Intercept[k] + matrix.Dot(m.Coef.Row(k), x)
+	}
+	return z
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (m *Model) EstimateClassProbabilities(x []float64) data.Histogram {
+	prob := data.Histogram(m.Decision(x))
+	matrix.Softmax(prob, prob)
+	return prob
+}
@@ -0,0 +1,150 @@
+package logit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// modelData generates samples from a three-class logistic regression
+// model, where only the first two of the three inputs are relevant.
+func modelData(n int, seed int64) *data.Data {
+	intercept := []float64{0, 1, -1}
+	coef := [][]float64{{2, 0, 0}, {0, -2, 0}, {-1, 1, 0}}
+	d := data.NewEmpty(3, n, 3)
+
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		x := d.X.Row(i)
+		x[0] = rng.NormFloat64()
+		x[1] = 3 + 5*rng.NormFloat64()
+		x[2] = rng.NormFloat64()
+		z := make([]float64, 3)
+		for k := range z {
+			z[k] = intercept[k] + matrix.Dot(coef[k], x)
+		}
+		prob := make(data.Histogram, 3)
+		for k := range z {
+			prob[k] = math.Exp(z[k])
+		}
+		prob = prob.Probabilities()
+		u := rng.Float64()
+		y := 0
+		for y < 2 && u > prob[y] {
+			u -= prob[y]
+			y++
+		}
+		d.Y[i] = y
+	}
+	return d
+}
+
+func (*Tests) TestGradient(c *C) {
+	d := modelData(50, 1)
+	d.Weights = make([]float64, 50)
+	for i := range d.Weights {
+		d.Weights[i] = float64(i%3) + 0.5
+	}
+	prob := &problem{
+		d:     d,
+		rows:  d.GetRows(),
+		K:     3,
+		p:     3,
+		shift: []float64{0.1, 3, 0},
+		scale: []float64{1, 5, 2},
+		l2:    0.3,
+	}
+	for _, row := range prob.rows {
+		prob.totalWeight += d.Weight(row)
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	theta := make([]float64, 12)
+	for i := range theta {
+		theta[i] = rng.NormFloat64()
+	}
+	grad := make([]float64, 12)
+	prob.eval(theta, grad)
+
+	tmp := make([]float64, 12)
+	const h = 1e-6
+	for i := range theta {
+		old := theta[i]
+		theta[i] = old + h
+		fPlus := prob.eval(theta, tmp)
+		theta[i] = old - h
+		fMinus := prob.eval(theta, tmp)
+		theta[i] = old
+		approx := (fPlus - fMinus) / (2 * h)
+		c.Check(math.Abs(approx-grad[i]) < 1e-6, Equals, true,
+			Commentf("component %d: %g != %g", i, approx, grad[i]))
+	}
+}
+
+func (*Tests) TestFit(c *C) {
+	d := modelData(5000, 3)
+	for _, standardize := range []bool{false, true} {
+		f := &Factory{
+			Standardize: standardize,
+		}
+		m := f.ModelFromData(d)
+		c.Assert(m.Converged, Equals, true)
+		c.Check(m.Stalled, Equals, false)
+		c.Check(m.GradientNorm < DefaultFactory.Tolerance, Equals, true)
+
+		// The parameters are only identified up to a common shift
+		// across classes, so we compare differences to class 0.
+		expected := [][]float64{{-2, -2, 0}, {-3, 1, 0}}
+		for k := 1; k < 3; k++ {
+			for j := 0; j < 3; j++ {
+				delta := m.Coef.At(k, j) - m.Coef.At(0, j)
+				c.Check(math.Abs(delta-expected[k-1][j]) < 0.25, Equals, true,
+					Commentf("B[%d,%d]: %g != %g", k, j, delta, expected[k-1][j]))
+			}
+		}
+		c.Check(math.Abs(m.Intercept[1]-m.Intercept[0]-1) < 0.25, Equals, true)
+		c.Check(math.Abs(m.Intercept[2]-m.Intercept[0]+1) < 0.25, Equals, true)
+
+		prob := m.EstimateClassProbabilities(d.X.Row(0))
+		c.Check(math.Abs(prob.Sum()-1) < 1e-12, Equals, true)
+	}
+}
+
+func (*Tests) TestL1(c *C) {
+	d := modelData(1000, 4)
+	f := &Factory{
+		L1:          0.02,
+		Standardize: true,
+	}
+	m := f.ModelFromData(d)
+	c.Check(m.Converged, Equals, true)
+	for k := 0; k < 3; k++ {
+		c.Check(m.Coef.At(k, 2), Equals, 0.0)
+	}
+	c.Check(m.Coef.At(0, 0), Not(Equals), 0.0)
+}
+
+func (*Tests) TestL2(c *C) {
+	d := modelData(1000, 5)
+	m0 := (&Factory{}).ModelFromData(d)
+	m1 := (&Factory{L2: 0.1}).ModelFromData(d)
+	c.Check(m1.Converged, Equals, true)
+	norm0 := 0.0
+	norm1 := 0.0
+	for k := 0; k < 3; k++ {
+		norm0 += math.Abs(m0.Coef.At(k, 0))
+		norm1 += math.Abs(m1.Coef.At(k, 0))
+	}
+	c.Check(norm1 < norm0, Equals, true)
+}
@@ -0,0 +1,57 @@
+package loss
+
+// CostMatrix describes the cost of decisions in a classification
+// problem with K classes.  C[y][k] gives the cost of deciding for
+// class k when the true class is y.  Normally the diagonal entries are
+// zero and all other entries are positive.
+type CostMatrix [][]float64
+
+// Decide returns the Bayes-optimal decision for the class
+// probabilities `prob`, i.e. the class k which minimises the expected
+// cost sum_y prob[y] C[y][k].  In case of a draw, the lowest index
+// involved is returned.
+func (C CostMatrix) Decide(prob []float64) int {
+	best := 0
+	bestCost := 0.0
+	for k := range C {
+		cost := 0.0
+		for y, p := range prob {
+			if p > 0 {
+				cost += p * C[y][k]
+			}
+		}
+		if k == 0 || cost < bestCost {
+			best = k
+			bestCost = cost
+		}
+	}
+	return best
+}
+
+// Cost returns a loss function which assumes that the model decides
+// for the Bayes-optimal class under the cost matrix `C`, as computed
+// by C.Decide, and then returns the cost of this decision.  For the
+// cost matrix with zeros on the diagonal and ones everywhere else, the
+// result equals ZeroOne except for the treatment of draws.
+func Cost(C CostMatrix) Function {
+	return func(y int, prob []float64) float64 {
+		return C[y][C.Decide(prob)]
+	}
+}
+
+// Threshold returns a loss function for binary problems, which
+// assumes that the model decides for class 1 if prob[1] >= t and for
+// class 0 otherwise.  The loss is 0.0 if the decision is correct and
+// 1.0 if the decision is wrong.
+func Threshold(t float64) Function {
+	return func(y int, prob []float64) float64 {
+		k := 0
+		if prob[1] >= t {
+			k = 1
+		}
+		if k == y {
+			return 0
+		}
+		return 1
+	}
+}
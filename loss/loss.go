@@ -33,6 +33,32 @@ func Deviance(y int, prob []float64) float64 {
 	return -2 * math.Log(prob[y])
 }
 
+// Brier computes the Brier score, i.e. the squared Euclidean distance
+// between `prob` and the indicator vector of class `y`.  The result is
+// between 0 and 2.
+func Brier(y int, prob []float64) float64 {
+	res := 0.0
+	for k, p := range prob {
+		if k == y {
+			p--
+		}
+		res += p * p
+	}
+	return res
+}
+
+// ClippedLogLoss returns a loss function which computes the negative
+// log-likelihood of the outcome `y`, where the probability prob[y] is
+// clipped to the range [eps, 1-eps] first.  Clipping keeps the loss
+// finite when a classifier assigns probability zero to the observed
+// class.
+func ClippedLogLoss(eps float64) Function {
+	return func(y int, prob []float64) float64 {
+		p := math.Min(math.Max(prob[y], eps), 1-eps)
+		return -math.Log(p)
+	}
+}
+
 // ZeroOne is a loss function which assumes that the model predicts
 // the class with the highest probability in `prob`, and then returns
 // 0.0 if the prediction is correct and 1.0 if the prediction is
@@ -24,6 +24,8 @@ import (
 var all = []Function{
 	Deviance,
 	ZeroOne,
+	Brier,
+	ClippedLogLoss(1e-15),
 }
 
 func TestLoss(t *testing.T) {
@@ -80,3 +82,66 @@ func TestZeroOne(t *testing.T) {
 		t.Error("unexpected loss values, expected 1/3 1/3 1/3, got", l0, l1, l2)
 	}
 }
+
+func TestBrier(t *testing.T) {
+	l := Brier(1, []float64{0.2, 0.5, 0.3})
+	if math.Abs(l-(0.04+0.25+0.09)) > 1e-12 {
+		t.Error("unexpected Brier score", l)
+	}
+	l = Brier(0, []float64{0, 1})
+	if l != 2 {
+		t.Error("unexpected Brier score", l, "for a wrong, certain prediction")
+	}
+}
+
+func TestClippedLogLoss(t *testing.T) {
+	L := ClippedLogLoss(1e-3)
+	l := L(0, []float64{0, 1})
+	if math.Abs(l-(-math.Log(1e-3))) > 1e-12 {
+		t.Error("unexpected loss value", l, "for probability zero")
+	}
+	l = L(1, []float64{0.5, 0.5})
+	if math.Abs(l-math.Log(2)) > 1e-12 {
+		t.Error("unexpected loss value", l, "for probability 1/2")
+	}
+}
+
+func TestCost(t *testing.T) {
+	// Missing class 1 is ten times as expensive as a false alarm.
+	C := CostMatrix{
+		{0, 1},
+		{10, 0},
+	}
+	prob := []float64{0.85, 0.15}
+	if k := C.Decide(prob); k != 1 {
+		t.Error("expected decision 1, got", k)
+	}
+	L := Cost(C)
+	if l := L(0, prob); l != 1 {
+		t.Error("unexpected cost", l, "for a false alarm")
+	}
+	if l := L(1, []float64{0.95, 0.05}); l != 10 {
+		t.Error("unexpected cost", l, "for a missed case")
+	}
+
+	zeroOne := CostMatrix{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}}
+	hist := []float64{0.2, 0.5, 0.3}
+	for y := range hist {
+		if l, l0 := Cost(zeroOne)(y, hist), ZeroOne(y, hist); l != l0 {
+			t.Error("unexpected cost", l, "for class", y, "expected", l0)
+		}
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	L := Threshold(0.2)
+	if l := L(1, []float64{0.75, 0.25}); l != 0 {
+		t.Error("unexpected loss", l, "above the threshold")
+	}
+	if l := L(0, []float64{0.75, 0.25}); l != 1 {
+		t.Error("unexpected loss", l, "for a false positive")
+	}
+	if l := L(1, []float64{0.9, 0.1}); l != 1 {
+		t.Error("unexpected loss", l, "for a false negative")
+	}
+}
@@ -0,0 +1,93 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotPositiveDefinite is returned by `NewCholesky` if the matrix is
+// not (numerically) symmetric positive definite.
+var ErrNotPositiveDefinite = errors.New("matrix is not positive definite")
+
+// Cholesky represents the Cholesky decomposition A = L L^T of a
+// symmetric, positive definite matrix A, where L is lower triangular.
+type Cholesky struct {
+	// L is the lower triangular Cholesky factor.  Entries above the
+	// diagonal are zero.
+	L *Float64
+}
+
+// NewCholesky computes the Cholesky decomposition of the square
+// matrix `a`.  Only the entries on and below the diagonal of `a` are
+// used.  If `a` is not positive definite, `ErrNotPositiveDefinite` is
+// returned.
+func NewCholesky(a *Float64) (*Cholesky, error) {
+	n, p := a.Shape()
+	if n != p {
+		panic("matrix is not square")
+	}
+
+	L := NewFloat64(n, n, 0, nil)
+	for j := 0; j < n; j++ {
+		Lj := L.Row(j)
+		s := a.At(j, j)
+		for k := 0; k < j; k++ {
+			s -= Lj[k] * Lj[k]
+		}
+		if !(s > 0) {
+			return nil, ErrNotPositiveDefinite
+		}
+		diag := math.Sqrt(s)
+		Lj[j] = diag
+		for i := j + 1; i < n; i++ {
+			Li := L.Row(i)
+			s := a.At(i, j)
+			for k := 0; k < j; k++ {
+				s -= Li[k] * Lj[k]
+			}
+			Li[j] = s / diag
+		}
+	}
+	return &Cholesky{L: L}, nil
+}
+
+// SolveLower solves L y = b for y, using forward substitution.  For a
+// vector x, the squared norm of the solution of L y = x equals x^T
+// A^{-1} x.
+func (c *Cholesky) SolveLower(b []float64) []float64 {
+	n, _ := c.L.Shape()
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		Li := c.L.Row(i)
+		s := b[i]
+		for k := 0; k < i; k++ {
+			s -= Li[k] * y[k]
+		}
+		y[i] = s / Li[i]
+	}
+	return y
+}
+
+// Solve solves the linear system A x = b for x.
+func (c *Cholesky) Solve(b []float64) []float64 {
+	n, _ := c.L.Shape()
+	x := c.SolveLower(b)
+	for i := n - 1; i >= 0; i-- {
+		s := x[i]
+		for k := i + 1; k < n; k++ {
+			s -= c.L.At(k, i) * x[k]
+		}
+		x[i] = s / c.L.At(i, i)
+	}
+	return x
+}
+
+// LogDet returns the logarithm of the determinant of A.
+func (c *Cholesky) LogDet() float64 {
+	n, _ := c.L.Shape()
+	res := 0.0
+	for i := 0; i < n; i++ {
+		res += math.Log(c.L.At(i, i))
+	}
+	return 2 * res
+}
@@ -0,0 +1,59 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCholesky(t *testing.T) {
+	a := NewFloat64(3, 3, 0, []float64{
+		4, 2, -2,
+		2, 10, 2,
+		-2, 2, 5,
+	})
+	c, err := NewCholesky(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check that L L^T = A
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			s := 0.0
+			for k := 0; k < 3; k++ {
+				s += c.L.At(i, k) * c.L.At(j, k)
+			}
+			if math.Abs(s-a.At(i, j)) > 1e-12 {
+				t.Errorf("(L L^T)[%d,%d] = %g != %g", i, j, s, a.At(i, j))
+			}
+		}
+	}
+
+	b := []float64{1, 2, 3}
+	x := c.Solve(b)
+	for i := 0; i < 3; i++ {
+		s := 0.0
+		for j := 0; j < 3; j++ {
+			s += a.At(i, j) * x[j]
+		}
+		if math.Abs(s-b[i]) > 1e-12 {
+			t.Errorf("(A x)[%d] = %g != %g", i, s, b[i])
+		}
+	}
+
+	// det(A) = 4*(50-4) - 2*(10+4) - 2*(4+20) = 108
+	if logDet := c.LogDet(); math.Abs(logDet-math.Log(108)) > 1e-12 {
+		t.Errorf("wrong log-determinant %g", logDet)
+	}
+}
+
+func TestCholeskySingular(t *testing.T) {
+	a := NewFloat64(2, 2, 0, []float64{
+		1, 1,
+		1, 1,
+	})
+	_, err := NewCholesky(a)
+	if err != ErrNotPositiveDefinite {
+		t.Errorf("expected ErrNotPositiveDefinite, got %v", err)
+	}
+}
@@ -97,3 +97,21 @@ func TestReadRow(t *testing.T) {
 		}
 	}
 }
+
+func TestVector(t *testing.T) {
+	x := []float64{1, 2, 3}
+	y := []float64{4, 5, 6}
+	if d := Dot(x, y); d != 32 {
+		t.Error("wrong inner product", d)
+	}
+	Axpy(2, x, y)
+	if y[0] != 6 || y[1] != 9 || y[2] != 12 {
+		t.Error("wrong result", y)
+	}
+
+	z := []float64{1000, 1000 + math.Log(3)}
+	Softmax(z, z)
+	if math.Abs(z[0]-0.25) > 1e-12 || math.Abs(z[1]-0.75) > 1e-12 {
+		t.Error("wrong probabilities", z)
+	}
+}
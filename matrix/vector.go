@@ -0,0 +1,39 @@
+package matrix
+
+import "math"
+
+// Dot returns the inner product of the vectors `x` and `y`, which must
+// have the same length.
+func Dot(x, y []float64) float64 {
+	res := 0.0
+	for i, xi := range x {
+		res += xi * y[i]
+	}
+	return res
+}
+
+// Axpy computes y += a*x.
+func Axpy(a float64, x, y []float64) {
+	for i, xi := range x {
+		y[i] += a * xi
+	}
+}
+
+// Softmax stores exp(z) normalised to sum to one in `prob`.  The
+// slices `prob` and `z` may be the same.
+func Softmax(prob, z []float64) {
+	max := math.Inf(-1)
+	for _, zi := range z {
+		if zi > max {
+			max = zi
+		}
+	}
+	sum := 0.0
+	for i, zi := range z {
+		prob[i] = math.Exp(zi - max)
+		sum += prob[i]
+	}
+	for i := range prob {
+		prob[i] /= sum
+	}
+}
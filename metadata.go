@@ -0,0 +1,85 @@
+package classification
+
+import (
+	"fmt"
+
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Metadata is implemented by classifiers which can describe the shape
+// of their inputs and outputs.  All classifiers constructed by the
+// factories in this module implement Metadata, using the information
+// from the training data.
+type Metadata interface {
+	// GetInfo returns the metadata of the classifier, or nil if no
+	// metadata is known.
+	GetInfo() *Info
+}
+
+// Info describes the inputs and outputs of a classifier.
+type Info struct {
+	// NumClasses gives the number of classes, i.e. the length of the
+	// histograms returned by EstimateClassProbabilities.
+	NumClasses int
+
+	// NumInputs gives the number of input values expected by
+	// EstimateClassProbabilities, or 0 if this is not known.
+	NumInputs int
+
+	// FeatureNames optionally gives the names of the input values.
+	FeatureNames []string
+
+	// ClassNames optionally gives the names of the classes.
+	ClassNames []string
+}
+
+// NewInfo returns the metadata for a classifier trained on `d`.
+func NewInfo(d *data.Data) *Info {
+	_, p := d.X.Shape()
+	return &Info{
+		NumClasses:   d.NumClasses,
+		NumInputs:    p,
+		FeatureNames: d.FeatureNames,
+		ClassNames:   d.ClassNames,
+	}
+}
+
+// CheckInput returns an error wrapping data.ErrShape if `x` does not
+// have the expected number of values.  If `info` is nil or the number
+// of inputs is not known, all inputs are accepted.
+func (info *Info) CheckInput(x []float64) error {
+	if info == nil || info.NumInputs == 0 {
+		return nil
+	}
+	if len(x) != info.NumInputs {
+		return fmt.Errorf("input has %d values, expected %d: %w",
+			len(x), info.NumInputs, data.ErrShape)
+	}
+	return nil
+}
+
+// GetInfo returns the metadata of the classifier `c`, or nil if `c`
+// does not implement Metadata.
+func GetInfo(c Classifier) *Info {
+	if m, ok := c.(Metadata); ok {
+		return m.GetInfo()
+	}
+	return nil
+}
+
+// CheckInputs returns an error wrapping data.ErrShape if the number of
+// columns of `x` does not match the number of inputs expected by `c`.
+// If no metadata is available for `c`, all inputs are accepted.
+func CheckInputs(c Classifier, x *matrix.Float64) error {
+	info := GetInfo(c)
+	if info == nil || info.NumInputs == 0 {
+		return nil
+	}
+	_, p := x.Shape()
+	if p != info.NumInputs {
+		return fmt.Errorf("inputs have %d columns, expected %d: %w",
+			p, info.NumInputs, data.ErrShape)
+	}
+	return nil
+}
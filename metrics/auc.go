@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"math"
+
+	"seehuhn.de/go/classification"
+)
+
+// AUCInterval gives the area under the ROC curve, together with a
+// confidence interval.
+type AUCInterval struct {
+	AUC    float64
+	StdErr float64
+
+	// Lower and Upper give the bounds of the confidence interval,
+	// clipped to the range [0, 1].
+	Lower, Upper float64
+
+	// Level gives the confidence level of the interval, e.g. 0.95.
+	Level float64
+}
+
+// DeLong computes the area under the ROC curve for the given class
+// against all other classes, together with a confidence interval at
+// the given level.  The standard error is computed using the method
+// from "Comparing the Areas under Two or More Correlated Receiver
+// Operating Characteristic Curves: A Nonparametric Approach" by DeLong,
+// DeLong and Clarke-Pearson (1988).  For weighted samples, weighted
+// variances and effective sample sizes are used; without weights the
+// result coincides with the original method.
+func DeLong(p *classification.Predictions, class int, level float64) *AUCInterval {
+	b := getBinary(p, class)
+
+	// placement values: V10 for positive, V01 for negative samples
+	v := make([]float64, len(b.score))
+	posAbove, negAbove := 0.0, 0.0
+	n := len(b.score)
+	for i := 0; i < n; {
+		j := i
+		gPos, gNeg := 0.0, 0.0
+		for j < n && b.score[j] == b.score[i] {
+			if b.positive[j] {
+				gPos += b.weight[j]
+			} else {
+				gNeg += b.weight[j]
+			}
+			j++
+		}
+		negBelow := b.totalNeg - negAbove - gNeg
+		for k := i; k < j; k++ {
+			if b.positive[k] {
+				v[k] = (negBelow + gNeg/2) / b.totalNeg
+			} else {
+				v[k] = (posAbove + gPos/2) / b.totalPos
+			}
+		}
+		posAbove += gPos
+		negAbove += gNeg
+		i = j
+	}
+
+	auc := 0.0
+	for k, vk := range v {
+		if b.positive[k] {
+			auc += b.weight[k] * vk
+		}
+	}
+	auc /= b.totalPos
+
+	var ss10, ss01, sw10, sw01 float64
+	for k, vk := range v {
+		w := b.weight[k]
+		if b.positive[k] {
+			ss10 += w * (vk - auc) * (vk - auc)
+			sw10 += w * w
+		} else {
+			ss01 += w * (vk - auc) * (vk - auc)
+			sw01 += w * w
+		}
+	}
+	// weighted sample variances, divided by the effective sample sizes
+	s10 := ss10 / (b.totalPos - sw10/b.totalPos)
+	s01 := ss01 / (b.totalNeg - sw01/b.totalNeg)
+	variance := s10*sw10/(b.totalPos*b.totalPos) + s01*sw01/(b.totalNeg*b.totalNeg)
+	se := math.Sqrt(variance)
+
+	z := normalQuantile(0.5 + level/2)
+	return &AUCInterval{
+		AUC:    auc,
+		StdErr: se,
+		Lower:  math.Max(auc-z*se, 0),
+		Upper:  math.Min(auc+z*se, 1),
+		Level:  level,
+	}
+}
+
+// Summary gives one-vs-rest performance measures for all classes of a
+// (possibly multiclass) problem.
+type Summary struct {
+	// AUC gives the area under the ROC curve for each class, against
+	// all other classes.  The value is NaN for classes which have no
+	// samples, or which contain all samples.
+	AUC []float64
+
+	// AveragePrecision gives the average precision for each class,
+	// against all other classes.  The value is NaN for classes which
+	// have no samples.
+	AveragePrecision []float64
+
+	// MacroAUC and MacroAveragePrecision are the unweighted means of
+	// the per-class values, ignoring NaN values.
+	MacroAUC              float64
+	MacroAveragePrecision float64
+
+	// WeightedAUC is the mean of the per-class AUC values, weighted
+	// by the total weight of the samples in each class.
+	WeightedAUC float64
+}
+
+// OneVsRest computes the one-vs-rest AUC and average precision for
+// every class, together with their averages.
+func OneVsRest(p *classification.Predictions) *Summary {
+	K := p.NumClasses
+	support := make([]float64, K)
+	total := 0.0
+	for i, y := range p.Y {
+		w := p.Weight(i)
+		support[y] += w
+		total += w
+	}
+
+	res := &Summary{
+		AUC:              make([]float64, K),
+		AveragePrecision: make([]float64, K),
+	}
+	var sumAUC, sumAP, sumWeightedAUC, weightAUC float64
+	nAUC, nAP := 0, 0
+	for k := 0; k < K; k++ {
+		res.AUC[k] = math.NaN()
+		res.AveragePrecision[k] = math.NaN()
+		if support[k] <= 0 {
+			continue
+		}
+		res.AveragePrecision[k] = PR(p, k).AveragePrecision()
+		sumAP += res.AveragePrecision[k]
+		nAP++
+		if support[k] >= total {
+			continue
+		}
+		res.AUC[k] = ROC(p, k).AUC()
+		sumAUC += res.AUC[k]
+		nAUC++
+		sumWeightedAUC += support[k] * res.AUC[k]
+		weightAUC += support[k]
+	}
+	res.MacroAUC = sumAUC / float64(nAUC)
+	res.MacroAveragePrecision = sumAP / float64(nAP)
+	res.WeightedAUC = sumWeightedAUC / weightAUC
+	return res
+}
+
+// normalQuantile returns the p-quantile of the standard normal
+// distribution, using the rational approximation by P. J. Acklam,
+// followed by one step of Halley's method.
+func normalQuantile(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	} else if p >= 1 {
+		return math.Inf(+1)
+	}
+
+	a := [...]float64{-3.969683028665376e+01, 2.209460984245205e+02,
+		-2.759285104469687e+02, 1.383577518672690e+02,
+		-3.066479806614716e+01, 2.506628277459239e+00}
+	b := [...]float64{-5.447609879822406e+01, 1.615858368580409e+02,
+		-1.556989798598866e+02, 6.680131188771972e+01,
+		-1.328068155288572e+01}
+	c := [...]float64{-7.784894002430293e-03, -3.223964580411365e-01,
+		-2.400758277161838e+00, -2.549732539343734e+00,
+		4.374664141464968e+00, 2.938163982698783e+00}
+	d := [...]float64{7.784695709041462e-03, 3.224671290700398e-01,
+		2.445134137142996e+00, 3.754408661907416e+00}
+
+	const pLow = 0.02425
+	var x float64
+	switch {
+	case p < pLow:
+		q := math.Sqrt(-2 * math.Log(p))
+		x = (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p <= 1-pLow:
+		q := p - 0.5
+		r := q * q
+		x = (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q /
+			(((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+	default:
+		q := math.Sqrt(-2 * math.Log1p(-p))
+		x = -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	}
+
+	// refinement
+	e := 0.5*math.Erfc(-x/math.Sqrt2) - p
+	u := e * math.Sqrt(2*math.Pi) * math.Exp(x*x/2)
+	x = x - u/(1+x*u/2)
+	return x
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+// CalibrationBin describes one bin of a reliability diagram.
+type CalibrationBin struct {
+	// Lower and Upper give the range of predicted probabilities
+	// covered by the bin.
+	Lower, Upper float64
+
+	// Weight gives the total weight of the samples in the bin.
+	Weight float64
+
+	// MeanPredicted gives the average predicted probability of the
+	// samples in the bin, and Observed gives the fraction of samples
+	// in the bin where the predicted event occurred.  Both are NaN for
+	// empty bins.
+	MeanPredicted float64
+	Observed      float64
+}
+
+// CalibrationReport describes how well predicted probabilities match
+// the observed frequencies.
+type CalibrationReport struct {
+	// Bins gives the reliability curve.  For a well calibrated
+	// classifier, Observed is close to MeanPredicted in every bin.
+	Bins []CalibrationBin
+
+	// ECE is the expected calibration error, the weighted average of
+	// |Observed - MeanPredicted| over all bins.
+	ECE float64
+
+	// MCE is the maximum calibration error, the largest value of
+	// |Observed - MeanPredicted| over all non-empty bins.
+	MCE float64
+}
+
+// Calibration computes the top-label calibration report, using
+// `numBins` bins of equal width.  For every sample, the predicted
+// probability is the largest estimated class probability, and the
+// event is that the corresponding class is correct.
+func Calibration(p *classification.Predictions, numBins int) *CalibrationReport {
+	n := p.Len()
+	prob := make([]float64, n)
+	event := make([]bool, n)
+	for i := 0; i < n; i++ {
+		row := data.Histogram(p.Prob.Row(i))
+		k := row.ArgMax()
+		prob[i] = row[k]
+		event[i] = p.Y[i] == k
+	}
+	return calibration(prob, event, p, numBins)
+}
+
+// ClassCalibration computes the calibration report for the estimated
+// probabilities of the given class, using `numBins` bins of equal
+// width.
+func ClassCalibration(p *classification.Predictions, class, numBins int) *CalibrationReport {
+	n := p.Len()
+	prob := make([]float64, n)
+	event := make([]bool, n)
+	for i := 0; i < n; i++ {
+		prob[i] = p.Prob.At(i, class)
+		event[i] = p.Y[i] == class
+	}
+	return calibration(prob, event, p, numBins)
+}
+
+// CalibrationFromData applies the classifier `c` to the test data `d`
+// and computes the top-label calibration report.
+func CalibrationFromData(c classification.Classifier, d *data.Data, numBins int) *CalibrationReport {
+	return Calibration(classification.Predict(c, d), numBins)
+}
+
+func calibration(prob []float64, event []bool, p *classification.Predictions, numBins int) *CalibrationReport {
+	res := &CalibrationReport{
+		Bins: make([]CalibrationBin, numBins),
+	}
+	sumPred := make([]float64, numBins)
+	sumEvent := make([]float64, numBins)
+	for i, pi := range prob {
+		k := int(pi * float64(numBins))
+		if k >= numBins {
+			k = numBins - 1
+		} else if k < 0 {
+			k = 0
+		}
+		w := p.Weight(i)
+		res.Bins[k].Weight += w
+		sumPred[k] += w * pi
+		if event[i] {
+			sumEvent[k] += w
+		}
+	}
+
+	total := 0.0
+	for k := range res.Bins {
+		bin := &res.Bins[k]
+		bin.Lower = float64(k) / float64(numBins)
+		bin.Upper = float64(k+1) / float64(numBins)
+		if bin.Weight <= 0 {
+			bin.MeanPredicted = math.NaN()
+			bin.Observed = math.NaN()
+			continue
+		}
+		bin.MeanPredicted = sumPred[k] / bin.Weight
+		bin.Observed = sumEvent[k] / bin.Weight
+		gap := math.Abs(bin.Observed - bin.MeanPredicted)
+		res.ECE += bin.Weight * gap
+		res.MCE = math.Max(res.MCE, gap)
+		total += bin.Weight
+	}
+	res.ECE /= total
+	return res
+}
+
+// String formats the report as a table.
+func (r *CalibrationReport) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%-13s %10s %9s %9s\n", "bin", "weight", "predicted", "observed")
+	for _, bin := range r.Bins {
+		fmt.Fprintf(b, "[%.3f,%.3f) %10.6g %9.4f %9.4f\n",
+			bin.Lower, bin.Upper, bin.Weight, bin.MeanPredicted, bin.Observed)
+	}
+	fmt.Fprintf(b, "\nECE %.4f\nMCE %.4f\n", r.ECE, r.MCE)
+	return b.String()
+}
+
+// WriteCSV writes the reliability curve in .csv form, with columns
+// "lower", "upper", "weight", "predicted" and "observed", into the
+// file with name `fname`.  Any pre-existing file with this name is
+// over-written.
+func (r *CalibrationReport) WriteCSV(fname string) error {
+	n := len(r.Bins)
+	lower := make([]float64, n)
+	upper := make([]float64, n)
+	weight := make([]float64, n)
+	predicted := make([]float64, n)
+	observed := make([]float64, n)
+	for k, bin := range r.Bins {
+		lower[k] = bin.Lower
+		upper[k] = bin.Upper
+		weight[k] = bin.Weight
+		predicted[k] = bin.MeanPredicted
+		observed[k] = bin.Observed
+	}
+	return writeCSV(fname,
+		[]string{"lower", "upper", "weight", "predicted", "observed"},
+		lower, upper, weight, predicted, observed)
+}
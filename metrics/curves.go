@@ -0,0 +1,203 @@
+// Package metrics implements threshold-free performance measures for
+// classifiers, computed from the estimated class probabilities stored
+// in classification.Predictions.
+//
+// For a given class, the estimated probability of this class is used
+// as a score, and the samples of this class are considered positive
+// while all other samples are negative (one-vs-rest).  For binary
+// problems, class 1 is normally used as the positive class.
+//
+// The package also provides calibration diagnostics, which compare
+// predicted probabilities to observed frequencies, and OptimalThreshold
+// to choose a decision threshold from validation data.
+package metrics
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"seehuhn.de/go/classification"
+)
+
+// binary holds the scores and labels for a one-vs-rest problem, sorted
+// by decreasing score.
+type binary struct {
+	score    []float64
+	positive []bool
+	weight   []float64
+
+	totalPos, totalNeg float64
+}
+
+func getBinary(p *classification.Predictions, class int) *binary {
+	n := p.Len()
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return p.Prob.At(idx[i], class) > p.Prob.At(idx[j], class)
+	})
+
+	b := &binary{
+		score:    make([]float64, n),
+		positive: make([]bool, n),
+		weight:   make([]float64, n),
+	}
+	for k, i := range idx {
+		b.score[k] = p.Prob.At(i, class)
+		b.positive[k] = p.Y[i] == class
+		b.weight[k] = p.Weight(i)
+		if b.positive[k] {
+			b.totalPos += b.weight[k]
+		} else {
+			b.totalNeg += b.weight[k]
+		}
+	}
+	return b
+}
+
+// forEachThreshold calls `fn` once for every distinct score, in
+// decreasing order, with the total weight of positive and negative
+// samples with score greater than or equal to the threshold.
+func (b *binary) forEachThreshold(fn func(threshold, tp, fp float64)) {
+	tp, fp := 0.0, 0.0
+	n := len(b.score)
+	for i := 0; i < n; {
+		s := b.score[i]
+		for i < n && b.score[i] == s {
+			if b.positive[i] {
+				tp += b.weight[i]
+			} else {
+				fp += b.weight[i]
+			}
+			i++
+		}
+		fn(s, tp, fp)
+	}
+}
+
+// ROCCurve represents a receiver operating characteristic curve.  Point
+// i of the curve corresponds to classifying all samples with score
+// greater than or equal to Threshold[i] as positive.  The first point
+// uses the threshold +Inf and is always (0, 0).
+type ROCCurve struct {
+	Threshold []float64
+	FPR       []float64 // false positive rate
+	TPR       []float64 // true positive rate
+}
+
+// ROC computes the ROC curve for the given class against all other
+// classes.  If there are no positive or no negative samples, the
+// corresponding rates are NaN.
+func ROC(p *classification.Predictions, class int) *ROCCurve {
+	b := getBinary(p, class)
+	c := &ROCCurve{
+		Threshold: []float64{math.Inf(+1)},
+		FPR:       []float64{0},
+		TPR:       []float64{0},
+	}
+	b.forEachThreshold(func(threshold, tp, fp float64) {
+		c.Threshold = append(c.Threshold, threshold)
+		c.FPR = append(c.FPR, fp/b.totalNeg)
+		c.TPR = append(c.TPR, tp/b.totalPos)
+	})
+	return c
+}
+
+// AUC returns the area under the ROC curve, computed using the
+// trapezoidal rule.
+func (c *ROCCurve) AUC() float64 {
+	res := 0.0
+	for i := 1; i < len(c.FPR); i++ {
+		res += (c.FPR[i] - c.FPR[i-1]) * (c.TPR[i] + c.TPR[i-1]) / 2
+	}
+	return res
+}
+
+// WriteCSV writes the curve in .csv form, with columns "threshold",
+// "fpr" and "tpr", into the file with name `fname`.  Any pre-existing
+// file with this name is over-written.
+func (c *ROCCurve) WriteCSV(fname string) error {
+	return writeCSV(fname, []string{"threshold", "fpr", "tpr"},
+		c.Threshold, c.FPR, c.TPR)
+}
+
+// PRCurve represents a precision-recall curve.  Point i of the curve
+// corresponds to classifying all samples with score greater than or
+// equal to Threshold[i] as positive.  The first point uses the
+// threshold +Inf and has recall 0 and precision 1.
+type PRCurve struct {
+	Threshold []float64
+	Recall    []float64
+	Precision []float64
+}
+
+// PR computes the precision-recall curve for the given class against
+// all other classes.
+func PR(p *classification.Predictions, class int) *PRCurve {
+	b := getBinary(p, class)
+	c := &PRCurve{
+		Threshold: []float64{math.Inf(+1)},
+		Recall:    []float64{0},
+		Precision: []float64{1},
+	}
+	b.forEachThreshold(func(threshold, tp, fp float64) {
+		precision := 1.0
+		if tp+fp > 0 {
+			precision = tp / (tp + fp)
+		}
+		c.Threshold = append(c.Threshold, threshold)
+		c.Recall = append(c.Recall, tp/b.totalPos)
+		c.Precision = append(c.Precision, precision)
+	})
+	return c
+}
+
+// AveragePrecision returns the average precision, i.e. the sum of the
+// precisions at all thresholds, weighted by the increase in recall.
+// Unlike the area under the PR curve computed by the trapezoidal
+// rule, this does not interpolate linearly between points.
+func (c *PRCurve) AveragePrecision() float64 {
+	res := 0.0
+	for i := 1; i < len(c.Recall); i++ {
+		res += (c.Recall[i] - c.Recall[i-1]) * c.Precision[i]
+	}
+	return res
+}
+
+// WriteCSV writes the curve in .csv form, with columns "threshold",
+// "recall" and "precision", into the file with name `fname`.  Any
+// pre-existing file with this name is over-written.
+func (c *PRCurve) WriteCSV(fname string) error {
+	return writeCSV(fname, []string{"threshold", "recall", "precision"},
+		c.Threshold, c.Recall, c.Precision)
+}
+
+func writeCSV(fname string, header []string, cols ...[]float64) error {
+	fd, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(fd)
+	err = w.Write(header)
+	record := make([]string, len(cols))
+	for i := 0; err == nil && i < len(cols[0]); i++ {
+		for j, col := range cols {
+			record[j] = strconv.FormatFloat(col[i], 'g', -1, 64)
+		}
+		err = w.Write(record)
+	}
+	if err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+	err2 := fd.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
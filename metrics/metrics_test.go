@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// binaryPredictions returns predictions for a binary problem where
+// `score` gives the probability of class 1.
+func binaryPredictions(score []float64, y []int, w []float64) *classification.Predictions {
+	prob := matrix.NewFloat64(len(score), 2, 0, nil)
+	for i, s := range score {
+		prob.Set(i, 0, 1-s)
+		prob.Set(i, 1, s)
+	}
+	return &classification.Predictions{
+		NumClasses: 2,
+		Y:          y,
+		Prob:       prob,
+		Weights:    w,
+	}
+}
+
+// pairAUC computes the AUC by comparing all pairs of positive and
+// negative samples.
+func pairAUC(score []float64, y []int) float64 {
+	sum, count := 0.0, 0.0
+	for i, yi := range y {
+		if yi != 1 {
+			continue
+		}
+		for j, yj := range y {
+			if yj == 1 {
+				continue
+			}
+			switch {
+			case score[i] > score[j]:
+				sum++
+			case score[i] == score[j]:
+				sum += 0.5
+			}
+			count++
+		}
+	}
+	return sum / count
+}
+
+func (*Tests) TestAUC(c *C) {
+	score := []float64{0.9, 0.8, 0.7, 0.4, 0.4, 0.3, 0.2, 0.8}
+	y := []int{1, 1, 0, 1, 0, 0, 1, 0}
+	p := binaryPredictions(score, y, nil)
+	expected := pairAUC(score, y)
+
+	roc := ROC(p, 1)
+	c.Check(math.Abs(roc.AUC()-expected) < 1e-12, Equals, true)
+	c.Check(roc.FPR[0], Equals, 0.0)
+	c.Check(roc.TPR[len(roc.TPR)-1], Equals, 1.0)
+	c.Check(roc.FPR[len(roc.FPR)-1], Equals, 1.0)
+
+	ci := DeLong(p, 1, 0.95)
+	c.Check(math.Abs(ci.AUC-expected) < 1e-12, Equals, true)
+	c.Check(ci.Lower <= ci.AUC && ci.AUC <= ci.Upper, Equals, true)
+
+	// Compare the standard error to a direct implementation of
+	// DeLong's formula.
+	var v10, v01 []float64
+	for i, yi := range y {
+		sum, count := 0.0, 0.0
+		for j, yj := range y {
+			if yj == yi {
+				continue
+			}
+			a, b := score[i], score[j]
+			if yi != 1 {
+				a, b = b, a
+			}
+			switch {
+			case a > b:
+				sum++
+			case a == b:
+				sum += 0.5
+			}
+			count++
+		}
+		if yi == 1 {
+			v10 = append(v10, sum/count)
+		} else {
+			v01 = append(v01, sum/count)
+		}
+	}
+	variance := func(x []float64, mean float64) float64 {
+		s := 0.0
+		for _, xi := range x {
+			s += (xi - mean) * (xi - mean)
+		}
+		return s / float64(len(x)-1)
+	}
+	se := math.Sqrt(variance(v10, expected)/float64(len(v10)) +
+		variance(v01, expected)/float64(len(v01)))
+	c.Check(math.Abs(ci.StdErr-se) < 1e-12, Equals, true,
+		Commentf("%g vs %g", ci.StdErr, se))
+}
+
+func (*Tests) TestWeights(c *C) {
+	// Integer weights must give the same curves as repeated samples.
+	score := []float64{0.9, 0.6, 0.5, 0.3}
+	y := []int{1, 0, 1, 0}
+	w := []float64{1, 3, 2, 1}
+	var score2 []float64
+	var y2 []int
+	for i, wi := range w {
+		for k := 0; k < int(wi); k++ {
+			score2 = append(score2, score[i])
+			y2 = append(y2, y[i])
+		}
+	}
+	p1 := binaryPredictions(score, y, w)
+	p2 := binaryPredictions(score2, y2, nil)
+	c.Check(ROC(p1, 1), DeepEquals, ROC(p2, 1))
+	c.Check(PR(p1, 1), DeepEquals, PR(p2, 1))
+	c.Check(math.Abs(DeLong(p1, 1, 0.9).AUC-pairAUC(score2, y2)) < 1e-12,
+		Equals, true)
+}
+
+func (*Tests) TestAveragePrecision(c *C) {
+	// ranking: pos, neg, pos, neg
+	p := binaryPredictions([]float64{0.9, 0.8, 0.7, 0.6}, []int{1, 0, 1, 0}, nil)
+	pr := PR(p, 1)
+	c.Check(math.Abs(pr.AveragePrecision()-(0.5*1+0.5*2./3)) < 1e-12, Equals, true)
+	c.Check(pr.Precision[0], Equals, 1.0)
+	c.Check(pr.Recall[0], Equals, 0.0)
+}
+
+func (*Tests) TestOneVsRest(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	n := 300
+	K := 3
+	prob := matrix.NewFloat64(n, K, 0, nil)
+	y := make([]int, n)
+	for i := range y {
+		y[i] = i % K
+		row := prob.Row(i)
+		for k := range row {
+			row[k] = rng.Float64()
+		}
+		row[y[i]] += 1 // perfect separation for every class
+	}
+	p := &classification.Predictions{NumClasses: K, Y: y, Prob: prob}
+	s := OneVsRest(p)
+	for k := 0; k < K; k++ {
+		c.Check(s.AUC[k], Equals, 1.0)
+		c.Check(s.AveragePrecision[k], Equals, 1.0)
+	}
+	c.Check(s.MacroAUC, Equals, 1.0)
+	c.Check(s.WeightedAUC, Equals, 1.0)
+
+	// A class without samples is ignored in the averages.
+	p.NumClasses = 4
+	p.Prob = matrix.NewFloat64(n, 4, 0, nil)
+	for i := 0; i < n; i++ {
+		copy(p.Prob.Row(i), prob.Row(i))
+	}
+	s = OneVsRest(p)
+	c.Check(math.IsNaN(s.AUC[3]), Equals, true)
+	c.Check(s.MacroAUC, Equals, 1.0)
+}
+
+func (*Tests) TestWriteCSV(c *C) {
+	dir, err := ioutil.TempDir("", "metrics")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	p := binaryPredictions([]float64{0.9, 0.2}, []int{1, 0}, nil)
+	fname := filepath.Join(dir, "roc.csv")
+	c.Assert(ROC(p, 1).WriteCSV(fname), IsNil)
+	body, err := ioutil.ReadFile(fname)
+	c.Assert(err, IsNil)
+	c.Check(strings.Split(string(body), "\n")[:2], DeepEquals,
+		[]string{"threshold,fpr,tpr", "+Inf,0,0"})
+}
+
+func (*Tests) TestNormalQuantile(c *C) {
+	for _, p := range []float64{1e-10, 0.01, 0.3, 0.5, 0.975, 0.999} {
+		x := normalQuantile(p)
+		q := 0.5 * math.Erfc(-x/math.Sqrt2)
+		c.Check(math.Abs(q-p) < 1e-12*math.Max(1, 1/p), Equals, true,
+			Commentf("p=%g, x=%g", p, x))
+	}
+}
+
+func (*Tests) TestCalibration(c *C) {
+	// scores 0.1, 0.15 (bin 0), 0.9 (bin 4); events: no, yes, yes
+	p := binaryPredictions([]float64{0.1, 0.15, 0.9}, []int{0, 1, 1}, nil)
+	r := ClassCalibration(p, 1, 5)
+	c.Assert(len(r.Bins), Equals, 5)
+	c.Check(r.Bins[0].Weight, Equals, 2.0)
+	c.Check(math.Abs(r.Bins[0].MeanPredicted-0.125) < 1e-12, Equals, true)
+	c.Check(r.Bins[0].Observed, Equals, 0.5)
+	c.Check(math.IsNaN(r.Bins[2].Observed), Equals, true)
+	c.Check(math.Abs(r.Bins[4].Observed-1) < 1e-12, Equals, true)
+	c.Check(math.Abs(r.MCE-0.375) < 1e-12, Equals, true)
+	c.Check(math.Abs(r.ECE-(2*0.375+0.1)/3) < 1e-12, Equals, true)
+
+	// Calibrated probabilities give a small ECE, overconfident ones a
+	// large ECE.
+	rng := rand.New(rand.NewSource(2))
+	n := 20000
+	calibrated := make([]float64, n)
+	overconfident := make([]float64, n)
+	y := make([]int, n)
+	for i := range y {
+		q := rng.Float64()
+		if rng.Float64() < q {
+			y[i] = 1
+		}
+		calibrated[i] = q
+		overconfident[i] = math.Round(q)
+	}
+	good := Calibration(binaryPredictions(calibrated, y, nil), 10)
+	bad := Calibration(binaryPredictions(overconfident, y, nil), 10)
+	c.Check(good.ECE < 0.02, Equals, true, Commentf("ECE %g", good.ECE))
+	c.Check(bad.ECE > 0.2, Equals, true, Commentf("ECE %g", bad.ECE))
+	c.Check(strings.Contains(good.String(), "ECE"), Equals, true)
+}
+
+func (*Tests) TestOptimalThreshold(c *C) {
+	score := []float64{0.9, 0.8, 0.7, 0.6, 0.4, 0.3, 0.2, 0.1}
+	y := []int{1, 1, 0, 1, 0, 1, 0, 0}
+	p := binaryPredictions(score, y, nil)
+
+	t, f1, err := OptimalThreshold(p, 1, F1)
+	c.Assert(err, IsNil)
+	c.Check(t, Equals, 0.3)
+	c.Check(f1, Equals, 0.8)
+
+	// J = 0.5 is attained for three thresholds; the largest one is
+	// used.
+	t, j, err := OptimalThreshold(p, 1, YoudenJ)
+	c.Assert(err, IsNil)
+	c.Check(t, Equals, 0.8)
+	c.Check(j, Equals, 0.5)
+
+	// Expensive false negatives lead to a low threshold, expensive
+	// false positives to a high one.
+	t, cost, err := OptimalThreshold(p, 1, NegCost(1, 10))
+	c.Assert(err, IsNil)
+	c.Check(t, Equals, 0.3)
+	c.Check(cost, Equals, -0.25)
+	t, cost, err = OptimalThreshold(p, 1, NegCost(10, 1))
+	c.Assert(err, IsNil)
+	c.Check(t, Equals, 0.8)
+	c.Check(cost, Equals, -0.25)
+
+	// If the highest score belongs to a negative sample, classifying
+	// all samples as negative can be optimal.
+	p = binaryPredictions([]float64{0.9, 0.5}, []int{0, 1}, nil)
+	t, cost, err = OptimalThreshold(p, 1, NegCost(10, 1))
+	c.Assert(err, IsNil)
+	c.Check(math.IsInf(t, +1), Equals, true)
+	c.Check(cost, Equals, -0.5)
+
+	_, _, err = OptimalThreshold(p, 2, F1)
+	c.Check(err, NotNil)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"math"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+// Criterion assesses the decisions obtained by classifying all samples
+// with score greater than or equal to a threshold as positive.  The
+// arguments give the total weight of true positives, false positives,
+// false negatives and true negatives.  Larger values are better.
+type Criterion func(tp, fp, fn, tn float64) float64
+
+// F1 is the harmonic mean of precision and recall.
+func F1(tp, fp, fn, tn float64) float64 {
+	if tp == 0 {
+		return 0
+	}
+	return 2 * tp / (2*tp + fp + fn)
+}
+
+// YoudenJ is Youden's J statistic, i.e. the true positive rate minus
+// the false positive rate.
+func YoudenJ(tp, fp, fn, tn float64) float64 {
+	return tp/(tp+fn) - fp/(fp+tn)
+}
+
+// NegCost returns a criterion which gives the negative average cost
+// per sample, where every false positive incurs the cost `costFP`
+// and every false negative incurs the cost `costFN`.
+func NegCost(costFP, costFN float64) Criterion {
+	return func(tp, fp, fn, tn float64) float64 {
+		return -(costFP*fp + costFN*fn) / (tp + fp + fn + tn)
+	}
+}
+
+// OptimalThreshold finds the threshold on the estimated probability
+// of the given class which maximises `crit`, when samples of this
+// class are considered positive and all other samples negative.  All
+// distinct scores are tried, as well as the threshold +Inf which
+// classifies all samples as negative.  In case of a draw, the largest
+// threshold is returned.  The function returns the threshold together
+// with the corresponding value of `crit`.
+//
+// The threshold is normally chosen using predictions for validation
+// data which was not used to train the classifier.  The error
+// data.ErrNoSamples or data.ErrClass is returned if `p` is empty or
+// if `class` is out of range.
+func OptimalThreshold(p *classification.Predictions, class int, crit Criterion) (threshold, score float64, err error) {
+	if p.Len() == 0 {
+		return 0, 0, data.ErrNoSamples
+	}
+	if class < 0 || class >= p.NumClasses {
+		return 0, 0, data.ErrClass
+	}
+	b := getBinary(p, class)
+	threshold = math.Inf(+1)
+	score = crit(0, 0, b.totalPos, b.totalNeg)
+	b.forEachThreshold(func(t, tp, fp float64) {
+		s := crit(tp, fp, b.totalPos-tp, b.totalNeg-fp)
+		if s > score || math.IsNaN(score) && !math.IsNaN(s) {
+			threshold = t
+			score = s
+		}
+	})
+	return threshold, score, nil
+}
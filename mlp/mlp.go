@@ -0,0 +1,474 @@
+// Package mlp implements multilayer perceptron classifiers.
+//
+// The networks consist of fully connected hidden layers, followed by a
+// softmax output layer with one unit per class.  Networks are trained
+// by minimising the (weighted) cross-entropy of the training data,
+// using minibatch stochastic gradient descent or the Adam method.
+// Input variables are centred and scaled to unit variance before they
+// are fed into the network.
+package mlp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+const mlpSeed = 1733960921
+
+// Activation specifies the non-linearity used in the hidden layers.
+type Activation int
+
+const (
+	// ReLU is the rectified linear unit max(0, z).
+	ReLU Activation = iota + 1
+
+	// Tanh is the hyperbolic tangent.
+	Tanh
+)
+
+func (a Activation) String() string {
+	switch a {
+	case ReLU:
+		return "ReLU"
+	case Tanh:
+		return "tanh"
+	default:
+		return fmt.Sprintf("Activation(%d)", int(a))
+	}
+}
+
+// apply applies the activation function to every element of `z`.
+func (a Activation) apply(z []float64) {
+	switch a {
+	case ReLU:
+		for i, zi := range z {
+			if zi < 0 {
+				z[i] = 0
+			}
+		}
+	case Tanh:
+		for i, zi := range z {
+			z[i] = math.Tanh(zi)
+		}
+	}
+}
+
+// derivative multiplies `delta` by the derivative of the activation
+// function, given the activation values `out`.
+func (a Activation) derivative(out, delta []float64) {
+	switch a {
+	case ReLU:
+		for i, oi := range out {
+			if oi <= 0 {
+				delta[i] = 0
+			}
+		}
+	case Tanh:
+		for i, oi := range out {
+			delta[i] *= 1 - oi*oi
+		}
+	}
+}
+
+// Optimizer specifies the method used to update the network weights.
+type Optimizer int
+
+const (
+	// Adam is the method from "Adam: A Method for Stochastic
+	// Optimization" by Kingma and Ba (2015).
+	Adam Optimizer = iota + 1
+
+	// SGD is stochastic gradient descent with momentum.
+	SGD
+)
+
+func (o Optimizer) String() string {
+	switch o {
+	case Adam:
+		return "Adam"
+	case SGD:
+		return "SGD"
+	default:
+		return fmt.Sprintf("Optimizer(%d)", int(o))
+	}
+}
+
+// Factory stores the parameters for training multilayer perceptrons.
+// Any zero field values are interpreted as the corresponding values
+// from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Hidden gives the number of units in each hidden layer.
+	Hidden []int
+
+	// Activation is the non-linearity used in the hidden layers.
+	Activation Activation
+
+	// Optimizer is the method used to update the weights.
+	Optimizer Optimizer
+
+	// LearningRate is the step size of the optimizer.  If this is
+	// zero, 0.001 is used for Adam and 0.01 is used for SGD.
+	LearningRate float64
+
+	// Momentum is the momentum parameter for SGD.  If this is zero,
+	// the default momentum 0.9 is used; use a negative value for plain
+	// SGD without momentum.  Momentum is not used by Adam.
+	Momentum float64
+
+	// BatchSize gives the number of samples used for each update.
+	BatchSize int
+
+	// Epochs gives the maximal number of passes over the training
+	// data.
+	Epochs int
+
+	// WeightDecay adds the penalty WeightDecay/2 times the sum of the
+	// squared weights to the loss.  Biases are not penalised.  The
+	// default is to use no penalty.
+	WeightDecay float64
+
+	// ValidationFraction, if positive, gives the fraction of the
+	// training data which is set aside to decide when to stop
+	// training.  The returned network uses the weights from the epoch
+	// with the smallest validation loss.
+	ValidationFraction float64
+
+	// Patience gives the number of epochs without improvement of the
+	// validation loss, after which training is stopped.  This is only
+	// used if `ValidationFraction` is positive.
+	Patience int
+
+	// Seed is used to initialise the random number generator for the
+	// initial weights, the minibatches and the validation split.
+	Seed int64
+}
+
+// DefaultFactory specifies the default parameters for training
+// multilayer perceptrons.
+var DefaultFactory = &Factory{
+	Hidden:     []int{64},
+	Activation: ReLU,
+	Optimizer:  Adam,
+	Momentum:   0.9,
+	BatchSize:  32,
+	Epochs:     30,
+	Patience:   5,
+	Seed:       mlpSeed,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	var sizes []string
+	for _, h := range f.Hidden {
+		sizes = append(sizes, fmt.Sprint(h))
+	}
+	return fmt.Sprintf("MLP %s, %s, %s",
+		strings.Join(sizes, "-"), f.Activation, f.Optimizer)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if len(res.Hidden) == 0 {
+		res.Hidden = DefaultFactory.Hidden
+	}
+	if res.Activation == 0 {
+		res.Activation = DefaultFactory.Activation
+	}
+	if res.Optimizer == 0 {
+		res.Optimizer = DefaultFactory.Optimizer
+	}
+	if res.LearningRate == 0 {
+		if res.Optimizer == SGD {
+			res.LearningRate = 0.01
+		} else {
+			res.LearningRate = 0.001
+		}
+	}
+	if res.Momentum == 0 {
+		res.Momentum = DefaultFactory.Momentum
+	} else if res.Momentum < 0 {
+		res.Momentum = 0
+	}
+	if res.BatchSize == 0 {
+		res.BatchSize = DefaultFactory.BatchSize
+	}
+	if res.Epochs == 0 {
+		res.Epochs = DefaultFactory.Epochs
+	}
+	if res.Patience == 0 {
+		res.Patience = DefaultFactory.Patience
+	}
+	if res.Seed == 0 {
+		res.Seed = DefaultFactory.Seed
+	}
+	return &res
+}
+
+// FromData trains a multilayer perceptron on the training data.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.NetworkFromData(d)
+}
+
+// NetworkFromData trains a multilayer perceptron on the training data.
+func (f *Factory) NetworkFromData(d *data.Data) *Network {
+	f = f.setDefaults()
+	rng := rand.New(rand.NewSource(f.Seed))
+
+	K := d.NumClasses
+	p := d.NCol()
+	rows := d.GetRows()
+
+	var trainRows, validRows []int
+	if f.ValidationFraction > 0 {
+		nValid := int(f.ValidationFraction * float64(len(rows)))
+		if nValid > 0 && nValid < len(rows) {
+			validRows = d.SampleWithoutReplacement(nValid, rng).GetRows()
+			trainRows = complement(rows, validRows, len(d.Y))
+		}
+	}
+	if validRows == nil {
+		trainRows = rows
+	}
+
+	sizes := append([]int{p}, f.Hidden...)
+	sizes = append(sizes, K)
+	theta := make([]float64, numParams(sizes))
+	res := &Network{
+		NumClasses: K,
+		Activation: f.Activation,
+		Layers:     makeLayers(sizes, theta),
+		Info:       classification.NewInfo(d),
+	}
+	res.Shift, res.Scale = standardise(d, trainRows)
+	initParams(res.Layers, f.Activation, rng)
+
+	// Weights are normalised to have mean one on the training rows.
+	w := make([]float64, len(d.Y))
+	totalWeight := 0.0
+	for _, row := range trainRows {
+		w[row] = d.Weight(row)
+		totalWeight += w[row]
+	}
+	for _, row := range trainRows {
+		w[row] *= float64(len(trainRows)) / totalWeight
+	}
+	for _, row := range validRows {
+		w[row] = d.Weight(row)
+	}
+
+	gradTheta := make([]float64, len(theta))
+	grads := makeLayers(sizes, gradTheta)
+	opt := newOptimizer(f, theta)
+	acts := make([][]float64, len(sizes))
+	deltas := make([][]float64, len(sizes))
+	for l, n := range sizes {
+		acts[l] = make([]float64, n)
+		deltas[l] = make([]float64, n)
+	}
+
+	order := make([]int, len(trainRows))
+	copy(order, trainRows)
+	bestLoss := math.Inf(+1)
+	bestEpoch := 0
+	var bestTheta []float64
+	for epoch := 0; epoch < f.Epochs; epoch++ {
+		rng.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+
+		trainLoss := 0.0
+		for start := 0; start < len(order); start += f.BatchSize {
+			end := start + f.BatchSize
+			if end > len(order) {
+				end = len(order)
+			}
+			batch := order[start:end]
+
+			for i := range gradTheta {
+				gradTheta[i] = 0
+			}
+			scale := 1 / float64(len(batch))
+			for _, row := range batch {
+				y := d.Y[row]
+				res.input(d.X.Row(row), acts[0])
+				forward(res.Layers, res.Activation, acts)
+				trainLoss -= w[row] * math.Log(math.Max(acts[len(acts)-1][y], 1e-300))
+				backward(res.Layers, res.Activation, acts, deltas,
+					y, scale*w[row], grads)
+			}
+			if f.WeightDecay > 0 {
+				for l, layer := range res.Layers {
+					out, _ := layer.W.Shape()
+					for i := 0; i < out; i++ {
+						matrix.Axpy(f.WeightDecay, layer.W.Row(i), grads[l].W.Row(i))
+					}
+				}
+			}
+			opt.step(theta, gradTheta)
+		}
+		res.TrainLoss = append(res.TrainLoss, trainLoss/float64(len(order)))
+		res.Epochs = epoch + 1
+
+		if validRows != nil {
+			validLoss := res.meanLoss(d, validRows, w, acts)
+			res.ValidLoss = append(res.ValidLoss, validLoss)
+			if validLoss < bestLoss {
+				bestLoss = validLoss
+				bestEpoch = epoch + 1
+				bestTheta = append(bestTheta[:0], theta...)
+			} else if epoch+1-bestEpoch >= f.Patience {
+				break
+			}
+		}
+	}
+
+	if bestTheta != nil {
+		copy(theta, bestTheta)
+		res.Epochs = bestEpoch
+	}
+	return res
+}
+
+// complement returns the elements of `rows` which are not in `remove`.
+// Both slices may contain repeated elements, in which case each
+// element of `remove` cancels one occurrence in `rows`.
+func complement(rows, remove []int, n int) []int {
+	count := make([]int, n)
+	for _, row := range remove {
+		count[row]++
+	}
+	res := make([]int, 0, len(rows)-len(remove))
+	for _, row := range rows {
+		if count[row] > 0 {
+			count[row]--
+			continue
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+// standardise returns the (weighted) column means and standard
+// deviations of the input variables.  Constant columns get a standard
+// deviation of one.
+func standardise(d *data.Data, rows []int) ([]float64, []float64) {
+	p := d.NCol()
+	shift := make([]float64, p)
+	scale := make([]float64, p)
+	totalWeight := 0.0
+	for _, row := range rows {
+		w := d.Weight(row)
+		matrix.Axpy(w, d.X.Row(row), shift)
+		totalWeight += w
+	}
+	for j := range shift {
+		shift[j] /= totalWeight
+	}
+	for _, row := range rows {
+		w := d.Weight(row)
+		for j, xj := range d.X.Row(row) {
+			delta := xj - shift[j]
+			scale[j] += w * delta * delta
+		}
+	}
+	for j, v := range scale {
+		sd := math.Sqrt(v / totalWeight)
+		if sd > 1e-12 {
+			scale[j] = sd
+		} else {
+			scale[j] = 1
+		}
+	}
+	return shift, scale
+}
+
+// Network is a trained multilayer perceptron.
+type Network struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Activation is the non-linearity used in the hidden layers.
+	Activation Activation
+
+	// Layers lists the layers of the network, starting with the
+	// first hidden layer and ending with the output layer.
+	Layers []*Layer
+
+	// Shift and Scale describe the standardisation of the inputs:
+	// the network is applied to (x[j] - Shift[j]) / Scale[j].
+	Shift, Scale []float64
+
+	// Epochs gives the number of training epochs used for the
+	// returned weights.
+	Epochs int
+
+	// TrainLoss gives the average cross-entropy on the training data
+	// for every epoch, computed while training progressed.
+	TrainLoss []float64
+
+	// ValidLoss gives the average cross-entropy on the validation data
+	// after every epoch, if early stopping was used.
+	ValidLoss []float64
+
+	// Info describes the training data of the network.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the network.  This implements the
+// classification.Metadata interface.
+func (n *Network) GetInfo() *classification.Info {
+	return n.Info
+}
+
+// input stores the standardised version of `x` in `in`.
+func (n *Network) input(x, in []float64) {
+	for j, xj := range x {
+		in[j] = (xj - n.Shift[j]) / n.Scale[j]
+	}
+}
+
+// meanLoss returns the weighted average cross-entropy for the given
+// rows.  `acts` is used as workspace.
+func (n *Network) meanLoss(d *data.Data, rows []int, w []float64, acts [][]float64) float64 {
+	loss := 0.0
+	total := 0.0
+	for _, row := range rows {
+		n.input(d.X.Row(row), acts[0])
+		forward(n.Layers, n.Activation, acts)
+		p := acts[len(acts)-1][d.Y[row]]
+		loss -= w[row] * math.Log(math.Max(p, 1e-300))
+		total += w[row]
+	}
+	return loss / total
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (n *Network) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := n.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	acts := make([][]float64, len(n.Layers)+1)
+	acts[0] = make([]float64, len(x))
+	for l, layer := range n.Layers {
+		out, _ := layer.W.Shape()
+		acts[l+1] = make([]float64, out)
+	}
+	n.input(x, acts[0])
+	forward(n.Layers, n.Activation, acts)
+	return data.Histogram(acts[len(acts)-1])
+}
@@ -0,0 +1,143 @@
+package mlp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// rings returns samples from two classes in the plane, which are not
+// linearly separable: class 1 is inside the circle of radius 1, class
+// 0 is in the ring between radius 1 and 2.
+func rings(n int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(2, n, 2)
+	for i := 0; i < n; i++ {
+		y := rng.Intn(2)
+		r := math.Sqrt(rng.Float64())
+		if y == 0 {
+			r += 1
+		}
+		phi := 2 * math.Pi * rng.Float64()
+		x := d.X.Row(i)
+		x[0] = r * math.Cos(phi)
+		x[1] = r * math.Sin(phi)
+		d.Y[i] = y
+	}
+	return d
+}
+
+func (*Tests) TestGradient(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	for _, act := range []Activation{ReLU, Tanh} {
+		sizes := []int{3, 5, 4, 3}
+		theta := make([]float64, numParams(sizes))
+		layers := makeLayers(sizes, theta)
+		initParams(layers, act, rng)
+		grad := make([]float64, len(theta))
+		grads := makeLayers(sizes, grad)
+
+		acts := make([][]float64, len(sizes))
+		deltas := make([][]float64, len(sizes))
+		for l, n := range sizes {
+			acts[l] = make([]float64, n)
+			deltas[l] = make([]float64, n)
+		}
+		x := []float64{0.3, -1.2, 0.7}
+		y := 2
+		loss := func() float64 {
+			copy(acts[0], x)
+			forward(layers, act, acts)
+			return -math.Log(acts[len(acts)-1][y])
+		}
+
+		loss()
+		backward(layers, act, acts, deltas, y, 1, grads)
+
+		const h = 1e-6
+		for i := range theta {
+			old := theta[i]
+			theta[i] = old + h
+			l1 := loss()
+			theta[i] = old - h
+			l2 := loss()
+			theta[i] = old
+			numeric := (l1 - l2) / (2 * h)
+			c.Check(math.Abs(numeric-grad[i]) < 1e-5, Equals, true,
+				Commentf("%s, parameter %d: %g vs %g", act, i, numeric, grad[i]))
+		}
+	}
+}
+
+func (*Tests) TestRings(c *C) {
+	train := rings(2000, 2)
+	test := rings(2000, 3)
+	set := data.MakeSet("rings", train, test)
+	methods := []*Factory{
+		{},
+		{Activation: Tanh, Hidden: []int{16, 16}},
+		{Optimizer: SGD, LearningRate: 0.05},
+		{WeightDecay: 1e-4, ValidationFraction: 0.2},
+	}
+	for _, m := range methods {
+		res := classification.Assess(m, set, loss.ZeroOne)
+		c.Assert(res.Err, IsNil)
+		c.Check(res.MeanLoss < 0.05, Equals, true,
+			Commentf("%s: loss %g", m.GetName(), res.MeanLoss))
+	}
+}
+
+func (*Tests) TestDeterministic(c *C) {
+	train := rings(300, 4)
+	f := &Factory{Epochs: 5}
+	x := []float64{0.5, 0.5}
+	p1 := f.NetworkFromData(train).EstimateClassProbabilities(x)
+	p2 := f.NetworkFromData(train).EstimateClassProbabilities(x)
+	c.Check(p1, DeepEquals, p2)
+	c.Check(math.Abs(p1.Sum()-1) < 1e-12, Equals, true)
+}
+
+func (*Tests) TestMomentum(c *C) {
+	c.Check((&Factory{}).setDefaults().Momentum, Equals, 0.9)
+	c.Check((&Factory{Momentum: 0.5}).setDefaults().Momentum, Equals, 0.5)
+	c.Check((&Factory{Momentum: -1}).setDefaults().Momentum, Equals, 0.0)
+
+	train := rings(300, 6)
+	x := []float64{0.5, 0.5}
+	p1 := (&Factory{Optimizer: SGD, Epochs: 5}).NetworkFromData(train).EstimateClassProbabilities(x)
+	p2 := (&Factory{Optimizer: SGD, Epochs: 5, Momentum: -1}).NetworkFromData(train).EstimateClassProbabilities(x)
+	c.Check(p1, Not(DeepEquals), p2)
+}
+
+func (*Tests) TestEarlyStopping(c *C) {
+	train := rings(500, 5)
+	net := (&Factory{
+		Epochs:             200,
+		ValidationFraction: 0.3,
+		Patience:           3,
+	}).NetworkFromData(train)
+	c.Check(len(net.ValidLoss) < 200, Equals, true)
+	c.Check(len(net.ValidLoss), Equals, len(net.TrainLoss))
+	c.Check(len(net.ValidLoss)-net.Epochs, Equals, 3)
+	best := net.ValidLoss[net.Epochs-1]
+	for _, l := range net.ValidLoss {
+		c.Check(l >= best, Equals, true)
+	}
+}
+
+func (*Tests) TestComplement(c *C) {
+	rows := []int{1, 2, 2, 3, 5}
+	c.Check(complement(rows, []int{2, 5}, 6), DeepEquals, []int{1, 2, 3})
+}
@@ -0,0 +1,130 @@
+package mlp
+
+import (
+	"math"
+	"math/rand"
+
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Layer is one fully connected layer of a network.
+type Layer struct {
+	// W stores the weights of the layer.  The matrix has one row per
+	// output and one column per input of the layer.
+	W *matrix.Float64
+
+	// B stores the biases of the layer, one per output.
+	B []float64
+}
+
+// numParams returns the number of parameters of a network with the
+// given layer sizes.
+func numParams(sizes []int) int {
+	res := 0
+	for l := 1; l < len(sizes); l++ {
+		res += sizes[l] * (sizes[l-1] + 1)
+	}
+	return res
+}
+
+// makeLayers returns layers which use consecutive parts of `theta` to
+// store their weights and biases.
+func makeLayers(sizes []int, theta []float64) []*Layer {
+	res := make([]*Layer, len(sizes)-1)
+	pos := 0
+	for l := range res {
+		in, out := sizes[l], sizes[l+1]
+		w := theta[pos : pos+in*out]
+		pos += in * out
+		b := theta[pos : pos+out]
+		pos += out
+		res[l] = &Layer{
+			W: matrix.NewFloat64(out, in, 0, w),
+			B: b,
+		}
+	}
+	return res
+}
+
+// initParams sets random initial weights, scaled by the fan-in of each
+// layer, and zero biases.
+func initParams(layers []*Layer, act Activation, rng *rand.Rand) {
+	gain := 1.0
+	if act == ReLU {
+		gain = 2
+	}
+	for _, layer := range layers {
+		out, in := layer.W.Shape()
+		sd := math.Sqrt(gain / float64(in))
+		for i := 0; i < out; i++ {
+			row := layer.W.Row(i)
+			for j := range row {
+				row[j] = sd * rng.NormFloat64()
+			}
+		}
+		for i := range layer.B {
+			layer.B[i] = 0
+		}
+	}
+}
+
+// forward computes the activations of all layers, for input acts[0].
+// The output layer uses the softmax function.
+func forward(layers []*Layer, act Activation, acts [][]float64) {
+	last := len(layers) - 1
+	for l, layer := range layers {
+		in := acts[l]
+		out := acts[l+1]
+		for i := range out {
+			out[i] = matrix.Dot(layer.W.Row(i), in) + layer.B[i]
+		}
+		if l < last {
+			act.apply(out)
+		} else {
+			matrix.Softmax(out, out)
+		}
+	}
+}
+
+// backward adds w times the gradient of the negative log-likelihood of
+// class `y` to `grad`, which must have the same layout as the network
+// parameters.  The activations must have been computed by forward().
+// `deltas` is used as workspace and must have the same shape as
+// `acts`.
+func backward(layers []*Layer, act Activation, acts, deltas [][]float64,
+	y int, w float64, grads []*Layer) {
+	L := len(layers)
+	delta := deltas[L]
+	for i, pi := range acts[L] {
+		delta[i] = w * pi
+	}
+	delta[y] -= w
+
+	for l := L - 1; l >= 0; l-- {
+		in := acts[l]
+		g := grads[l]
+		for i, di := range delta {
+			if di == 0 {
+				continue
+			}
+			matrix.Axpy(di, in, g.W.Row(i))
+			g.B[i] += di
+		}
+		if l == 0 {
+			break
+		}
+
+		prev := deltas[l]
+		for j := range prev {
+			prev[j] = 0
+		}
+		for i, di := range delta {
+			if di == 0 {
+				continue
+			}
+			matrix.Axpy(di, layers[l].W.Row(i), prev)
+		}
+		act.derivative(in, prev)
+		delta = prev
+	}
+}
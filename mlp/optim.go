@@ -0,0 +1,53 @@
+package mlp
+
+import "math"
+
+// optimizer updates the network parameters, given the gradient of the
+// loss for one minibatch.
+type optimizer struct {
+	method       Optimizer
+	learningRate float64
+	momentum     float64
+
+	m, v []float64 // first and second moment estimates (v only for Adam)
+	t    int       // number of steps taken so far
+}
+
+func newOptimizer(f *Factory, theta []float64) *optimizer {
+	res := &optimizer{
+		method:       f.Optimizer,
+		learningRate: f.LearningRate,
+		momentum:     f.Momentum,
+		m:            make([]float64, len(theta)),
+	}
+	if f.Optimizer == Adam {
+		res.v = make([]float64, len(theta))
+	}
+	return res
+}
+
+func (opt *optimizer) step(theta, grad []float64) {
+	opt.t++
+	switch opt.method {
+	case Adam:
+		const (
+			beta1   = 0.9
+			beta2   = 0.999
+			epsilon = 1e-8
+		)
+		c1 := 1 - math.Pow(beta1, float64(opt.t))
+		c2 := 1 - math.Pow(beta2, float64(opt.t))
+		for i, g := range grad {
+			opt.m[i] = beta1*opt.m[i] + (1-beta1)*g
+			opt.v[i] = beta2*opt.v[i] + (1-beta2)*g*g
+			mHat := opt.m[i] / c1
+			vHat := opt.v[i] / c2
+			theta[i] -= opt.learningRate * mHat / (math.Sqrt(vHat) + epsilon)
+		}
+	default:
+		for i, g := range grad {
+			opt.m[i] = opt.momentum*opt.m[i] - opt.learningRate*g
+			theta[i] += opt.m[i]
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package model
+
+import (
+	"encoding/binary"
+	"math"
+
+	"seehuhn.de/go/classification"
+)
+
+// Writer is used by codecs to encode classifiers.  The first error is
+// recorded and all later writes are ignored; the container reports the
+// error once encoding is finished.
+type Writer struct {
+	buf []byte
+	err error
+}
+
+// Uvarint writes a non-negative integer.
+func (w *Writer) Uvarint(x uint64) {
+	if w.err != nil {
+		return
+	}
+	w.buf = appendUvarint(w.buf, x)
+}
+
+// Int writes a non-negative integer of type int.
+func (w *Writer) Int(x int) {
+	if x < 0 {
+		w.Fail(ErrEncoding)
+		return
+	}
+	w.Uvarint(uint64(x))
+}
+
+// Bool writes a boolean value.
+func (w *Writer) Bool(x bool) {
+	if x {
+		w.Uvarint(1)
+	} else {
+		w.Uvarint(0)
+	}
+}
+
+// Float64 writes a floating point number.
+func (w *Writer) Float64(x float64) {
+	if w.err != nil {
+		return
+	}
+	tmp := [8]byte{}
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(x))
+	w.buf = append(w.buf, tmp[:]...)
+}
+
+// Float64s writes a slice of floating point numbers, preceded by its
+// length.
+func (w *Writer) Float64s(x []float64) {
+	w.Uvarint(uint64(len(x)))
+	for _, xi := range x {
+		w.Float64(xi)
+	}
+}
+
+// Bytes writes a byte slice, preceded by its length.
+func (w *Writer) Bytes(x []byte) {
+	if w.err != nil {
+		return
+	}
+	w.buf = appendUvarint(w.buf, uint64(len(x)))
+	w.buf = append(w.buf, x...)
+}
+
+// String writes a string, preceded by its length.
+func (w *Writer) String(x string) {
+	w.Bytes([]byte(x))
+}
+
+// Strings writes a slice of strings, preceded by its length.
+func (w *Writer) Strings(x []string) {
+	w.Uvarint(uint64(len(x)))
+	for _, xi := range x {
+		w.String(xi)
+	}
+}
+
+// Classifier writes `c` as a nested container.  This is used by codecs
+// for classifiers which contain other classifiers.
+func (w *Writer) Classifier(c classification.Classifier) {
+	if w.err != nil {
+		return
+	}
+	body, err := Marshal(c)
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.Bytes(body)
+}
+
+// Fail records the error `err`, unless an error has been recorded
+// already.
+func (w *Writer) Fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	tmp := [binary.MaxVarintLen64]byte{}
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+// Reader is used by codecs to decode classifiers.  If the data is
+// malformed, the first error is recorded, all later reads return zero
+// values, and the container reports the error once decoding is
+// finished.
+type Reader struct {
+	buf []byte
+	err error
+}
+
+// Uvarint reads a non-negative integer.
+func (r *Reader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = ErrEncoding
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return x
+}
+
+// Int reads a non-negative integer of type int.
+func (r *Reader) Int() int {
+	x := r.Uvarint()
+	if x > math.MaxInt32 {
+		r.Fail(ErrEncoding)
+		return 0
+	}
+	return int(x)
+}
+
+// Len reads a length, which is checked to be at most `max`.  This can
+// be used to limit the memory allocated when decoding malformed data.
+func (r *Reader) Len(max int) int {
+	n := r.Int()
+	if n > max {
+		r.Fail(ErrEncoding)
+		return 0
+	}
+	return n
+}
+
+// Bool reads a boolean value.
+func (r *Reader) Bool() bool {
+	switch r.Uvarint() {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.Fail(ErrEncoding)
+		return false
+	}
+}
+
+// Float64 reads a floating point number.
+func (r *Reader) Float64() float64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 8 {
+		r.err = ErrEncoding
+		return 0
+	}
+	x := math.Float64frombits(binary.LittleEndian.Uint64(r.buf))
+	r.buf = r.buf[8:]
+	return x
+}
+
+// Float64s reads a slice of floating point numbers written by
+// Writer.Float64s.  Empty slices are returned as nil.
+func (r *Reader) Float64s() []float64 {
+	n := r.Len(len(r.buf) / 8)
+	if n == 0 {
+		return nil
+	}
+	res := make([]float64, n)
+	for i := range res {
+		res[i] = r.Float64()
+	}
+	return res
+}
+
+// Bytes reads a byte slice written by Writer.Bytes.  The returned
+// slice shares memory with the input data.
+func (r *Reader) Bytes() []byte {
+	n := r.Len(len(r.buf))
+	if r.err != nil {
+		return nil
+	}
+	res := r.buf[:n:n]
+	r.buf = r.buf[n:]
+	return res
+}
+
+// String reads a string written by Writer.String.
+func (r *Reader) String() string {
+	return string(r.Bytes())
+}
+
+// Strings reads a slice of strings written by Writer.Strings.  Empty
+// slices are returned as nil.
+func (r *Reader) Strings() []string {
+	n := r.Len(len(r.buf))
+	if n == 0 {
+		return nil
+	}
+	res := make([]string, n)
+	for i := range res {
+		res[i] = r.String()
+	}
+	return res
+}
+
+// Classifier reads a nested container written by Writer.Classifier.
+func (r *Reader) Classifier() classification.Classifier {
+	body := r.Bytes()
+	if r.err != nil {
+		return nil
+	}
+	c, err := Unmarshal(body)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	return c
+}
+
+// Fail records the error `err`, unless an error has been recorded
+// already.  Codecs can use this to report invalid data.
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error encountered while reading, or nil.
+func (r *Reader) Err() error {
+	return r.err
+}
@@ -0,0 +1,352 @@
+// Package model implements a container format to store trained
+// classifiers.
+//
+// Every container stores the type tag and encoding version of the
+// classifier, its metadata (see classification.Info), the encoded
+// classifier, and a checksum.  Classifiers which contain other
+// classifiers, for example ensembles or stacked classifiers, store
+// their parts as nested containers.
+//
+// Each classifier type is handled by a Codec, which must be registered
+// using Register.  The packages in this module register their codecs
+// when they are initialised, so the corresponding packages must be
+// imported before a classifier can be loaded.  For example:
+//
+//	import _ "seehuhn.de/go/classification/tree"
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"seehuhn.de/go/classification"
+)
+
+// These errors are returned when a container cannot be decoded.
+var (
+	ErrEncoding    = errors.New("cannot decode model container")
+	ErrVersion     = errors.New("unknown model container format version")
+	ErrChecksum    = errors.New("model container checksum mismatch")
+	ErrUnknownType = errors.New("unknown model type")
+)
+
+const containerTag = "JVCM"
+const containerVersion = 1
+
+// maxContainerSize is the maximal size of the payload of a container.
+const maxContainerSize = 1 << 40
+
+// Codec describes how classifiers of one type are encoded.
+type Codec struct {
+	// Tag identifies the classifier type inside containers.  Tags
+	// must be unique.
+	Tag string
+
+	// Version is the version of the encoding written by Encode.
+	// Containers with larger version numbers are rejected by Read.
+	Version int
+
+	// Encode writes the classifier `c` to `w`.  The metadata of `c`
+	// is stored separately by the container and need not be written.
+	Encode func(w *Writer, c classification.Classifier) error
+
+	// Decode reads a classifier which was written by Encode, using
+	// encoding version `version`.  `info` is the metadata stored in
+	// the container, or nil if no metadata was stored.
+	Decode func(r *Reader, version int, info *classification.Info) (classification.Classifier, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*Codec
+	byTag  map[string]*Codec
+}{
+	byType: make(map[reflect.Type]*Codec),
+	byTag:  make(map[string]*Codec),
+}
+
+// Register installs the codec for classifiers with the same dynamic
+// type as `sample`.  Register panics if a codec is already registered
+// for this type or for this tag.
+func Register(sample classification.Classifier, codec *Codec) {
+	t := reflect.TypeOf(sample)
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, dup := registry.byType[t]; dup {
+		panic("model: duplicate codec for type " + t.String())
+	}
+	if _, dup := registry.byTag[codec.Tag]; dup {
+		panic("model: duplicate codec for tag " + codec.Tag)
+	}
+	registry.byType[t] = codec
+	registry.byTag[codec.Tag] = codec
+}
+
+func codecForType(c classification.Classifier) *Codec {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.byType[reflect.TypeOf(c)]
+}
+
+func codecForTag(tag string) *Codec {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.byTag[tag]
+}
+
+// Marshal encodes the classifier `c` into a container.
+func Marshal(c classification.Classifier) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := Write(buf, c)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write encodes the classifier `c` into a container and writes the
+// result to `w`.  An error wrapping ErrUnknownType is returned if no
+// codec is registered for the type of `c` or any of its parts.
+func Write(w io.Writer, c classification.Classifier) error {
+	codec := codecForType(c)
+	if codec == nil {
+		return fmt.Errorf("%T: %w", c, ErrUnknownType)
+	}
+
+	payload := &Writer{}
+	payload.String(codec.Tag)
+	payload.Uvarint(uint64(codec.Version))
+	writeInfo(payload, classification.GetInfo(c))
+	body := &Writer{}
+	err := codec.Encode(body, c)
+	if err == nil {
+		err = body.err
+	}
+	if err != nil {
+		return err
+	}
+	payload.Bytes(body.buf)
+	if payload.err != nil {
+		return payload.err
+	}
+
+	out := bufio.NewWriter(w)
+
+	// 1: tag
+	_, err = out.WriteString(containerTag)
+	if err != nil {
+		return err
+	}
+
+	// 2: version
+	err = out.WriteByte(containerVersion)
+	if err != nil {
+		return err
+	}
+
+	// 3: payload length and payload
+	tmp := [binary.MaxVarintLen64]byte{}
+	n := binary.PutUvarint(tmp[:], uint64(len(payload.buf)))
+	_, err = out.Write(tmp[:n])
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(payload.buf)
+	if err != nil {
+		return err
+	}
+
+	// 4: checksum of the payload
+	err = binary.Write(out, binary.LittleEndian, crc32.ChecksumIEEE(payload.buf))
+	if err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
+
+// Save writes the classifier `c` into the file `fname`.  Any
+// pre-existing file with this name is over-written.
+func Save(fname string, c classification.Classifier) error {
+	fd, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = Write(fd, c)
+	err2 := fd.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Unmarshal decodes a classifier from a container generated by
+// Marshal or Write.
+func Unmarshal(data []byte) (classification.Classifier, error) {
+	r := bytes.NewReader(data)
+	c, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, ErrEncoding
+	}
+	return c, nil
+}
+
+// Load reads a classifier from the file `fname`, which must have been
+// generated by Save.
+func Load(fname string) (classification.Classifier, error) {
+	fd, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return Read(fd)
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Read reads a container from `r` and returns the decoded classifier.
+//
+// The function returns ErrEncoding if the data read from `r` is
+// invalid, ErrChecksum if the data is corrupted, ErrVersion if the
+// data was generated using an incompatible (i.e. newer) version of the
+// library, and an error wrapping ErrUnknownType if no codec is
+// registered for the classifier type.
+func Read(r io.Reader) (classification.Classifier, error) {
+	// Avoid buffering if possible, so that no data beyond the end of
+	// the container is consumed.
+	buf, ok := r.(byteReader)
+	if !ok {
+		buf = bufio.NewReader(r)
+	}
+
+	// 1: tag
+	tag := make([]byte, len(containerTag))
+	_, err := io.ReadFull(buf, tag)
+	if err != nil {
+		return nil, err
+	}
+	if string(tag) != containerTag {
+		return nil, ErrEncoding
+	}
+
+	// 2: version
+	version, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != containerVersion {
+		return nil, ErrVersion
+	}
+
+	// 3: payload length and payload
+	n, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxContainerSize {
+		return nil, ErrEncoding
+	}
+	payload := &bytes.Buffer{}
+	_, err = io.CopyN(payload, buf, int64(n))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 4: checksum of the payload
+	var sum uint32
+	err = binary.Read(buf, binary.LittleEndian, &sum)
+	if err != nil {
+		return nil, err
+	}
+	if sum != crc32.ChecksumIEEE(payload.Bytes()) {
+		return nil, ErrChecksum
+	}
+
+	return decodePayload(payload.Bytes())
+}
+
+func decodePayload(payload []byte) (classification.Classifier, error) {
+	r := &Reader{buf: payload}
+	tag := r.String()
+	version := r.Uvarint()
+	info := readInfo(r)
+	body := r.Bytes()
+	if r.err == nil && len(r.buf) > 0 {
+		r.err = ErrEncoding
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	codec := codecForTag(tag)
+	if codec == nil {
+		return nil, fmt.Errorf("%q: %w", tag, ErrUnknownType)
+	}
+	if version > uint64(codec.Version) {
+		return nil, ErrVersion
+	}
+
+	br := &Reader{buf: body}
+	c, err := codec.Decode(br, int(version), info)
+	if err == nil {
+		err = br.err
+	}
+	if err == nil && len(br.buf) > 0 {
+		err = ErrEncoding
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func writeInfo(w *Writer, info *classification.Info) {
+	if info == nil {
+		w.Bool(false)
+		return
+	}
+	w.Bool(true)
+	w.Uvarint(uint64(info.NumClasses))
+	w.Uvarint(uint64(info.NumInputs))
+	w.Strings(info.FeatureNames)
+	w.Strings(info.ClassNames)
+}
+
+func readInfo(r *Reader) *classification.Info {
+	if !r.Bool() {
+		return nil
+	}
+	info := &classification.Info{
+		NumClasses:   r.Int(),
+		NumInputs:    r.Int(),
+		FeatureNames: r.Strings(),
+		ClassNames:   r.Strings(),
+	}
+	if r.err != nil {
+		return nil
+	}
+	if info.FeatureNames != nil && len(info.FeatureNames) != info.NumInputs ||
+		info.ClassNames != nil && len(info.ClassNames) != info.NumClasses {
+		r.err = ErrEncoding
+		return nil
+	}
+	return info
+}
@@ -0,0 +1,190 @@
+package model_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/boosting"
+	"seehuhn.de/go/classification/calibrate"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/forest"
+	"seehuhn.de/go/classification/gbm"
+	"seehuhn.de/go/classification/impurity"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/model"
+	"seehuhn.de/go/classification/multiclass"
+	"seehuhn.de/go/classification/stacking"
+	"seehuhn.de/go/classification/tree"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func sampleData() *data.Data {
+	d := data.NewEmpty(3, 90, 2)
+	d.FeatureNames = []string{"x", "y"}
+	d.ClassNames = []string{"a", "b", "c"}
+	rng := rand.New(rand.NewSource(1))
+	for i := range d.Y {
+		k := i % 3
+		d.X.Set(i, 0, float64(k)+rng.NormFloat64())
+		d.X.Set(i, 1, rng.NormFloat64())
+		d.Y[i] = k
+	}
+	return d
+}
+
+// checkSame verifies that `c1` and `c2` give the same predictions on
+// the inputs of `d`.
+func checkSame(c *C, c1, c2 classification.Classifier, d *data.Data) {
+	for i := range d.Y {
+		x := d.X.Row(i)
+		c.Check(c2.EstimateClassProbabilities(x), DeepEquals,
+			c1.EstimateClassProbabilities(x))
+	}
+}
+
+func (*Tests) TestRoundTrip(c *C) {
+	d := sampleData()
+	factories := []classification.Factory{
+		tree.CART,
+		(&forest.RandomForestFactory{
+			RandomTree: forest.RandomTree{
+				NumSamples: 0.7,
+				NumLeaves:  6,
+				SplitScore: impurity.Gini,
+			},
+			NumTrees: 5,
+		}).New(),
+		&logit.Factory{L2: 1e-2},
+		&gbm.Factory{Rounds: 5},
+		&stacking.Factory{
+			Base: []classification.Factory{tree.CART, &logit.Factory{}},
+		},
+		&boosting.Factory{Algorithm: boosting.SAMME, Rounds: 5},
+		&boosting.Factory{Algorithm: boosting.SAMMER, Rounds: 5},
+		&calibrate.Factory{Base: tree.CART, Method: calibrate.Platt},
+		&calibrate.Factory{Base: tree.CART, Method: calibrate.Isotonic},
+		&calibrate.Factory{Base: tree.CART, Method: calibrate.Temperature},
+		&calibrate.Factory{Base: tree.CART, Method: calibrate.Dirichlet},
+		&stacking.Factory{
+			Base: []classification.Factory{
+				&calibrate.Factory{Base: tree.CART},
+				&logit.Factory{},
+			},
+		},
+		&multiclass.OneVsRest{Base: &logit.Factory{}},
+		&multiclass.OneVsOne{Base: &logit.Factory{}},
+	}
+	for _, f := range factories {
+		c1 := f.FromData(d)
+		body, err := model.Marshal(c1)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+		c2, err := model.Unmarshal(body)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+
+		c.Check(classification.GetInfo(c2), DeepEquals, classification.GetInfo(c1))
+		checkSame(c, c1, c2, d)
+	}
+}
+
+func (*Tests) TestRoundTripMissingClass(c *C) {
+	// Without samples for class "c", the multiclass classifiers use
+	// constant binary classifiers for some of the problems.
+	d := sampleData()
+	for i, y := range d.Y {
+		if y == 2 {
+			d.Y[i] = 1
+		}
+	}
+	factories := []classification.Factory{
+		&multiclass.OneVsRest{Base: &logit.Factory{}},
+		&multiclass.OneVsOne{Base: &logit.Factory{}},
+		&calibrate.Factory{Base: &logit.Factory{}, Method: calibrate.Isotonic},
+	}
+	for _, f := range factories {
+		c1 := f.FromData(d)
+		body, err := model.Marshal(c1)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+		c2, err := model.Unmarshal(body)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+		checkSame(c, c1, c2, d)
+	}
+}
+
+func (*Tests) TestRoundTripBinary(c *C) {
+	// For two classes, the calibration only stores a map for class 1.
+	d := sampleData()
+	d.NumClasses = 2
+	d.ClassNames = d.ClassNames[:2]
+	for i, y := range d.Y {
+		d.Y[i] = y % 2
+	}
+	for _, method := range []calibrate.Method{calibrate.Platt, calibrate.Isotonic} {
+		f := &calibrate.Factory{Base: tree.CART, Method: method}
+		c1 := f.FromData(d)
+		body, err := model.Marshal(c1)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+		c2, err := model.Unmarshal(body)
+		c.Assert(err, IsNil, Commentf("%s", f.GetName()))
+		checkSame(c, c1, c2, d)
+	}
+}
+
+func (*Tests) TestSaveLoad(c *C) {
+	d := sampleData()
+	c1 := tree.CART.FromData(d)
+	fname := filepath.Join(c.MkDir(), "tree.model")
+	err := model.Save(fname, c1)
+	c.Assert(err, IsNil)
+	c2, err := model.Load(fname)
+	c.Assert(err, IsNil)
+	checkSame(c, c1, c2, d)
+}
+
+func (*Tests) TestErrors(c *C) {
+	d := sampleData()
+	body, err := model.Marshal(tree.CART.FromData(d))
+	c.Assert(err, IsNil)
+
+	corrupt := append([]byte(nil), body...)
+	corrupt[len(corrupt)/2] ^= 1
+	_, err = model.Unmarshal(corrupt)
+	c.Check(err, Equals, model.ErrChecksum)
+
+	_, err = model.Unmarshal(body[:len(body)-1])
+	c.Check(err, NotNil)
+
+	_, err = model.Unmarshal(append(body, 0))
+	c.Check(err, Equals, model.ErrEncoding)
+
+	future := append([]byte(nil), body...)
+	future[4] = 99
+	_, err = model.Unmarshal(future)
+	c.Check(err, Equals, model.ErrVersion)
+
+	// Two containers can be read from the same stream.
+	r := bytes.NewReader(append(append([]byte(nil), body...), body...))
+	_, err = model.Read(r)
+	c.Check(err, IsNil)
+	_, err = model.Read(r)
+	c.Check(err, IsNil)
+	c.Check(r.Len(), Equals, 0)
+
+	// No codec is registered for k-nearest-neighbour classifiers.
+	_, err = model.Marshal((&knn.Factory{}).FromData(d))
+	c.Check(errors.Is(err, model.ErrUnknownType), Equals, true)
+
+	c.Check(func() {
+		model.Register(&tree.Tree{}, &model.Codec{Tag: "other"})
+	}, PanicMatches, "model: duplicate codec.*")
+}
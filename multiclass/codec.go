@@ -0,0 +1,99 @@
+package multiclass
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/model"
+)
+
+// maxModels limits the number of binary classifiers accepted when
+// decoding.
+const maxModels = 1 << 20
+
+func init() {
+	model.Register(&OneVsRestClassifier{}, &model.Codec{
+		Tag:     "one-vs-rest",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			writeModels(w, c.(*OneVsRestClassifier).Models)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &OneVsRestClassifier{
+				Models: readModels(r),
+				Info:   info,
+			}
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			if len(res.Models) == 0 {
+				return nil, model.ErrEncoding
+			}
+			return res, nil
+		},
+	})
+
+	model.Register(&OneVsOneClassifier{}, &model.Codec{
+		Tag:     "one-vs-one",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			ovo := c.(*OneVsOneClassifier)
+			w.Int(ovo.NumClasses)
+			writeModels(w, ovo.Models)
+			w.Float64s(ovo.PairWeight)
+			w.Float64s(ovo.ClassWeight)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &OneVsOneClassifier{
+				NumClasses:  r.Int(),
+				Models:      readModels(r),
+				PairWeight:  r.Float64s(),
+				ClassWeight: data.Histogram(r.Float64s()),
+				Info:        info,
+			}
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			K := res.NumClasses
+			nPairs := K * (K - 1) / 2
+			if K < 2 || len(res.Models) != nPairs ||
+				len(res.PairWeight) != nPairs || len(res.ClassWeight) != K {
+				return nil, model.ErrEncoding
+			}
+			return res, nil
+		},
+	})
+}
+
+// writeModels writes a list of binary classifiers.  Constant
+// classifiers, used for classes without training samples, are stored
+// inline; all other classifiers are stored as nested containers.
+func writeModels(w *model.Writer, models []classification.Classifier) {
+	w.Int(len(models))
+	for _, m := range models {
+		if c, ok := m.(constant); ok {
+			w.Bool(true)
+			w.Float64(c[0])
+			w.Float64(c[1])
+			continue
+		}
+		w.Bool(false)
+		w.Classifier(m)
+	}
+}
+
+// readModels reads a list of binary classifiers written by
+// writeModels.
+func readModels(r *model.Reader) []classification.Classifier {
+	n := r.Len(maxModels)
+	var res []classification.Classifier
+	for i := 0; i < n && r.Err() == nil; i++ {
+		if r.Bool() {
+			res = append(res, constant{r.Float64(), r.Float64()})
+		} else {
+			res = append(res, r.Classifier())
+		}
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package multiclass
+
+import (
+	"math"
+
+	"seehuhn.de/go/classification/data"
+)
+
+// couple combines the pairwise probabilities r[pos] = P(i | i or j)
+// for all pairs i < j of classes, into class probabilities.  The
+// method of Hastie and Tibshirani minimises the weighted
+// Kullback-Leibler distance between r and mu[i,j] = p[i] / (p[i] +
+// p[j]), using the pair weights `n`.  Classes with zero weight in
+// `classWeight` get probability zero.
+func couple(classWeight data.Histogram, r, n []float64) data.Histogram {
+	const eps = 1e-7
+	K := len(classWeight)
+
+	// rr[i][j] = P(i | i or j), nn[i][j] = weight of the pair
+	rr := make([][]float64, K)
+	nn := make([][]float64, K)
+	for i := range rr {
+		rr[i] = make([]float64, K)
+		nn[i] = make([]float64, K)
+	}
+	pos := 0
+	for i := 0; i < K; i++ {
+		for j := i + 1; j < K; j++ {
+			rij := math.Min(math.Max(r[pos], eps), 1-eps)
+			rr[i][j] = rij
+			rr[j][i] = 1 - rij
+			nn[i][j] = n[pos]
+			nn[j][i] = n[pos]
+			pos++
+		}
+	}
+
+	// Classes which never occurred in training get probability zero.
+	p := make(data.Histogram, K)
+	for i, w := range classWeight {
+		if w > 0 {
+			p[i] = 1
+		}
+	}
+	if p.Sum() <= 0 {
+		for i := range p {
+			p[i] = 1
+		}
+		return p.Probabilities()
+	}
+	p = p.Probabilities()
+
+	for iter := 0; iter < 1000; iter++ {
+		maxChange := 0.0
+		for i := range p {
+			if p[i] <= 0 {
+				continue
+			}
+			num := 0.0
+			den := 0.0
+			for j := range p {
+				if j == i || nn[i][j] <= 0 {
+					continue
+				}
+				num += nn[i][j] * rr[i][j]
+				den += nn[i][j] * p[i] / (p[i] + p[j])
+			}
+			if den <= 0 {
+				continue
+			}
+			old := p[i]
+			p[i] *= num / den
+			sum := 1 - old + p[i]
+			for k := range p {
+				p[k] /= sum
+			}
+			maxChange = math.Max(maxChange, math.Abs(p[i]-old))
+		}
+		if maxChange < 1e-10 {
+			break
+		}
+	}
+	return p
+}
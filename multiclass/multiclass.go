@@ -0,0 +1,344 @@
+// Package multiclass implements methods to reduce multiclass problems
+// to binary classification problems.
+//
+// OneVsRest trains one binary classifier per class, which separates
+// the class from all other classes.  OneVsOne trains one binary
+// classifier for each pair of classes, and combines the pairwise
+// probabilities using the coupling method from "Classification by
+// Pairwise Coupling" by Hastie and Tibshirani (1998).
+package multiclass
+
+import (
+	"runtime"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+)
+
+// OneVsRest is a classification.Factory which trains one binary
+// classifier for every class.
+type OneVsRest struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.  If this is empty, a name is derived
+	// from the name of the base factory.
+	Name string
+
+	// Base is used to train the binary classifiers.  For each binary
+	// problem, class 1 corresponds to the class being separated from
+	// the others.
+	Base classification.Factory
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *OneVsRest) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.Base.GetName() + ", one-vs-rest"
+}
+
+// FromData trains the binary classifiers.
+func (f *OneVsRest) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// CheckedFromData trains the binary classifiers.  This implements the
+// classification.CheckedFactory interface.
+func (f *OneVsRest) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClassifierFromData trains the binary classifiers.  The method panics
+// if the classifier cannot be constructed; use CheckedFromData to get
+// an error instead.
+func (f *OneVsRest) ClassifierFromData(d *data.Data) *OneVsRestClassifier {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (f *OneVsRest) checkedClassifierFromData(d *data.Data) (*OneVsRestClassifier, error) {
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	K := d.NumClasses
+	hist := d.GetHist()
+	total := hist.Sum()
+
+	res := &OneVsRestClassifier{
+		Models: make([]classification.Classifier, K),
+		Info:   classification.NewInfo(d),
+	}
+	var problems []*data.Data
+	var index []int
+	for k := 0; k < K; k++ {
+		switch {
+		case hist[k] <= 0:
+			res.Models[k] = constant{1, 0}
+		case hist[k] >= total:
+			res.Models[k] = constant{0, 1}
+		default:
+			b := *d // make a shallow copy
+			b.NumClasses = 2
+			b.ClassNames = nil
+			b.Y = make([]int, len(d.Y))
+			for _, row := range d.GetRows() {
+				if d.Y[row] == k {
+					b.Y[row] = 1
+				}
+			}
+			problems = append(problems, &b)
+			index = append(index, k)
+		}
+	}
+	models, err := trainAll(f.Base, problems)
+	if err != nil {
+		return nil, err
+	}
+	for i, model := range models {
+		res.Models[index[i]] = model
+	}
+	return res, nil
+}
+
+// OneVsRestClassifier combines the binary classifiers trained by
+// OneVsRest.
+type OneVsRestClassifier struct {
+	// Models gives the binary classifier for each class.
+	Models []classification.Classifier
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *OneVsRestClassifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.  The probabilities from the binary
+// classifiers are normalised to sum to one.
+func (c *OneVsRestClassifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	prob := make(data.Histogram, len(c.Models))
+	for k, model := range c.Models {
+		prob[k] = model.EstimateClassProbabilities(x)[1]
+	}
+	if prob.Sum() <= 0 {
+		for k := range prob {
+			prob[k] = 1
+		}
+	}
+	return prob.Probabilities()
+}
+
+// OneVsOne is a classification.Factory which trains one binary
+// classifier for every pair of classes.
+type OneVsOne struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.  If this is empty, a name is derived
+	// from the name of the base factory.
+	Name string
+
+	// Base is used to train the binary classifiers.  For the pair of
+	// classes i < j, class 0 of the binary problem corresponds to i
+	// and class 1 corresponds to j.
+	Base classification.Factory
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *OneVsOne) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.Base.GetName() + ", one-vs-one"
+}
+
+// FromData trains the binary classifiers.
+func (f *OneVsOne) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// CheckedFromData trains the binary classifiers.  This implements the
+// classification.CheckedFactory interface.
+func (f *OneVsOne) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClassifierFromData trains the binary classifiers.  The method panics
+// if the classifier cannot be constructed; use CheckedFromData to get
+// an error instead.
+func (f *OneVsOne) ClassifierFromData(d *data.Data) *OneVsOneClassifier {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (f *OneVsOne) checkedClassifierFromData(d *data.Data) (*OneVsOneClassifier, error) {
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	K := d.NumClasses
+	hist := d.GetHist()
+
+	rowsByClass := make([][]int, K)
+	for _, row := range d.GetRows() {
+		rowsByClass[d.Y[row]] = append(rowsByClass[d.Y[row]], row)
+	}
+
+	res := &OneVsOneClassifier{
+		NumClasses:  K,
+		Models:      make([]classification.Classifier, K*(K-1)/2),
+		PairWeight:  make([]float64, K*(K-1)/2),
+		ClassWeight: hist,
+		Info:        classification.NewInfo(d),
+	}
+	var problems []*data.Data
+	var index []int
+	pos := 0
+	for i := 0; i < K; i++ {
+		for j := i + 1; j < K; j++ {
+			switch {
+			case hist[i] <= 0 && hist[j] <= 0:
+				res.Models[pos] = constant{0.5, 0.5}
+			case hist[j] <= 0:
+				res.Models[pos] = constant{1, 0}
+			case hist[i] <= 0:
+				res.Models[pos] = constant{0, 1}
+			default:
+				rows := make([]int, 0, len(rowsByClass[i])+len(rowsByClass[j]))
+				rows = append(rows, rowsByClass[i]...)
+				rows = append(rows, rowsByClass[j]...)
+				y := make([]int, len(d.Y))
+				for _, row := range rowsByClass[j] {
+					y[row] = 1
+				}
+				b := *d // make a shallow copy
+				b.NumClasses = 2
+				if d.ClassNames != nil {
+					b.ClassNames = []string{d.ClassNames[i], d.ClassNames[j]}
+				}
+				b.Y = y
+				b.Rows = rows
+				problems = append(problems, &b)
+				index = append(index, pos)
+				res.PairWeight[pos] = hist[i] + hist[j]
+			}
+			pos++
+		}
+	}
+	models, err := trainAll(f.Base, problems)
+	if err != nil {
+		return nil, err
+	}
+	for i, model := range models {
+		res.Models[index[i]] = model
+	}
+	return res, nil
+}
+
+// OneVsOneClassifier combines the binary classifiers trained by
+// OneVsOne.
+type OneVsOneClassifier struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Models gives the binary classifiers for all pairs i < j of
+	// classes, in the order (0,1), (0,2), ..., (0,K-1), (1,2), ...
+	Models []classification.Classifier
+
+	// PairWeight gives the total weight of the training samples for
+	// each pair of classes, in the same order as Models.  These are
+	// used as the weights in the coupling method.  Pairs where one of
+	// the classes has no training samples have weight zero.
+	PairWeight []float64
+
+	// ClassWeight gives the total weight of the training samples in
+	// each class.  Classes without training samples have probability
+	// zero.
+	ClassWeight data.Histogram
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *OneVsOneClassifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`, obtained by pairwise coupling.
+func (c *OneVsOneClassifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	r := make([]float64, len(c.Models))
+	for pos, model := range c.Models {
+		r[pos] = model.EstimateClassProbabilities(x)[0]
+	}
+	return couple(c.ClassWeight, r, c.PairWeight)
+}
+
+// constant is a binary classifier which always returns the same
+// probabilities.
+type constant [2]float64
+
+func (c constant) EstimateClassProbabilities(x []float64) data.Histogram {
+	return data.Histogram{c[0], c[1]}
+}
+
+// trainAll trains classifiers for all problems in parallel.  If
+// training fails for any of the problems, the error for the first such
+// problem is returned.
+func trainAll(base classification.Factory, problems []*data.Data) ([]classification.Classifier, error) {
+	res := make([]classification.Classifier, len(problems))
+	errs := make([]error, len(problems))
+	cf := classification.Checked(base)
+
+	jobs := make(chan int, len(problems))
+	for i := range problems {
+		jobs <- i
+	}
+	close(jobs)
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(problems) {
+		numWorkers = len(problems)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res[i], errs[i] = cf.CheckedFromData(problems[i])
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
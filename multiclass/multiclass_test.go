@@ -0,0 +1,150 @@
+package multiclass
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// fourClasses returns samples from four normal distributions in the
+// plane, centred at the corners of a square with side length 3.
+func fourClasses(n int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(4, n, 2)
+	for i := 0; i < n; i++ {
+		y := rng.Intn(4)
+		x := d.X.Row(i)
+		x[0] = rng.NormFloat64() + 3*float64(y%2)
+		x[1] = rng.NormFloat64() + 3*float64(y/2)
+		d.Y[i] = y
+	}
+	return d
+}
+
+// binaryOnly wraps a factory and panics if it is used for anything
+// other than a binary problem.
+type binaryOnly struct {
+	classification.Factory
+}
+
+func (f binaryOnly) FromData(d *data.Data) classification.Classifier {
+	if d.NumClasses != 2 {
+		panic("not a binary problem")
+	}
+	return f.Factory.FromData(d)
+}
+
+func (*Tests) TestMulticlass(c *C) {
+	train := fourClasses(2000, 1)
+	test := fourClasses(2000, 2)
+	set := data.MakeSet("four classes", train, test)
+
+	base := binaryOnly{&logit.Factory{}}
+	methods := []classification.Factory{
+		&OneVsRest{Base: base},
+		&OneVsOne{Base: base},
+	}
+	for _, m := range methods {
+		res := classification.Assess(m, set, loss.ZeroOne)
+		c.Assert(res.Err, IsNil)
+		// The Bayes error rate is 1 - (1-Phi(-1.5))^2 = 0.127.
+		c.Check(res.MeanLoss < 0.15, Equals, true,
+			Commentf("%s: loss %g", m.GetName(), res.MeanLoss))
+	}
+}
+
+func (*Tests) TestMissingClass(c *C) {
+	train := fourClasses(400, 3)
+	for i, y := range train.Y {
+		if y == 2 {
+			train.Y[i] = 3
+		}
+	}
+	x := []float64{0, 3}
+
+	ovr := (&OneVsRest{Base: &logit.Factory{}}).ClassifierFromData(train)
+	p := ovr.EstimateClassProbabilities(x)
+	c.Check(p[2], Equals, 0.0)
+	c.Check(p.ArgMax(), Equals, 3)
+
+	ovo := (&OneVsOne{Base: &logit.Factory{}}).ClassifierFromData(train)
+	p = ovo.EstimateClassProbabilities(x)
+	c.Check(p[2], Equals, 0.0)
+	c.Check(p.ArgMax(), Equals, 3)
+	c.Check(math.Abs(p.Sum()-1) < 1e-12, Equals, true)
+}
+
+func (*Tests) TestSingleClass(c *C) {
+	train := fourClasses(100, 4)
+	for i := range train.Y {
+		train.Y[i] = 0
+	}
+	ovo := (&OneVsOne{Base: &baseline.Prior{}}).ClassifierFromData(train)
+	p := ovo.EstimateClassProbabilities([]float64{0, 0})
+	c.Check(p, DeepEquals, data.Histogram{1, 0, 0, 0})
+}
+
+// failing is a factory which always panics, to check that errors from
+// the binary classifiers, which are trained in separate goroutines,
+// are reported to the caller.
+type failing struct{}
+
+func (failing) GetName() string { return "failing" }
+
+func (failing) FromData(*data.Data) classification.Classifier {
+	panic("failing.FromData")
+}
+
+func (*Tests) TestBaseError(c *C) {
+	train := fourClasses(100, 5)
+	methods := []classification.CheckedFactory{
+		&OneVsRest{Base: failing{}},
+		&OneVsOne{Base: failing{}},
+	}
+	for _, m := range methods {
+		_, err := m.CheckedFromData(train)
+		var pErr *classification.PanicError
+		c.Check(errors.As(err, &pErr), Equals, true,
+			Commentf("%s: %v", m.GetName(), err))
+	}
+}
+
+func (*Tests) TestCouple(c *C) {
+	// Consistent pairwise probabilities must be reproduced exactly.
+	p := []float64{0.5, 0.3, 0.15, 0.05}
+	K := len(p)
+	var r, n []float64
+	for i := 0; i < K; i++ {
+		for j := i + 1; j < K; j++ {
+			r = append(r, p[i]/(p[i]+p[j]))
+			n = append(n, float64(10+i+j))
+		}
+	}
+	q := couple(data.Histogram{1, 1, 1, 1}, r, n)
+	for i := range p {
+		c.Check(math.Abs(q[i]-p[i]) < 1e-6, Equals, true,
+			Commentf("%d: %g vs %g", i, q[i], p[i]))
+	}
+
+	// Inconsistent probabilities still give a distribution which
+	// respects the ordering of pairwise wins.
+	r = []float64{0.9, 0.8, 0.7}
+	q = couple(data.Histogram{1, 1, 1}, r, []float64{1, 1, 1})
+	c.Check(math.Abs(q.Sum()-1) < 1e-12, Equals, true)
+	c.Check(q[0] > q[1] && q[1] > q[2], Equals, true)
+}
@@ -0,0 +1,70 @@
+package classification
+
+import (
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Predictions stores the estimated class probabilities of a classifier
+// for a test data set, together with the true classes.  This allows
+// to compute performance measures which need more information than
+// the mean loss, for example ROC curves.
+type Predictions struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Y gives the true class of every test sample.
+	Y []int
+
+	// Prob stores the estimated class probabilities, one row per test
+	// sample and one column per class.
+	Prob *matrix.Float64
+
+	// Weights, if non-nil, gives the weight of every test sample.
+	Weights []float64
+}
+
+// Predict applies the classifier `c` to all samples in `d`.
+func Predict(c Classifier, d *data.Data) *Predictions {
+	p := newPredictions(d.NumClasses, d.NRow(), d.Weights != nil)
+	for _, row := range d.GetRows() {
+		prob := c.EstimateClassProbabilities(d.X.Row(row))
+		p.add(d.Y[row], prob, d.Weight(row))
+	}
+	return p
+}
+
+func newPredictions(numClasses, capacity int, weighted bool) *Predictions {
+	p := &Predictions{
+		NumClasses: numClasses,
+		Y:          make([]int, 0, capacity),
+		Prob:       matrix.NewFloat64(capacity, numClasses, 0, nil),
+	}
+	if weighted {
+		p.Weights = make([]float64, 0, capacity)
+	}
+	return p
+}
+
+// add appends a sample to the predictions.  The Prob matrix must have
+// been allocated with sufficient capacity.
+func (p *Predictions) add(y int, prob data.Histogram, w float64) {
+	copy(p.Prob.Row(len(p.Y)), prob)
+	p.Y = append(p.Y, y)
+	if p.Weights != nil {
+		p.Weights = append(p.Weights, w)
+	}
+}
+
+// Len returns the number of test samples.
+func (p *Predictions) Len() int {
+	return len(p.Y)
+}
+
+// Weight returns the weight of test sample `i`.
+func (p *Predictions) Weight(i int) float64 {
+	if p.Weights == nil {
+		return 1
+	}
+	return p.Weights[i]
+}
@@ -0,0 +1,228 @@
+package classification
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Report summarises the predictions of a classifier on a test data
+// set.  Each sample is assigned to the class chosen by the decision
+// rule of the classifier (see Decide), which is the class with the
+// highest estimated probability unless the classifier implements
+// Decider, and the (weighted) counts of true and predicted classes
+// are tabulated.
+type Report struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Confusion is the confusion matrix.  Rows correspond to the true
+	// classes, columns to the predicted classes.  If the test data has
+	// sample weights, the entries are sums of weights.
+	Confusion *matrix.Float64
+
+	// Total is the sum of all entries of the confusion matrix.
+	Total float64
+
+	// Support gives the total weight of the samples in each class.
+	Support []float64
+
+	// Precision, Recall and F1 give the per-class metrics.  If no
+	// samples were predicted to be in a class, the precision for this
+	// class is zero; if a class has no samples, the recall is zero.
+	Precision []float64
+	Recall    []float64
+	F1        []float64
+
+	// Accuracy is the fraction of correctly classified samples.
+	Accuracy float64
+
+	// The macro averages are unweighted means of the per-class
+	// metrics, taken over all classes which occur either as true or as
+	// predicted classes.
+	MacroPrecision float64
+	MacroRecall    float64
+	MacroF1        float64
+
+	// The micro averages are computed from the total numbers of true
+	// positives, false positives and false negatives.  Since every
+	// sample has exactly one true and one predicted class, all three
+	// equal the accuracy.
+	MicroPrecision float64
+	MicroRecall    float64
+	MicroF1        float64
+
+	// BalancedAccuracy is the mean recall over all classes which have
+	// samples.
+	BalancedAccuracy float64
+
+	// Kappa is Cohen's kappa, measuring the agreement between true and
+	// predicted classes beyond the agreement expected by chance.  Kappa
+	// is NaN if the agreement expected by chance is perfect.
+	Kappa float64
+}
+
+// NewReport allocates a new, empty report for `numClasses` classes.
+// Samples can be added using the Add method, and the metrics are
+// computed by the Update method.
+func NewReport(numClasses int) *Report {
+	return &Report{
+		NumClasses: numClasses,
+		Confusion:  matrix.NewFloat64(numClasses, numClasses, 0, nil),
+	}
+}
+
+// Add records a sample with true class `y` and predicted class
+// `pred`, with weight `w`.
+func (r *Report) Add(y, pred int, w float64) {
+	row := r.Confusion.Row(y)
+	row[pred] += w
+}
+
+// Update computes all metrics from the confusion matrix.
+func (r *Report) Update() {
+	K := r.NumClasses
+	predicted := make([]float64, K)
+	r.Support = make([]float64, K)
+	r.Precision = make([]float64, K)
+	r.Recall = make([]float64, K)
+	r.F1 = make([]float64, K)
+	r.Total = 0
+	correct := 0.0
+	for i := 0; i < K; i++ {
+		for j, nij := range r.Confusion.Row(i) {
+			r.Support[i] += nij
+			predicted[j] += nij
+		}
+		r.Total += r.Support[i]
+		correct += r.Confusion.At(i, i)
+	}
+
+	var sumPrecision, sumRecall, sumF1 float64
+	var sumBalanced float64
+	nUsed, nPresent := 0, 0
+	for k := 0; k < K; k++ {
+		tp := r.Confusion.At(k, k)
+		if predicted[k] > 0 {
+			r.Precision[k] = tp / predicted[k]
+		}
+		if r.Support[k] > 0 {
+			r.Recall[k] = tp / r.Support[k]
+			sumBalanced += r.Recall[k]
+			nPresent++
+		}
+		if s := r.Precision[k] + r.Recall[k]; s > 0 {
+			r.F1[k] = 2 * r.Precision[k] * r.Recall[k] / s
+		}
+		if predicted[k] > 0 || r.Support[k] > 0 {
+			sumPrecision += r.Precision[k]
+			sumRecall += r.Recall[k]
+			sumF1 += r.F1[k]
+			nUsed++
+		}
+	}
+
+	r.Accuracy = correct / r.Total
+	r.MicroPrecision = r.Accuracy
+	r.MicroRecall = r.Accuracy
+	r.MicroF1 = r.Accuracy
+	r.MacroPrecision = sumPrecision / float64(nUsed)
+	r.MacroRecall = sumRecall / float64(nUsed)
+	r.MacroF1 = sumF1 / float64(nUsed)
+	r.BalancedAccuracy = sumBalanced / float64(nPresent)
+
+	pe := 0.0
+	for k := 0; k < K; k++ {
+		pe += r.Support[k] * predicted[k]
+	}
+	pe /= r.Total * r.Total
+	if pe < 1 {
+		r.Kappa = (r.Accuracy - pe) / (1 - pe)
+	} else {
+		r.Kappa = math.NaN()
+	}
+}
+
+// String formats the report as a table, using the class indices as
+// class names.
+func (r *Report) String() string {
+	return r.Format(nil)
+}
+
+// Format formats the report as a table, consisting of the confusion
+// matrix, the per-class metrics and the summary metrics.  If
+// `classNames` is nil, the class indices are used as class names.
+func (r *Report) Format(classNames []string) string {
+	K := r.NumClasses
+	if classNames == nil {
+		classNames = make([]string, K)
+		for k := range classNames {
+			classNames[k] = fmt.Sprint(k)
+		}
+	}
+	nameWidth := len("true\\pred")
+	for _, name := range classNames {
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+	}
+	cellWidth := 6
+	for _, name := range classNames {
+		if len(name)+1 > cellWidth {
+			cellWidth = len(name) + 1
+		}
+	}
+	for i := 0; i < K; i++ {
+		for _, nij := range r.Confusion.Row(i) {
+			if l := len(formatCount(nij)) + 1; l > cellWidth {
+				cellWidth = l
+			}
+		}
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%-*s", nameWidth, "true\\pred")
+	for _, name := range classNames {
+		fmt.Fprintf(b, "%*s", cellWidth, name)
+	}
+	b.WriteString("\n")
+	for i := 0; i < K; i++ {
+		fmt.Fprintf(b, "%-*s", nameWidth, classNames[i])
+		for _, nij := range r.Confusion.Row(i) {
+			fmt.Fprintf(b, "%*s", cellWidth, formatCount(nij))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(b, "%-*s %9s %9s %9s %9s\n", nameWidth,
+		"class", "precision", "recall", "F1", "support")
+	for k := 0; k < K; k++ {
+		fmt.Fprintf(b, "%-*s %9.4f %9.4f %9.4f %9s\n", nameWidth,
+			classNames[k], r.Precision[k], r.Recall[k], r.F1[k],
+			formatCount(r.Support[k]))
+	}
+	fmt.Fprintf(b, "%-*s %9.4f %9.4f %9.4f %9s\n", nameWidth,
+		"macro avg", r.MacroPrecision, r.MacroRecall, r.MacroF1,
+		formatCount(r.Total))
+	fmt.Fprintf(b, "%-*s %9.4f %9.4f %9.4f %9s\n", nameWidth,
+		"micro avg", r.MicroPrecision, r.MicroRecall, r.MicroF1,
+		formatCount(r.Total))
+	b.WriteString("\n")
+
+	fmt.Fprintf(b, "accuracy          %.4f\n", r.Accuracy)
+	fmt.Fprintf(b, "balanced accuracy %.4f\n", r.BalancedAccuracy)
+	fmt.Fprintf(b, "Cohen's kappa     %.4f\n", r.Kappa)
+	return b.String()
+}
+
+// formatCount formats a (possibly weighted) count, omitting the
+// decimals for integer values.
+func formatCount(x float64) string {
+	if x == math.Trunc(x) && math.Abs(x) < 1e15 {
+		return fmt.Sprintf("%.0f", x)
+	}
+	return fmt.Sprintf("%.2f", x)
+}
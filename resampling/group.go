@@ -0,0 +1,84 @@
+package resampling
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"seehuhn.de/go/classification/data"
+)
+
+// Group performs K-fold cross-validation where all samples with the
+// same group ID are kept in the same fold.  This is useful if samples
+// within a group are not independent, for example for repeated
+// measurements on the same subject.
+type Group struct {
+	// K gives the number of folds.  K must be at least 2.
+	K int
+
+	// Groups gives the group ID for every sample.  The slice is
+	// indexed by row number of the data matrix, i.e. it must have the
+	// same length as the Y field of the data.
+	Groups []int
+
+	// Seed is used to break ties when assigning groups to folds.
+	Seed int64
+}
+
+// Splits implements the Scheme interface.
+//
+// Groups are assigned to folds in order of decreasing size, where
+// every group is placed in the fold with the fewest samples so far.
+// This keeps the fold sizes approximately equal.
+func (s *Group) Splits(d *data.Data) ([]data.Set, error) {
+	K := s.K
+	if K < 2 {
+		return nil, fmt.Errorf("need at least K=2 groups for cross-validation, got %d: %w",
+			K, ErrInvalidScheme)
+	}
+	if len(s.Groups) != len(d.Y) {
+		return nil, fmt.Errorf("%d group IDs for %d samples: %w",
+			len(s.Groups), len(d.Y), data.ErrShape)
+	}
+
+	rows := d.GetRows()
+	size := make(map[int]int)
+	var ids []int
+	for _, row := range rows {
+		g := s.Groups[row]
+		if size[g] == 0 {
+			ids = append(ids, g)
+		}
+		size[g]++
+	}
+	if len(ids) < K {
+		return nil, fmt.Errorf("%d groups for %d-fold cross-validation: %w",
+			len(ids), K, data.ErrTooFewSamples)
+	}
+
+	rng := rand.New(rand.NewSource(seedOrDefault(s.Seed)))
+	rng.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+	sort.SliceStable(ids, func(i, j int) bool {
+		return size[ids[i]] > size[ids[j]]
+	})
+	groupFold := make(map[int]int, len(ids))
+	foldSize := make([]int, K)
+	for _, g := range ids {
+		best := 0
+		for k := 1; k < K; k++ {
+			if foldSize[k] < foldSize[best] {
+				best = k
+			}
+		}
+		groupFold[g] = best
+		foldSize[best] += size[g]
+	}
+
+	fold := make([]int, len(rows))
+	for i, row := range rows {
+		fold[i] = groupFold[s.Groups[row]]
+	}
+	return foldSplits("group", d, rows, fold, K), nil
+}
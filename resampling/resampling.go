@@ -0,0 +1,290 @@
+// Package resampling implements methods to repeatedly split a data
+// set into training and test data, for example for cross-validation.
+//
+// Every method is described by a value implementing the Scheme
+// interface.  Schemes can be passed to classification.AssessResampled
+// to assess a classifier, and to tree.Factory to choose the amount of
+// pruning.
+package resampling
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"seehuhn.de/go/classification/data"
+)
+
+const resamplingSeed = 1630442983
+
+// ErrInvalidScheme is wrapped by errors caused by invalid parameters of a
+// resampling scheme.
+var ErrInvalidScheme = errors.New("invalid resampling parameter")
+
+// Scheme describes a method to split data into pairs of training and
+// test data.
+type Scheme interface {
+	// Splits returns the training/test splits for the data `d`.  The
+	// .TrainingData() and .TestData() methods of the returned data sets
+	// never return an error.
+	Splits(d *data.Data) ([]data.Set, error)
+}
+
+// split is a data.Set where the training and test data are given by
+// subsets of the rows of a common data set.
+type split struct {
+	name  string
+	data  *data.Data
+	train []int
+	test  []int
+}
+
+func (s *split) GetName() string {
+	return s.name
+}
+
+func (s *split) TrainingData() (*data.Data, error) {
+	res := *s.data // make a shallow copy
+	res.Rows = s.train
+	return &res, nil
+}
+
+func (s *split) TestData() (*data.Data, error) {
+	res := *s.data // make a shallow copy
+	res.Rows = s.test
+	return &res, nil
+}
+
+// foldSplits converts an assignment of rows to folds into one split
+// per fold, where the fold is used as test data and the remaining
+// folds form the training data.
+func foldSplits(name string, d *data.Data, rows []int, fold []int, K int) []data.Set {
+	res := make([]data.Set, K)
+	for k := 0; k < K; k++ {
+		s := &split{
+			name: fmt.Sprintf("%s %d/%d", name, k, K),
+			data: d,
+		}
+		for i, row := range rows {
+			if fold[i] == k {
+				s.test = append(s.test, row)
+			} else {
+				s.train = append(s.train, row)
+			}
+		}
+		res[k] = s
+	}
+	return res
+}
+
+func seedOrDefault(seed int64) int64 {
+	if seed == 0 {
+		return resamplingSeed
+	}
+	return seed
+}
+
+func shuffled(rows []int, rng *rand.Rand) []int {
+	res := append([]int(nil), rows...)
+	rng.Shuffle(len(res), func(i, j int) {
+		res[i], res[j] = res[j], res[i]
+	})
+	return res
+}
+
+// KFold splits the data randomly into K parts of (approximately)
+// equal size.  Every part is used once as test data, with the
+// remaining parts forming the training data.  The splits are the same
+// as the ones returned by data.Data.GetXValSet.
+type KFold struct {
+	// K gives the number of folds.  K must be at least 2.
+	K int
+
+	// Seed is used to initialise the random number generator.
+	Seed int64
+}
+
+// Splits implements the Scheme interface.
+func (s *KFold) Splits(d *data.Data) ([]data.Set, error) {
+	K := s.K
+	if K < 2 {
+		return nil, fmt.Errorf("need at least K=2 groups for cross-validation, got %d: %w",
+			K, ErrInvalidScheme)
+	}
+	if d.NRow() < K {
+		return nil, fmt.Errorf("%d samples for %d-fold cross-validation: %w",
+			d.NRow(), K, data.ErrTooFewSamples)
+	}
+	seed := seedOrDefault(s.Seed)
+	res := make([]data.Set, K)
+	for k := range res {
+		res[k] = d.GetXValSet(seed, K, k)
+	}
+	return res, nil
+}
+
+// Stratified splits the data randomly into K parts, such that the
+// class proportions in every part are as close as possible to the
+// class proportions of the full data.
+type Stratified struct {
+	// K gives the number of folds.  K must be at least 2.
+	K int
+
+	// Seed is used to initialise the random number generator.
+	Seed int64
+}
+
+// Splits implements the Scheme interface.
+func (s *Stratified) Splits(d *data.Data) ([]data.Set, error) {
+	K := s.K
+	if K < 2 {
+		return nil, fmt.Errorf("need at least K=2 groups for cross-validation, got %d: %w",
+			K, ErrInvalidScheme)
+	}
+	if d.NRow() < K {
+		return nil, fmt.Errorf("%d samples for %d-fold cross-validation: %w",
+			d.NRow(), K, data.ErrTooFewSamples)
+	}
+	return stratified(d, K, seedOrDefault(s.Seed)), nil
+}
+
+func stratified(d *data.Data, K int, seed int64) []data.Set {
+	rng := rand.New(rand.NewSource(seed))
+	rows := shuffled(d.GetRows(), rng)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return d.Y[rows[i]] < d.Y[rows[j]]
+	})
+	// Dealing the sorted rows to the folds in turn distributes every
+	// class as evenly as possible.
+	fold := make([]int, len(rows))
+	for i := range fold {
+		fold[i] = i % K
+	}
+	return foldSplits("stratified", d, rows, fold, K)
+}
+
+// Repeated performs K-fold cross-validation several times, using a
+// different random split into folds each time.  This reduces the
+// variance caused by the choice of folds.
+type Repeated struct {
+	// K gives the number of folds.  K must be at least 2.
+	K int
+
+	// Repeats gives the number of repetitions.  The default is 10.
+	Repeats int
+
+	// Stratified, if set, uses stratified folds for every
+	// repetition.
+	Stratified bool
+
+	// Seed is used to initialise the random number generator.
+	// Repetition r uses seed Seed+r.
+	Seed int64
+}
+
+// Splits implements the Scheme interface.
+func (s *Repeated) Splits(d *data.Data) ([]data.Set, error) {
+	repeats := s.Repeats
+	if repeats == 0 {
+		repeats = 10
+	}
+	if repeats < 0 {
+		return nil, fmt.Errorf("%d repetitions: %w", repeats, ErrInvalidScheme)
+	}
+	K := s.K
+	seed := seedOrDefault(s.Seed)
+	var res []data.Set
+	for r := 0; r < repeats; r++ {
+		var scheme Scheme
+		if s.Stratified {
+			scheme = &Stratified{K: K, Seed: seed + int64(r)}
+		} else {
+			scheme = &KFold{K: K, Seed: seed + int64(r)}
+		}
+		splits, err := scheme.Splits(d)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, splits...)
+	}
+	return res, nil
+}
+
+// LeaveOneOut uses every sample once as the test data, with all other
+// samples forming the training data.  This requires one training run
+// per sample.
+type LeaveOneOut struct{}
+
+// Splits implements the Scheme interface.
+func (s *LeaveOneOut) Splits(d *data.Data) ([]data.Set, error) {
+	rows := d.GetRows()
+	n := len(rows)
+	if n < 2 {
+		return nil, fmt.Errorf("%d samples for leave-one-out: %w", n, data.ErrTooFewSamples)
+	}
+	res := make([]data.Set, n)
+	for i, row := range rows {
+		train := make([]int, 0, n-1)
+		train = append(train, rows[:i]...)
+		train = append(train, rows[i+1:]...)
+		res[i] = &split{
+			name:  fmt.Sprintf("leave out %d", row),
+			data:  d,
+			train: train,
+			test:  []int{row},
+		}
+	}
+	return res, nil
+}
+
+// MonteCarlo repeatedly splits the data randomly into training and
+// test data.  Unlike for K-fold cross-validation, the test sets of
+// different splits can overlap.
+type MonteCarlo struct {
+	// Repeats gives the number of random splits.  The default is 10.
+	Repeats int
+
+	// TestFraction gives the fraction of samples used as test data.
+	// The default is 0.25.
+	TestFraction float64
+
+	// Seed is used to initialise the random number generator.
+	Seed int64
+}
+
+// Splits implements the Scheme interface.
+func (s *MonteCarlo) Splits(d *data.Data) ([]data.Set, error) {
+	numSplits := s.Repeats
+	if numSplits == 0 {
+		numSplits = 10
+	}
+	frac := s.TestFraction
+	if frac == 0 {
+		frac = 0.25
+	}
+	rows := d.GetRows()
+	n := len(rows)
+	nTest := int(frac*float64(n) + 0.5)
+	if numSplits < 0 || frac <= 0 || frac >= 1 {
+		return nil, fmt.Errorf("%d splits with test fraction %g: %w",
+			numSplits, frac, ErrInvalidScheme)
+	}
+	if nTest < 1 || nTest >= n {
+		return nil, fmt.Errorf("%d samples for test fraction %g: %w",
+			n, frac, data.ErrTooFewSamples)
+	}
+
+	rng := rand.New(rand.NewSource(seedOrDefault(s.Seed)))
+	res := make([]data.Set, numSplits)
+	for k := range res {
+		perm := shuffled(rows, rng)
+		res[k] = &split{
+			name:  fmt.Sprintf("Monte Carlo %d/%d", k, numSplits),
+			data:  d,
+			train: perm[nTest:],
+			test:  perm[:nTest],
+		}
+	}
+	return res, nil
+}
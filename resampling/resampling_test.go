@@ -0,0 +1,205 @@
+package resampling
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func testData(n int) *data.Data {
+	d := &data.Data{
+		NumClasses: 3,
+		X:          matrix.NewFloat64(n, 1, 0, nil),
+		Y:          make([]int, n),
+	}
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		// class proportions 1/2, 1/3, 1/6
+		switch {
+		case i%6 < 3:
+			d.Y[i] = 0
+		case i%6 < 5:
+			d.Y[i] = 1
+		default:
+			d.Y[i] = 2
+		}
+	}
+	return d
+}
+
+func getRows(c *C, s data.Set) ([]int, []int) {
+	train, err := s.TrainingData()
+	c.Assert(err, IsNil)
+	test, err := s.TestData()
+	c.Assert(err, IsNil)
+	return train.GetRows(), test.GetRows()
+}
+
+// checkPartition verifies that every split partitions the data, and
+// that every row is used exactly once as test data.
+func checkPartition(c *C, d *data.Data, splits []data.Set) {
+	n := d.NRow()
+	count := make([]int, n)
+	for _, s := range splits {
+		train, test := getRows(c, s)
+		together := append(append([]int(nil), train...), test...)
+		sort.Ints(together)
+		c.Assert(together, HasLen, n)
+		for i, row := range together {
+			c.Assert(row, Equals, i)
+		}
+		for _, row := range test {
+			count[row]++
+		}
+	}
+	for _, k := range count {
+		c.Check(k, Equals, 1)
+	}
+}
+
+func (*Tests) TestKFold(c *C) {
+	d := testData(50)
+	splits, err := (&KFold{K: 4, Seed: 1}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 4)
+	checkPartition(c, d, splits)
+	for k, s := range splits {
+		train, test := getRows(c, s)
+		train2, test2 := getRows(c, d.GetXValSet(1, 4, k))
+		c.Check(train, DeepEquals, train2)
+		c.Check(test, DeepEquals, test2)
+	}
+
+	_, err = (&KFold{K: 1}).Splits(d)
+	c.Check(err, NotNil)
+	_, err = (&KFold{}).Splits(d)
+	c.Check(errors.Is(err, ErrInvalidScheme), Equals, true)
+	_, err = (&KFold{K: 60}).Splits(d)
+	c.Check(err, NotNil)
+}
+
+func (*Tests) TestStratified(c *C) {
+	d := testData(60)
+	splits, err := (&Stratified{K: 5}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 5)
+	checkPartition(c, d, splits)
+	for _, s := range splits {
+		test, _ := s.TestData()
+		c.Check(test.GetHist(), DeepEquals, data.Histogram{6, 4, 2})
+	}
+}
+
+func (*Tests) TestRepeated(c *C) {
+	d := testData(30)
+	splits, err := (&Repeated{K: 3, Repeats: 4, Stratified: true}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 12)
+	for r := 0; r < 4; r++ {
+		checkPartition(c, d, splits[3*r:3*r+3])
+	}
+	_, first := getRows(c, splits[0])
+	_, second := getRows(c, splits[3])
+	c.Check(first, Not(DeepEquals), second)
+
+	_, err = (&Repeated{Repeats: 2}).Splits(d)
+	c.Check(errors.Is(err, ErrInvalidScheme), Equals, true)
+}
+
+func (*Tests) TestGroup(c *C) {
+	d := testData(40)
+	groups := make([]int, 40)
+	for i := range groups {
+		groups[i] = i / 3
+	}
+	splits, err := (&Group{K: 3, Groups: groups}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 3)
+	checkPartition(c, d, splits)
+	for _, s := range splits {
+		train, test := getRows(c, s)
+		c.Check(len(test) >= 12 && len(test) <= 15, Equals, true)
+		inTest := make(map[int]bool)
+		for _, row := range test {
+			inTest[groups[row]] = true
+		}
+		for _, row := range train {
+			c.Check(inTest[groups[row]], Equals, false)
+		}
+	}
+
+	_, err = (&Group{K: 3, Groups: groups[:10]}).Splits(d)
+	c.Check(err, NotNil)
+	_, err = (&Group{K: 3, Groups: make([]int, 40)}).Splits(d)
+	c.Check(err, NotNil)
+}
+
+func (*Tests) TestLeaveOneOut(c *C) {
+	d := testData(7)
+	d.Rows = []int{1, 2, 3, 4, 5, 6}
+	splits, err := (&LeaveOneOut{}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 6)
+	for i, s := range splits {
+		train, test := getRows(c, s)
+		c.Check(test, DeepEquals, []int{i + 1})
+		c.Check(train, HasLen, 5)
+	}
+}
+
+func (*Tests) TestMonteCarlo(c *C) {
+	d := testData(40)
+	splits, err := (&MonteCarlo{Repeats: 3, TestFraction: 0.3}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 3)
+	for _, s := range splits {
+		train, test := getRows(c, s)
+		c.Check(test, HasLen, 12)
+		c.Check(train, HasLen, 28)
+	}
+
+	_, err = (&MonteCarlo{TestFraction: 1}).Splits(d)
+	c.Check(err, NotNil)
+}
+
+func (*Tests) TestForwardChaining(c *C) {
+	d := testData(20)
+	time := make([]float64, 20)
+	for i := range time {
+		time[i] = float64(20 - i) // reverse order
+	}
+	splits, err := (&ForwardChaining{K: 3, Time: time}).Splits(d)
+	c.Assert(err, IsNil)
+	c.Assert(splits, HasLen, 3)
+	prevTrain := 0
+	for _, s := range splits {
+		train, test := getRows(c, s)
+		c.Check(len(train) > prevTrain, Equals, true)
+		prevTrain = len(train)
+		for _, i := range train {
+			for _, j := range test {
+				c.Check(time[i] < time[j], Equals, true)
+			}
+		}
+	}
+
+	splits, err = (&ForwardChaining{K: 4, MaxTrain: 3}).Splits(d)
+	c.Assert(err, IsNil)
+	train, test := getRows(c, splits[3])
+	c.Check(train, DeepEquals, []int{13, 14, 15})
+	c.Check(test, DeepEquals, []int{16, 17, 18, 19})
+
+	_, err = (&ForwardChaining{}).Splits(d)
+	c.Check(errors.Is(err, ErrInvalidScheme), Equals, true)
+}
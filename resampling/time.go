@@ -0,0 +1,69 @@
+package resampling
+
+import (
+	"fmt"
+	"sort"
+
+	"seehuhn.de/go/classification/data"
+)
+
+// ForwardChaining produces splits for time-ordered data, where the
+// test data always comes after the training data.  The samples are
+// divided into K+1 consecutive blocks, and split k uses blocks
+// 0, ..., k for training and block k+1 for testing.
+type ForwardChaining struct {
+	// K gives the number of splits.  K must be at least 1.
+	K int
+
+	// Time, if non-nil, gives the time for every sample, indexed by
+	// row number of the data matrix.  If Time is nil, the samples are
+	// assumed to be ordered by time.
+	Time []float64
+
+	// MaxTrain, if positive, limits the training data to the most
+	// recent MaxTrain samples before the test block.
+	MaxTrain int
+}
+
+// Splits implements the Scheme interface.
+func (s *ForwardChaining) Splits(d *data.Data) ([]data.Set, error) {
+	K := s.K
+	if K < 1 {
+		return nil, fmt.Errorf("%d splits: %w", K, ErrInvalidScheme)
+	}
+	if s.Time != nil && len(s.Time) != len(d.Y) {
+		return nil, fmt.Errorf("%d time values for %d samples: %w",
+			len(s.Time), len(d.Y), data.ErrShape)
+	}
+
+	rows := append([]int(nil), d.GetRows()...)
+	if s.Time != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return s.Time[rows[i]] < s.Time[rows[j]]
+		})
+	} else {
+		sort.Ints(rows)
+	}
+	n := len(rows)
+	if n < K+1 {
+		return nil, fmt.Errorf("%d samples for %d forward-chaining splits: %w",
+			n, K, data.ErrTooFewSamples)
+	}
+
+	res := make([]data.Set, K)
+	for k := 0; k < K; k++ {
+		a := (k + 1) * n / (K + 1)
+		b := (k + 2) * n / (K + 1)
+		start := 0
+		if s.MaxTrain > 0 && a > s.MaxTrain {
+			start = a - s.MaxTrain
+		}
+		res[k] = &split{
+			name:  fmt.Sprintf("forward chaining %d/%d", k, K),
+			data:  d,
+			train: rows[start:a],
+			test:  rows[a:b],
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package stacking
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/model"
+)
+
+// maxBase limits the number of base classifiers accepted when
+// decoding.
+const maxBase = 1 << 16
+
+func init() {
+	model.Register(&Classifier{}, &model.Codec{
+		Tag:     "stacking",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			s := c.(*Classifier)
+			w.Int(s.NumClasses)
+			w.Int(len(s.Base))
+			for _, base := range s.Base {
+				w.Classifier(base)
+			}
+			w.Classifier(s.Meta)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			res := &Classifier{
+				NumClasses: r.Int(),
+				Info:       info,
+			}
+			n := r.Len(maxBase)
+			for i := 0; i < n && r.Err() == nil; i++ {
+				res.Base = append(res.Base, r.Classifier())
+			}
+			res.Meta = r.Classifier()
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			if res.NumClasses < 1 || n == 0 {
+				return nil, model.ErrEncoding
+			}
+			return res, nil
+		},
+	})
+}
@@ -0,0 +1,276 @@
+// Package stacking implements stacked generalisation, as described in
+// "Stacked Generalization" by Wolpert (1992).
+//
+// A number of base classifiers are trained and their estimated class
+// probabilities are used as the inputs for a meta classifier, which
+// makes the final prediction.  To avoid overfitting, the meta
+// classifier is trained on out-of-fold probabilities, obtained using
+// cross-validation.
+package stacking
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/matrix"
+)
+
+const stackingSeed = 1603207561
+
+// Factory stores the parameters for stacked generalisation.  Any zero
+// field values, other than `Base`, are interpreted as the
+// corresponding values from the [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// Base lists the factories for the base classifiers.
+	Base []classification.Factory
+
+	// Meta is used to train the meta classifier.  The inputs for the
+	// meta classifier are the class probabilities of all base
+	// classifiers, concatenated in the order given by Base.
+	Meta classification.Factory
+
+	// Folds gives the number of cross-validation folds used to
+	// compute the out-of-fold probabilities.  Folds must be at least
+	// 2, and the training data must have at least Folds samples.
+	Folds int
+
+	// Seed is used to split the data into cross-validation folds.
+	Seed int64
+}
+
+// DefaultFactory specifies the default parameters for stacked
+// generalisation.  The default meta classifier is a lightly penalised
+// logistic regression.
+var DefaultFactory = &Factory{
+	Meta:  &logit.Factory{L2: 1e-3},
+	Folds: 5,
+	Seed:  stackingSeed,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	var names []string
+	for _, base := range f.Base {
+		names = append(names, base.GetName())
+	}
+	return fmt.Sprintf("stacked (%s) -> %s",
+		strings.Join(names, "; "), f.Meta.GetName())
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.Meta == nil {
+		res.Meta = DefaultFactory.Meta
+	}
+	if res.Folds == 0 {
+		res.Folds = DefaultFactory.Folds
+	}
+	if res.Seed == 0 {
+		res.Seed = DefaultFactory.Seed
+	}
+	return &res
+}
+
+// FromData trains the base classifiers and the meta classifier.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// CheckedFromData trains the base classifiers and the meta
+// classifier.  This implements the classification.CheckedFactory
+// interface.
+func (f *Factory) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClassifierFromData trains the base classifiers and the meta
+// classifier.  The base classifiers are trained once for each
+// cross-validation fold, to obtain the training data for the meta
+// classifier, and then once more on the full training data.  The
+// method panics if the classifier cannot be constructed; use
+// CheckedFromData to get an error instead.
+func (f *Factory) ClassifierFromData(d *data.Data) *Classifier {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (f *Factory) checkedClassifierFromData(d *data.Data) (*Classifier, error) {
+	f = f.setDefaults()
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	K := d.NumClasses
+	B := len(f.Base)
+	if B == 0 {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err:     fmt.Errorf("no base classifiers: %w", classification.ErrInvalidParameter),
+		}
+	}
+	if f.Folds < 2 {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("Folds=%d: %w",
+				f.Folds, classification.ErrInvalidParameter),
+		}
+	}
+	if n := d.NRow(); n < f.Folds {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("%d samples for %d folds: %w",
+				n, f.Folds, data.ErrTooFewSamples),
+		}
+	}
+
+	// Job j < B*Folds computes out-of-fold probabilities for base
+	// j/Folds and fold j%Folds, the remaining B jobs train the final
+	// base classifiers.
+	type result struct {
+		job   int
+		rows  []int
+		prob  []data.Histogram
+		model classification.Classifier
+		err   error
+	}
+	nJobs := B*f.Folds + B
+	jobs := make(chan int, nJobs)
+	for j := 0; j < nJobs; j++ {
+		jobs <- j
+	}
+	close(jobs)
+	results := make(chan *result)
+	numWorkers := runtime.NumCPU()
+	if numWorkers > nJobs {
+		numWorkers = nJobs
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if j >= B*f.Folds {
+					base := classification.Checked(f.Base[j-B*f.Folds])
+					model, err := base.CheckedFromData(d)
+					results <- &result{job: j, model: model, err: err}
+					continue
+				}
+				base := classification.Checked(f.Base[j/f.Folds])
+				train, test, err := d.XValSplit(f.Seed, f.Folds, j%f.Folds)
+				if err != nil {
+					results <- &result{job: j, err: err}
+					continue
+				}
+				c, err := base.CheckedFromData(train)
+				if err != nil {
+					results <- &result{job: j, err: err}
+					continue
+				}
+				rows := test.GetRows()
+				prob := make([]data.Histogram, len(rows))
+				for i, row := range rows {
+					prob[i] = c.EstimateClassProbabilities(test.X.Row(row))
+				}
+				results <- &result{job: j, rows: rows, prob: prob}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// The meta data set uses the same row indices as `d`.
+	metaX := matrix.NewFloat64(len(d.Y), B*K, 0, nil)
+	res := &Classifier{
+		NumClasses: K,
+		Base:       make([]classification.Classifier, B),
+		Info:       classification.NewInfo(d),
+	}
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		if r.model != nil {
+			res.Base[r.job-B*f.Folds] = r.model
+			continue
+		}
+		col := (r.job / f.Folds) * K
+		for i, row := range r.rows {
+			copy(metaX.Row(row)[col:col+K], r.prob[i])
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	meta := *d // make a shallow copy
+	meta.X = metaX
+	meta.FeatureNames = nil
+	model, err := classification.Checked(f.Meta).CheckedFromData(&meta)
+	if err != nil {
+		return nil, err
+	}
+	res.Meta = model
+	return res, nil
+}
+
+// Classifier is a trained stacked classifier.
+type Classifier struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// Base lists the base classifiers, trained on the full training
+	// data.
+	Base []classification.Classifier
+
+	// Meta is the meta classifier, which takes the concatenated class
+	// probabilities of the base classifiers as its input.
+	Meta classification.Classifier
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	z := make([]float64, 0, len(c.Base)*c.NumClasses)
+	for _, base := range c.Base {
+		z = append(z, base.EstimateClassProbabilities(x)...)
+	}
+	return c.Meta.EstimateClassProbabilities(z)
+}
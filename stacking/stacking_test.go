@@ -0,0 +1,122 @@
+package stacking
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/logit"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/tree"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// threeClasses returns samples from three normal distributions in the
+// plane, with means (0,0), (2,0) and (0,2).
+func threeClasses(n int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(3, n, 2)
+	for i := 0; i < n; i++ {
+		y := rng.Intn(3)
+		x := d.X.Row(i)
+		x[0] = rng.NormFloat64()
+		x[1] = rng.NormFloat64()
+		switch y {
+		case 1:
+			x[0] += 2
+		case 2:
+			x[1] += 2
+		}
+		d.Y[i] = y
+	}
+	return d
+}
+
+func (*Tests) TestStacking(c *C) {
+	train := threeClasses(1500, 1)
+	test := threeClasses(3000, 2)
+	set := data.MakeSet("three classes", train, test)
+
+	lr := &logit.Factory{}
+	single := classification.Assess(lr, set, loss.Deviance)
+	c.Assert(single.Err, IsNil)
+
+	f := &Factory{
+		Base: []classification.Factory{
+			&baseline.Uniform{},
+			tree.CART,
+			&knn.Factory{K: 1},
+			lr,
+		},
+	}
+	res := classification.Assess(f, set, loss.Deviance)
+	c.Assert(res.Err, IsNil)
+	// The meta classifier should learn to ignore the uninformative
+	// and the overfitting base classifiers.
+	c.Check(res.MeanLoss < single.MeanLoss+3*single.StdErr, Equals, true,
+		Commentf("stacked %g, logit %g", res.MeanLoss, single.MeanLoss))
+
+	cf := f.ClassifierFromData(train)
+	c.Check(len(cf.Base), Equals, 4)
+	p := cf.EstimateClassProbabilities([]float64{2, 0})
+	c.Check(p.ArgMax(), Equals, 1)
+	c.Check(math.Abs(p.Sum()-1) < 1e-12, Equals, true)
+}
+
+func (*Tests) TestName(c *C) {
+	f := &Factory{Base: []classification.Factory{&baseline.Prior{}}}
+	c.Check(f.GetName(), Equals, "stacked (class prior) -> logistic regression, L2=0.001")
+}
+
+func (*Tests) TestFewSamples(c *C) {
+	f := &Factory{
+		Base:  []classification.Factory{&baseline.Prior{}},
+		Meta:  &baseline.Prior{},
+		Folds: 3,
+	}
+	d := threeClasses(3, 4)
+	prob := f.FromData(d).EstimateClassProbabilities(d.X.Row(0))
+	c.Check(prob, HasLen, 3)
+
+	f.Folds = 5
+	_, err := f.CheckedFromData(d)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+}
+
+func (*Tests) TestNoBase(c *C) {
+	f := &Factory{}
+	_, err := f.CheckedFromData(threeClasses(20, 6))
+	c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true)
+}
+
+// failing is a factory which panics, to check that errors from the
+// base classifiers, which are trained in separate goroutines, are
+// reported to the caller.
+type failing struct{}
+
+func (failing) GetName() string { return "failing" }
+
+func (failing) FromData(*data.Data) classification.Classifier {
+	panic("failing.FromData")
+}
+
+func (*Tests) TestBaseError(c *C) {
+	f := &Factory{
+		Base: []classification.Factory{&baseline.Prior{}, failing{}},
+	}
+	_, err := f.CheckedFromData(threeClasses(20, 7))
+	var pErr *classification.PanicError
+	c.Check(errors.As(err, &pErr), Equals, true)
+}
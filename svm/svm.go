@@ -0,0 +1,383 @@
+// Package svm implements linear support vector machines.
+//
+// The binary problems are solved using the dual coordinate descent
+// method from "A Dual Coordinate Descent Method for Large-scale
+// Linear SVM" by Hsieh, Chang, Lin, Keerthi and Sundararajan (ICML
+// 2008).  Multiclass problems are reduced to binary problems using
+// the one-vs-rest approach.  Decision values are converted into class
+// probabilities using Platt scaling, fitted on out-of-fold decision
+// values.
+package svm
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/calibrate"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+)
+
+const svmSeed = 1288516032
+
+// Factory stores the parameters for training linear SVMs.  Any zero
+// field values are interpreted as the corresponding values from the
+// [DefaultFactory] structure.
+type Factory struct {
+	// Name gives a short, human-readable description of the algorithm
+	// described by the Factory.
+	Name string
+
+	// C is the penalty for margin violations.  Larger values lead to
+	// less regularisation.  Sample weights are multiplied with C.
+	C float64
+
+	// MaxIter gives the maximal number of passes over the training
+	// data for the coordinate descent method.
+	MaxIter int
+
+	// Tolerance is the stopping threshold for the projected gradient
+	// of the dual problem.
+	Tolerance float64
+
+	// PlattFolds gives the number of cross-validation folds used to
+	// generate the decision values for fitting the Platt scaling.  The
+	// training data must have at least PlattFolds samples.  If
+	// PlattFolds is 1, the decision values of the final classifier on
+	// the training data are used instead.
+	PlattFolds int
+
+	// Seed is used to initialise the random number generator, which
+	// determines the order of coordinate updates.
+	Seed int64
+}
+
+// DefaultFactory specifies the default parameters for training linear
+// SVMs.
+var DefaultFactory = &Factory{
+	C:          1,
+	MaxIter:    1000,
+	Tolerance:  0.1,
+	PlattFolds: 3,
+	Seed:       svmSeed,
+}
+
+// GetName returns a human-readable name for the factory.
+func (f *Factory) GetName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	f = f.setDefaults()
+	return fmt.Sprintf("linear SVM, C=%g", f.C)
+}
+
+func (f *Factory) setDefaults() *Factory {
+	res := *f // make a copy
+	if res.C == 0 {
+		res.C = DefaultFactory.C
+	}
+	if res.MaxIter == 0 {
+		res.MaxIter = DefaultFactory.MaxIter
+	}
+	if res.Tolerance == 0 {
+		res.Tolerance = DefaultFactory.Tolerance
+	}
+	if res.PlattFolds == 0 {
+		res.PlattFolds = DefaultFactory.PlattFolds
+	}
+	if res.Seed == 0 {
+		res.Seed = DefaultFactory.Seed
+	}
+	return &res
+}
+
+// FromData trains a linear SVM classifier.
+func (f *Factory) FromData(d *data.Data) classification.Classifier {
+	return f.ClassifierFromData(d)
+}
+
+// CheckedFromData trains a linear SVM classifier.  This implements the
+// classification.CheckedFactory interface.
+func (f *Factory) CheckedFromData(d *data.Data) (classification.Classifier, error) {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClassifierFromData trains a linear SVM classifier.  The method
+// panics if the classifier cannot be constructed; use CheckedFromData
+// to get an error instead.
+func (f *Factory) ClassifierFromData(d *data.Data) *Classifier {
+	res, err := f.checkedClassifierFromData(d)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (f *Factory) checkedClassifierFromData(d *data.Data) (*Classifier, error) {
+	f = f.setDefaults()
+	if err := d.Check(); err != nil {
+		return nil, &classification.FactoryError{Factory: f.GetName(), Err: err}
+	}
+	folds := f.PlattFolds
+	if n := d.NRow(); folds >= 2 && n < folds {
+		return nil, &classification.FactoryError{
+			Factory: f.GetName(),
+			Err: fmt.Errorf("%d samples for %d Platt folds: %w",
+				n, folds, data.ErrTooFewSamples),
+		}
+	}
+
+	res := f.trainAll(d)
+
+	// Collect out-of-fold decision values to fit the Platt scaling.
+	nProblems := len(res.Bias)
+	decision := make([][]float64, nProblems)
+	for m := range decision {
+		decision[m] = make([]float64, len(d.Y))
+	}
+	if folds >= 2 {
+		for k := 0; k < folds; k++ {
+			train, test, err := d.XValSplit(f.Seed, folds, k)
+			if err != nil {
+				return nil, err
+			}
+			c := f.trainAll(train)
+			for _, row := range test.GetRows() {
+				x := test.X.Row(row)
+				for m := range decision {
+					decision[m][row] = c.Decision(m, x)
+				}
+			}
+		}
+	} else {
+		for _, row := range d.GetRows() {
+			x := d.X.Row(row)
+			for m := range decision {
+				decision[m][row] = res.Decision(m, x)
+			}
+		}
+	}
+
+	rows := d.GetRows()
+	res.PlattA = make([]float64, nProblems)
+	res.PlattB = make([]float64, nProblems)
+	dec := make([]float64, len(rows))
+	label := make([]bool, len(rows))
+	w := make([]float64, len(rows))
+	for m := range decision {
+		for i, row := range rows {
+			dec[i] = decision[m][row]
+			label[i] = positive(d, m, row)
+			w[i] = d.Weight(row)
+		}
+		res.PlattA[m], res.PlattB[m] = calibrate.FitPlatt(dec, label, w)
+	}
+	return res, nil
+}
+
+// positive returns true if `row` belongs to the positive class of the
+// binary problem `m`.
+func positive(d *data.Data, m int, row int) bool {
+	if d.NumClasses == 2 {
+		return d.Y[row] == 1
+	}
+	return d.Y[row] == m
+}
+
+// trainAll solves all binary problems for the data set `d`.  The
+// returned classifier has no Platt scaling parameters set.
+func (f *Factory) trainAll(d *data.Data) *Classifier {
+	p := d.NCol()
+	nProblems := d.NumClasses
+	if nProblems == 2 {
+		nProblems = 1
+	}
+	res := &Classifier{
+		NumClasses: d.NumClasses,
+		W:          matrix.NewFloat64(nProblems, p, 0, nil),
+		Bias:       make([]float64, nProblems),
+		Info:       classification.NewInfo(d),
+	}
+
+	var wg sync.WaitGroup
+	for m := 0; m < nProblems; m++ {
+		wg.Add(1)
+		go func(m int) {
+			defer wg.Done()
+			res.Bias[m] = f.trainBinary(d, m, res.W.Row(m))
+		}(m)
+	}
+	wg.Wait()
+	return res
+}
+
+// trainBinary solves the binary problem `m` using dual coordinate
+// descent.  The weight vector is stored in `w`, and the bias is
+// returned.  The bias is treated as the coefficient of an additional,
+// constant input variable.
+func (f *Factory) trainBinary(d *data.Data, m int, w []float64) float64 {
+	rng := rand.New(rand.NewSource(f.Seed + int64(m)))
+	rows := d.GetRows()
+	n := len(rows)
+
+	y := make([]float64, n)
+	upper := make([]float64, n)
+	qii := make([]float64, n)
+	for i, row := range rows {
+		if positive(d, m, row) {
+			y[i] = 1
+		} else {
+			y[i] = -1
+		}
+		upper[i] = f.C * d.Weight(row)
+		qii[i] = matrix.Dot(d.X.Row(row), d.X.Row(row)) + 1
+	}
+
+	alpha := make([]float64, n)
+	bias := 0.0
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	// Coordinates which are likely to stay at a bound are temporarily
+	// removed from the active set ("shrinking"); the first `active`
+	// entries of `order` form the active set.
+	active := n
+	maxPGOld := math.Inf(+1)
+	minPGOld := math.Inf(-1)
+	for iter := 0; iter < f.MaxIter; iter++ {
+		rng.Shuffle(active, func(i, j int) { order[i], order[j] = order[j], order[i] })
+		maxPG := math.Inf(-1)
+		minPG := math.Inf(+1)
+		for s := 0; s < active; s++ {
+			i := order[s]
+			x := d.X.Row(rows[i])
+			G := y[i]*(matrix.Dot(w, x)+bias) - 1
+
+			PG := 0.0
+			switch {
+			case alpha[i] == 0:
+				if G > maxPGOld {
+					active--
+					order[s], order[active] = order[active], order[s]
+					s--
+					continue
+				} else if G < 0 {
+					PG = G
+				}
+			case alpha[i] == upper[i]:
+				if G < minPGOld {
+					active--
+					order[s], order[active] = order[active], order[s]
+					s--
+					continue
+				} else if G > 0 {
+					PG = G
+				}
+			default:
+				PG = G
+			}
+			maxPG = math.Max(maxPG, PG)
+			minPG = math.Min(minPG, PG)
+			if PG == 0 {
+				continue
+			}
+
+			old := alpha[i]
+			alpha[i] = math.Min(math.Max(old-G/qii[i], 0), upper[i])
+			delta := (alpha[i] - old) * y[i]
+			for j, xj := range x {
+				w[j] += delta * xj
+			}
+			bias += delta
+		}
+
+		if maxPG-minPG < f.Tolerance {
+			if active == n {
+				break
+			}
+			// Check convergence on the full problem.
+			active = n
+			maxPGOld = math.Inf(+1)
+			minPGOld = math.Inf(-1)
+			continue
+		}
+		maxPGOld = maxPG
+		if maxPGOld <= 0 {
+			maxPGOld = math.Inf(+1)
+		}
+		minPGOld = minPG
+		if minPGOld >= 0 {
+			minPGOld = math.Inf(-1)
+		}
+	}
+	return bias
+}
+
+// Classifier is a trained linear SVM classifier.
+type Classifier struct {
+	// NumClasses gives the number of classes of the response variable.
+	NumClasses int
+
+	// W stores the weight vectors of the binary problems, one per
+	// row.  For two classes there is a single problem, class 1
+	// versus class 0; otherwise there is one problem per class,
+	// class k versus the rest.
+	W *matrix.Float64
+
+	// Bias stores the bias of each binary problem.
+	Bias []float64
+
+	// PlattA and PlattB store the parameters of the Platt scaling for
+	// each binary problem.  The probability of the positive class is
+	// 1 / (1 + exp(A*f + B)), where f is the decision value.
+	PlattA, PlattB []float64
+
+	// Info describes the training data of the classifier.
+	Info *classification.Info
+}
+
+// GetInfo returns the metadata of the classifier.  This implements the
+// classification.Metadata interface.
+func (c *Classifier) GetInfo() *classification.Info {
+	return c.Info
+}
+
+// Decision returns the decision value w.x + b of binary problem `m`
+// for input `x`.
+func (c *Classifier) Decision(m int, x []float64) float64 {
+	if err := c.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+	return matrix.Dot(c.W.Row(m), x) + c.Bias[m]
+}
+
+// EstimateClassProbabilities returns the estimated class
+// probabilities for input `x`.  For more than two classes, the
+// one-vs-rest probabilities are normalised to sum to one.
+func (c *Classifier) EstimateClassProbabilities(x []float64) data.Histogram {
+	prob := make(data.Histogram, c.NumClasses)
+	if c.NumClasses == 2 {
+		p1 := calibrate.Sigmoid(-(c.PlattA[0]*c.Decision(0, x) + c.PlattB[0]))
+		prob[0] = 1 - p1
+		prob[1] = p1
+		return prob
+	}
+
+	for m := range prob {
+		prob[m] = calibrate.Sigmoid(-(c.PlattA[m]*c.Decision(m, x) + c.PlattB[m]))
+	}
+	if prob.Sum() <= 0 {
+		for m := range prob {
+			prob[m] = 1
+		}
+	}
+	return prob.Probabilities()
+}
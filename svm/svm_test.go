@@ -0,0 +1,106 @@
+package svm
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+// threeClasses returns samples from three normal distributions in the
+// plane, with means (0,0), (3,0) and (0,3).
+func threeClasses(n int, seed int64) *data.Data {
+	rng := rand.New(rand.NewSource(seed))
+	d := data.NewEmpty(3, n, 2)
+	for i := 0; i < n; i++ {
+		y := rng.Intn(3)
+		x := d.X.Row(i)
+		x[0] = rng.NormFloat64()
+		x[1] = rng.NormFloat64()
+		switch y {
+		case 1:
+			x[0] += 3
+		case 2:
+			x[1] += 3
+		}
+		d.Y[i] = y
+	}
+	return d
+}
+
+func (*Tests) TestBinary(c *C) {
+	set := data.NewNormals(2.0, 2000, 2000)
+	res := classification.Assess(&Factory{}, set, loss.ZeroOne)
+	c.Assert(res.Err, IsNil)
+	// The Bayes error rate is Phi(-1) = 0.159.
+	c.Check(res.MeanLoss < 0.18, Equals, true,
+		Commentf("loss %g", res.MeanLoss))
+
+	train, _ := set.TrainingData()
+	cf := (&Factory{}).ClassifierFromData(train)
+	m, _ := cf.W.Shape()
+	c.Check(m, Equals, 1)
+
+	// The probability of class 1 must increase with the decision value.
+	c.Check(cf.PlattA[0] < 0, Equals, true)
+	lo := cf.EstimateClassProbabilities([]float64{-2})
+	mid := cf.EstimateClassProbabilities([]float64{0})
+	hi := cf.EstimateClassProbabilities([]float64{2})
+	c.Check(lo[1] < mid[1] && mid[1] < hi[1], Equals, true)
+	c.Check(math.Abs(mid[1]-0.5) < 0.1, Equals, true,
+		Commentf("p = %g", mid[1]))
+}
+
+func (*Tests) TestMulticlass(c *C) {
+	train := threeClasses(1500, 1)
+	test := threeClasses(1500, 2)
+	set := data.MakeSet("three classes", train, test)
+	res := classification.Assess(&Factory{}, set, loss.ZeroOne)
+	c.Assert(res.Err, IsNil)
+	c.Check(res.MeanLoss < 0.12, Equals, true,
+		Commentf("loss %g", res.MeanLoss))
+
+	cf := (&Factory{}).ClassifierFromData(train)
+	p := cf.EstimateClassProbabilities([]float64{3, 0})
+	c.Check(p.ArgMax(), Equals, 1)
+	c.Check(math.Abs(p.Sum()-1) < 1e-12, Equals, true)
+}
+
+func (*Tests) TestWeights(c *C) {
+	// Giving zero weight to class 1 should make class 0 dominant.
+	train := threeClasses(600, 3)
+	train.Weights = make([]float64, len(train.Y))
+	for i, y := range train.Y {
+		if y != 1 {
+			train.Weights[i] = 1
+		}
+	}
+	cf := (&Factory{}).ClassifierFromData(train)
+	p := cf.EstimateClassProbabilities([]float64{3, 0})
+	c.Check(p[1] < 0.2, Equals, true, Commentf("p = %v", p))
+}
+
+func (*Tests) TestFewSamples(c *C) {
+	d := threeClasses(2, 4)
+	_, err := (&Factory{}).CheckedFromData(d)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true)
+	var fe *classification.FactoryError
+	c.Check(errors.As(err, &fe), Equals, true)
+
+	// With a single fold, the Platt scaling uses in-sample values.
+	cl, err := (&Factory{PlattFolds: 1}).CheckedFromData(d)
+	c.Assert(err, IsNil)
+	c.Check(cl.EstimateClassProbabilities(d.X.Row(0)), HasLen, 3)
+}
@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/model"
+)
+
+func init() {
+	model.Register(&Tree{}, &model.Codec{
+		Tag:     "tree",
+		Version: 1,
+		Encode: func(w *model.Writer, c classification.Classifier) error {
+			body, err := c.(*Tree).MarshalBinary()
+			if err != nil {
+				return err
+			}
+			w.Bytes(body)
+			return nil
+		},
+		Decode: func(r *model.Reader, version int, info *classification.Info) (classification.Classifier, error) {
+			body := r.Bytes()
+			if err := r.Err(); err != nil {
+				return nil, err
+			}
+			t := &Tree{}
+			err := t.UnmarshalBinary(body)
+			if err != nil {
+				return nil, err
+			}
+			// The encoded body includes the metadata, so `info` is
+			// not used here.
+			return t, nil
+		},
+	})
+}
@@ -17,6 +17,9 @@
 package tree
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math"
 	"sort"
 
@@ -25,6 +28,7 @@ import (
 	"seehuhn.de/go/classification/impurity"
 	"seehuhn.de/go/classification/loss"
 	"seehuhn.de/go/classification/matrix"
+	"seehuhn.de/go/classification/resampling"
 	"seehuhn.de/go/classification/tree/stop"
 )
 
@@ -63,8 +67,19 @@ type Factory struct {
 	XValLoss loss.Function
 
 	// The number of groups to use in cross-validation when estimating
-	// the expected loss.  The default is to use 5 groups.
+	// the expected loss.  The default is to use 5 groups.  K is
+	// ignored if Resampling is set.
 	K int
+
+	// Resampling, if non-nil, specifies the training/test splits used
+	// to estimate the expected loss of the candidate trees.  The
+	// default is K-fold cross-validation.
+	Resampling resampling.Scheme
+
+	// MaxDepth, if positive, limits the depth of the initial tree.
+	// A value of 1 leads to "decision stumps" with a single split.
+	// The default is to not limit the depth of the tree.
+	MaxDepth int
 }
 
 // CART specifies the parameters for constructing a tree as suggested
@@ -86,50 +101,104 @@ var CART = &Factory{
 // `Factory` structure when the `Factory.FromData` method is called.
 var DefaultFactory = CART
 
+// GetName returns a human-readable name for the factory.  If the Name
+// field is empty, the generic name "tree" is used.
 func (b *Factory) GetName() string {
-	return b.Name
+	if b.Name != "" {
+		return b.Name
+	}
+	return "tree"
 }
 
 // FromData constructs a new classification tree from training data.
+// The method panics if the tree cannot be constructed; use
+// CheckedFromData to get an error instead.
 func (b *Factory) FromData(data *data.Data) classification.Classifier {
-	tree, _ := b.TreeFromData(data)
+	tree, _, err := b.TreeFromData(data)
+	if err != nil {
+		panic(err)
+	}
 	return tree
 }
 
+// CheckedFromData constructs a new classification tree from training
+// data.  This implements the classification.CheckedFactory interface.
+func (b *Factory) CheckedFromData(data *data.Data) (classification.Classifier, error) {
+	return b.FromDataContext(context.Background(), data)
+}
+
+// FromDataContext constructs a new classification tree from training
+// data.  This implements the classification.ContextFactory interface.
+func (b *Factory) FromDataContext(ctx context.Context, data *data.Data) (classification.Classifier, error) {
+	tree, _, err := b.TreeFromDataContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
 // TreeFromData constructs a new classification tree from training
 // data.  The returned values are the new tree and an estimate of the
-// expected loss.
-func (b *Factory) TreeFromData(data *data.Data) (*Tree, float64) {
-	b = b.setDefaults()
+// expected loss.  Any error is of type *classification.FactoryError.
+func (b *Factory) TreeFromData(data *data.Data) (*Tree, float64, error) {
+	return b.TreeFromDataContext(context.Background(), data)
+}
 
-	p := data.NCol()
-	if p > maxColumns {
-		panic("too large p")
+// TreeFromDataContext is like TreeFromData, but growing the tree and
+// the cross-validation can be cancelled using `ctx`.  In this case,
+// the error from ctx.Err() is returned.  NodeGrown and FoldDone events
+// are reported to the classification.Progress value attached to
+// `ctx`, if any.
+func (b *Factory) TreeFromDataContext(ctx context.Context, data *data.Data) (*Tree, float64, error) {
+	b = b.setDefaults()
+	if err := b.check(data); err != nil {
+		return nil, 0, &classification.FactoryError{Factory: b.GetName(), Err: err}
 	}
 
 	// step 1: generate the full tree
-	tree := b.fullTree(data)
+	tree, err := b.fullTreeContext(ctx, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	info := classification.NewInfo(data)
 	if tree.IsLeaf() {
-		return tree, 0.0
+		tree.Info = info
+		return tree, 0.0, nil
 	}
 
 	// step 2: generate candidates for a pruned tree
 	candidates, alpha := b.getCandidates(tree)
 	loss := make([]float64, len(candidates))
-	for k := 0; k < b.K; k++ {
-		xValSet := data.GetXValSet(xValSeed, b.K, k)
-
+	scheme := b.Resampling
+	if scheme == nil {
+		scheme = &resampling.KFold{K: b.K, Seed: xValSeed}
+	}
+	splits, err := scheme.Splits(data)
+	if err != nil {
+		return nil, 0, &classification.FactoryError{Factory: b.GetName(), Err: err}
+	}
+	numTests := 0
+	for k, xValSet := range splits {
 		// Build the initial tree using the training data.
-		// TODO(voss): error handling?
-		trainingData, _ := xValSet.TrainingData()
-		tree := b.fullTree(trainingData)
+		trainingData, err := xValSet.TrainingData()
+		if err != nil {
+			return nil, 0, &classification.FactoryError{Factory: b.GetName(), Err: err}
+		}
+		tree, err := b.fullTreeContext(ctx, trainingData)
+		if err != nil {
+			return nil, 0, err
+		}
 
 		// Get all candidates for pruning the tree.
 		XVcandidates, XValpha := b.getCandidates(tree)
 
 		// Assess the expected loss of each candidate, using the test data.
-		testData, _ := xValSet.TestData()
+		testData, err := xValSet.TestData()
+		if err != nil {
+			return nil, 0, &classification.FactoryError{Factory: b.GetName(), Err: err}
+		}
 		testRows := testData.GetRows()
+		numTests += len(testRows)
 		XVloss := make([]float64, len(XVcandidates))
 		XVlossDone := make([]bool, len(XVcandidates))
 		for j := range candidates {
@@ -148,6 +217,13 @@ func (b *Factory) TreeFromData(data *data.Data) (*Tree, float64) {
 			}
 			loss[j] += XVloss[i]
 		}
+
+		classification.ReportProgress(ctx, classification.ProgressEvent{
+			Kind:   classification.FoldDone,
+			Source: b.GetName(),
+			Done:   k + 1,
+			Total:  len(splits),
+		})
 	}
 
 	// step 3: return the optimal tree
@@ -159,7 +235,37 @@ func (b *Factory) TreeFromData(data *data.Data) (*Tree, float64) {
 			bestLoss = loss[j]
 		}
 	}
-	return candidates[bestIdx], bestLoss / float64(data.NRow())
+	best := candidates[bestIdx]
+	best.Info = info
+	return best, bestLoss / float64(numTests), nil
+}
+
+// ErrTooManyColumns is wrapped by the error returned by TreeFromData
+// if the data has more than maxColumns input columns.
+var ErrTooManyColumns = errors.New("too many input columns")
+
+// check verifies the data and the parameters.  The number of samples
+// is checked later, by the resampling scheme, since no
+// cross-validation is required if the full tree is a single leaf.
+func (b *Factory) check(d *data.Data) error {
+	if err := d.Check(); err != nil {
+		return err
+	}
+	if p := d.NCol(); p > maxColumns {
+		return fmt.Errorf("%d columns (max. %d): %w", p, maxColumns, ErrTooManyColumns)
+	}
+	if b.Resampling == nil && b.K < 2 {
+		return fmt.Errorf("K=%d: %w", b.K, classification.ErrInvalidParameter)
+	}
+	return nil
+}
+
+// GrowTree constructs a classification tree from training data,
+// without pruning the result.  Growth of the tree is controlled by
+// the `StopGrowth`, `SplitScore` and `MaxDepth` fields of `b`.
+func (b *Factory) GrowTree(data *data.Data) *Tree {
+	b = b.setDefaults()
+	return b.fullTree(data)
 }
 
 func (b *Factory) setDefaults() *Factory {
@@ -183,31 +289,65 @@ func (b *Factory) setDefaults() *Factory {
 }
 
 func (b *Factory) fullTree(data *data.Data) *Tree {
-	hist := data.GetHist()
-	return b.getFullTree(data, hist)
+	// With the background context, growing the tree cannot fail.
+	tree, _ := b.fullTreeContext(context.Background(), data)
+	return tree
+}
+
+func (b *Factory) fullTreeContext(ctx context.Context, data *data.Data) (*Tree, error) {
+	g := &grower{
+		Factory: b,
+		ctx:     ctx,
+	}
+	return g.getFullTree(data, data.GetHist(), 0)
 }
 
-func (b *Factory) getFullTree(data *data.Data, hist data.Histogram) *Tree {
-	if b.StopGrowth(hist) {
+// grower holds the state while growing a single tree.
+type grower struct {
+	*Factory
+	ctx   context.Context
+	nodes int
+}
+
+func (g *grower) getFullTree(data *data.Data, hist data.Histogram, depth int) (*Tree, error) {
+	if err := g.ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.nodes++
+	classification.ReportProgress(g.ctx, classification.ProgressEvent{
+		Kind:   classification.NodeGrown,
+		Source: g.GetName(),
+		Done:   g.nodes,
+	})
+
+	if g.StopGrowth(hist) || (g.MaxDepth > 0 && depth >= g.MaxDepth) {
 		return &Tree{
 			Hist: hist,
-		}
+		}, nil
 	}
 
-	best := b.findBestSplit(data, hist)
+	best := g.findBestSplit(data, hist)
 	if best == nil {
 		return &Tree{
 			Hist: hist,
-		}
+		}, nil
 	}
 
+	left, err := g.getFullTree(best.Left, best.LeftHist, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := g.getFullTree(best.Right, best.RightHist, depth+1)
+	if err != nil {
+		return nil, err
+	}
 	return &Tree{
 		Hist:       hist,
-		LeftChild:  b.getFullTree(best.Left, best.LeftHist),
-		RightChild: b.getFullTree(best.Right, best.RightHist),
+		LeftChild:  left,
+		RightChild: right,
 		Column:     best.Col,
 		Limit:      best.Limit,
-	}
+	}, nil
 }
 
 // findBestSplit finds the best split point for the given data.
@@ -236,9 +376,11 @@ func (b *Factory) findBestSplit(d *data.Data, hist data.Histogram) *searchResult
 		leftHist := make(data.Histogram, len(hist))
 		var rightHist = copyFloatSlice(hist)
 		for i := 1; i < len(rows); i++ {
-			yi := d.Y[rows[i-1]]
-			leftHist[yi]++ // TODO(voss): use the weights here!
-			rightHist[yi]--
+			row := rows[i-1]
+			yi := d.Y[row]
+			wi := d.Weight(row)
+			leftHist[yi] += wi
+			rightHist[yi] -= wi
 
 			left := d.X.At(rows[i-1], col)
 			right := d.X.At(rows[i], col)
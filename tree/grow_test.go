@@ -1,10 +1,16 @@
 package tree
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
 	"seehuhn.de/go/classification/data"
 	"seehuhn.de/go/classification/impurity"
 	"seehuhn.de/go/classification/matrix"
+	"seehuhn.de/go/classification/tree/stop"
 )
 
 func (*Tests) TestFindBestSplit1(c *C) {
@@ -109,3 +115,118 @@ func (*Tests) TestFindBestSplit3(c *C) {
 			"got", best.Left.NRow(), best.Right.NRow())
 	}
 }
+
+func (*Tests) TestFindBestSplitWeights(c *C) {
+	theData := &data.Data{
+		NumClasses: 2,
+		X:          matrix.NewFloat64(4, 1, 0, []float64{0, 1, 2, 3}),
+		Y:          []int{0, 1, 0, 1},
+	}
+	b := &Factory{
+		SplitScore: impurity.Gini,
+	}
+
+	best := b.findBestSplit(theData, theData.GetHist())
+	c.Check(best.Limit, Equals, 0.5)
+
+	theData.Weights = []float64{1, 1, 1, 100}
+	best = b.findBestSplit(theData, theData.GetHist())
+	c.Check(best.Limit, Equals, 2.5)
+	c.Check(best.LeftHist, DeepEquals, data.Histogram{2, 1})
+	c.Check(best.RightHist, DeepEquals, data.Histogram{0, 100})
+}
+
+func (*Tests) TestGrowTreeMaxDepth(c *C) {
+	n := 20
+	raw := make([]float64, n)
+	response := make([]int, n)
+	for i := range raw {
+		raw[i] = float64(i)
+		response[i] = (i / 3) % 2
+	}
+	theData := &data.Data{
+		NumClasses: 2,
+		X:          matrix.NewFloat64(n, 1, 0, raw),
+		Y:          response,
+	}
+	for _, maxDepth := range []int{1, 2, 3} {
+		b := &Factory{
+			MaxDepth: maxDepth,
+		}
+		tree := b.GrowTree(theData)
+		depth := 0
+		tree.ForeachLeaf(func(_ data.Histogram, d int) {
+			if d > depth {
+				depth = d
+			}
+		})
+		c.Check(depth, Equals, maxDepth)
+	}
+}
+
+func (*Tests) TestTreeFromDataErrors(c *C) {
+	d := data.NewEmpty(2, 8, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		d.Y[i] = i % 2
+	}
+	b := &Factory{StopGrowth: stop.IfPure, K: 10}
+
+	// Cross-validation needs at least K samples.
+	_, _, err := b.TreeFromData(d)
+	c.Check(errors.Is(err, data.ErrTooFewSamples), Equals, true, Commentf("%v", err))
+	var fe *classification.FactoryError
+	c.Check(errors.As(err, &fe), Equals, true)
+
+	b.K = 1
+	_, err = b.CheckedFromData(d)
+	c.Check(errors.Is(err, classification.ErrInvalidParameter), Equals, true)
+	c.Check(err, ErrorMatches, "tree: K=1: invalid parameter")
+
+	// Pure data sets need no cross-validation.
+	for i := range d.Y {
+		d.Y[i] = 1
+	}
+	b.K = 10
+	tree, _, err := b.TreeFromData(d)
+	c.Assert(err, IsNil)
+	c.Check(tree.IsLeaf(), Equals, true)
+
+	d.Y[3] = 5
+	_, _, err = b.TreeFromData(d)
+	c.Check(errors.Is(err, data.ErrClass), Equals, true)
+	c.Check(func() { b.FromData(d) }, PanicMatches, ".*class out of range")
+}
+
+func (*Tests) TestTreeFromDataContext(c *C) {
+	d := data.NewEmpty(2, 40, 1)
+	for i := range d.Y {
+		d.X.Set(i, 0, float64(i))
+		d.Y[i] = (i / 3) % 2
+	}
+	b := &Factory{StopGrowth: stop.IfPure, K: 5}
+
+	var mu sync.Mutex
+	count := map[classification.ProgressKind]int{}
+	var lastFold classification.ProgressEvent
+	ctx := classification.WithProgress(context.Background(),
+		classification.ProgressFunc(func(e classification.ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			count[e.Kind]++
+			if e.Kind == classification.FoldDone {
+				lastFold = e
+			}
+		}))
+	_, _, err := b.TreeFromDataContext(ctx, d)
+	c.Assert(err, IsNil)
+	c.Check(count[classification.NodeGrown] > 0, Equals, true)
+	c.Check(count[classification.FoldDone], Equals, 5)
+	c.Check(lastFold.Done, Equals, 5)
+	c.Check(lastFold.Total, Equals, 5)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = b.TreeFromDataContext(ctx, d)
+	c.Check(errors.Is(err, context.Canceled), Equals, true)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 
+	"seehuhn.de/go/classification"
 	"seehuhn.de/go/classification/data"
 )
 
@@ -19,7 +20,11 @@ var ErrTreeEncoding = errors.New("cannot decode binary tree representation")
 var ErrTreeVersion = errors.New("unknown tree file format version")
 
 const binaryFormatTag = "JVCT"
-const binaryFormatVersion = 1
+const binaryFormatVersion = 2
+
+// maxNameLength is the maximal length of feature and class names in
+// the binary format.
+const maxNameLength = 1 << 16
 
 // MarshalBinary encodes the tree `t` into a binary form and returns
 // the result.  This method implements the `encoding.BinaryMarshaler`
@@ -58,6 +63,12 @@ func (t *Tree) WriteBinary(w io.Writer) error {
 		return err
 	}
 
+	// 3a: metadata (version 2 and newer)
+	err = appendInfo(buf, t.Info)
+	if err != nil {
+		return err
+	}
+
 	err = appendBinaryTree(buf, p, t)
 	if err != nil {
 		return err
@@ -67,6 +78,48 @@ func (t *Tree) WriteBinary(w io.Writer) error {
 	return err
 }
 
+func appendInfo(buf *bufio.Writer, info *classification.Info) error {
+	if info == nil {
+		info = &classification.Info{}
+	}
+
+	// 3a.1: number of inputs, or 0 if unknown
+	err := appendUvarint(buf, uint64(info.NumInputs))
+	if err != nil {
+		return err
+	}
+
+	// 3a.2: feature names
+	err = appendNames(buf, info.FeatureNames)
+	if err != nil {
+		return err
+	}
+
+	// 3a.3: class names
+	return appendNames(buf, info.ClassNames)
+}
+
+func appendNames(buf *bufio.Writer, names []string) error {
+	err := appendUvarint(buf, uint64(len(names)))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if len(name) > maxNameLength {
+			return errors.New("name too long")
+		}
+		err = appendUvarint(buf, uint64(len(name)))
+		if err != nil {
+			return err
+		}
+		_, err = buf.WriteString(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func appendBinaryTree(buf *bufio.Writer, p int, t *Tree) error {
 	if t.IsLeaf() {
 		// 4: node type (0=leaf, 1=internal)
@@ -164,7 +217,7 @@ func FromFile(r io.Reader) (*Tree, error) {
 	if err != nil {
 		return nil, err
 	}
-	if version != binaryFormatVersion {
+	if version < 1 || version > binaryFormatVersion {
 		return nil, ErrTreeVersion
 	}
 
@@ -178,7 +231,86 @@ func FromFile(r io.Reader) (*Tree, error) {
 	}
 	p := int(pTmp)
 
-	return readBinaryTree(buf, p)
+	// 3a: metadata (version 2 and newer)
+	var info *classification.Info
+	if version >= 2 {
+		info, err = readInfo(buf, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := readBinaryTree(buf, p)
+	if err != nil {
+		return nil, err
+	}
+	t.Info = info
+	return t, nil
+}
+
+func readInfo(buf *bufio.Reader, p int) (*classification.Info, error) {
+	// 3a.1: number of inputs, or 0 if unknown
+	inputs, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, err
+	}
+	if inputs > maxColumns {
+		return nil, ErrTreeEncoding
+	}
+
+	// 3a.2: feature names
+	features, err := readNames(buf, int(inputs))
+	if err != nil {
+		return nil, err
+	}
+
+	// 3a.3: class names
+	labels, err := readNames(buf, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if inputs == 0 && features == nil && labels == nil {
+		return nil, nil
+	}
+	return &classification.Info{
+		NumClasses:   p,
+		NumInputs:    int(inputs),
+		FeatureNames: features,
+		ClassNames:   labels,
+	}, nil
+}
+
+// readNames reads a list of names, which must be either empty or of
+// length `n`.  If the list is empty, nil is returned.
+func readNames(buf *bufio.Reader, n int) ([]string, error) {
+	count, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	if count != uint64(n) {
+		return nil, ErrTreeEncoding
+	}
+	names := make([]string, n)
+	for i := range names {
+		l, err := binary.ReadUvarint(buf)
+		if err != nil {
+			return nil, err
+		}
+		if l > maxNameLength {
+			return nil, ErrTreeEncoding
+		}
+		name := make([]byte, l)
+		_, err = io.ReadFull(buf, name)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = string(name)
+	}
+	return names, nil
 }
 
 func readBinaryTree(buf *bufio.Reader, p int) (*Tree, error) {
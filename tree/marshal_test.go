@@ -5,6 +5,7 @@ import (
 	"encoding"
 
 	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
 )
 
 func (*Tests) TestBinaryFormat(c *C) {
@@ -60,6 +61,42 @@ func (*Tests) TestMarshalling(c *C) {
 	c.Assert(tree2, DeepEquals, tree1)
 }
 
+func (*Tests) TestMarshalInfo(c *C) {
+	tree1 := &Tree{
+		LeftChild: &Tree{
+			Hist: []float64{3, 0},
+		},
+		RightChild: &Tree{
+			Hist: []float64{1, 2},
+		},
+		Column: 1,
+		Limit:  0.5,
+		Hist:   []float64{4, 2},
+		Info: &classification.Info{
+			NumClasses:   2,
+			NumInputs:    2,
+			FeatureNames: []string{"height", "weight"},
+			ClassNames:   []string{"cat", "dog"},
+		},
+	}
+
+	data, err := tree1.MarshalBinary()
+	c.Assert(err, Equals, nil)
+	tree2 := &Tree{}
+	err = tree2.UnmarshalBinary(data)
+	c.Assert(err, Equals, nil)
+	c.Check(tree2, DeepEquals, tree1)
+
+	// trees in the version 1 format have no metadata
+	v1 := []byte("JVCT\x01\x02\x00\x00\x00\x00\x00\x00\x00\xf0?" +
+		"\x00\x00\x00\x00\x00\x00\x00\x00")
+	tree3 := &Tree{}
+	err = tree3.UnmarshalBinary(v1)
+	c.Assert(err, Equals, nil)
+	c.Check(tree3.Info, IsNil)
+	c.Check([]float64(tree3.Hist), DeepEquals, []float64{1, 0})
+}
+
 func (*Tests) TestFuzzerCrash1(c *C) {
 	data := []byte("JVCT\x01\xb4\x99ѿ\x02\x01\x01\xd1\xea\xf6\x18\xfd?\x00d" +
 		"\x00")
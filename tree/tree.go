@@ -19,10 +19,13 @@ package tree
 import (
 	"fmt"
 	"math"
+	"runtime"
 	"strings"
+	"sync"
 
 	"seehuhn.de/go/classification"
 	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
 )
 
 // To prevent excessive memory use, the number of columns is limited
@@ -52,6 +55,11 @@ type Tree struct {
 	// `Limit`, the value corresponds to the left subtree, and
 	// otherwise to the right subtree.
 	Limit float64
+
+	// Info, if non-nil, describes the training data of the tree.
+	// Factories set this field for the root node of the trees they
+	// construct; it is nil for all other nodes.
+	Info *classification.Info
 }
 
 func (t *Tree) doFormat(indent int) []string {
@@ -98,6 +106,19 @@ func (t *Tree) NumClasses() int {
 	return t.LeftChild.NumClasses()
 }
 
+// GetInfo returns the metadata of the tree `t`, or nil if this is not
+// known.  This implements the classification.Metadata interface.
+func (t *Tree) GetInfo() *classification.Info {
+	return t.Info
+}
+
+// checkInput panics if `x` does not have the expected length.
+func (t *Tree) checkInput(x []float64) {
+	if err := t.Info.CheckInput(x); err != nil {
+		panic(err)
+	}
+}
+
 // IsLeaf returns true if `t` is a terminal node and returns false if
 // `t` has child nodes.
 func (t *Tree) IsLeaf() bool {
@@ -119,17 +140,65 @@ func (t *Tree) lookup(x []float64) *Tree {
 // GetClassCounts returns the class counts for input `x`, as seen in
 // the training data.
 func (t *Tree) GetClassCounts(x []float64) data.Histogram {
+	t.checkInput(x)
 	return t.lookup(x).Hist
 }
 
 // EstimateClassProbabilities returns the estimated class
 // probabilities for input `x`.
 func (t *Tree) EstimateClassProbabilities(x []float64) data.Histogram {
+	t.checkInput(x)
 	return t.lookup(x).Hist.Probabilities()
 }
 
+// EstimateClassProbabilitiesBatch stores the estimated class
+// probabilities for row i of `x` in row i of `prob`.  Blocks of rows
+// are processed in parallel.  This implements the
+// classification.BatchClassifier interface.
+func (t *Tree) EstimateClassProbabilitiesBatch(x, prob *matrix.Float64) {
+	n, _ := x.Shape()
+	m, k := prob.Shape()
+	if m != n || k != len(t.Hist) {
+		panic("wrong shape for the result matrix")
+	}
+	if err := classification.CheckInputs(t, x); err != nil {
+		panic(err)
+	}
+
+	numWorkers := runtime.NumCPU()
+	blockSize := (n + numWorkers - 1) / numWorkers
+	if blockSize < minBatchBlock {
+		blockSize = minBatchBlock
+	}
+	wg := &sync.WaitGroup{}
+	for start := 0; start < n; start += blockSize {
+		end := start + blockSize
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			for i := start; i < end; i++ {
+				hist := t.lookup(x.Row(i)).Hist
+				total := hist.Sum()
+				row := prob.Row(i)
+				for j, nj := range hist {
+					row[j] = nj / total
+				}
+			}
+			wg.Done()
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// minBatchBlock is the minimal number of rows processed by one
+// goroutine in EstimateClassProbabilitiesBatch.
+const minBatchBlock = 256
+
 // GuessClass tries to guess the class corresponding to input `x`.
 func (t *Tree) GuessClass(x []float64) int {
+	t.checkInput(x)
 	return t.lookup(x).Hist.ArgMax()
 }
 
@@ -157,10 +226,18 @@ func (t *Tree) foreachLeafRecursive(depth int, fn func(data.Histogram, int)) {
 // values, negative infinities in `a` or positive infinities in `b`
 // indicate unconstrained coordinates), the class counts for the
 // samples corresponding to the node, as well as the depth of the node
-// in the tree.
+// in the tree.  The slices `a` and `b` have one entry per input
+// variable; if the number of inputs is not known, the largest column
+// used by any split determines their length.
 func (t *Tree) ForeachLeafRegion(
 	fn func(a, b []float64, hist data.Histogram, depth int)) {
-	p := t.NumClasses()
+	p := 0
+	if t.Info != nil {
+		p = t.Info.NumInputs
+	}
+	if p == 0 {
+		p = t.maxColumn() + 1
+	}
 	a := make([]float64, p)
 	b := make([]float64, p)
 	for i := 0; i < p; i++ {
@@ -170,6 +247,22 @@ func (t *Tree) ForeachLeafRegion(
 	t.foreachLeafRegionRecursive(a, b, 0, fn)
 }
 
+// maxColumn returns the largest column index used by any split in
+// the tree `t`, or -1 if `t` is a leaf.
+func (t *Tree) maxColumn() int {
+	if t.IsLeaf() {
+		return -1
+	}
+	res := t.Column
+	if c := t.LeftChild.maxColumn(); c > res {
+		res = c
+	}
+	if c := t.RightChild.maxColumn(); c > res {
+		res = c
+	}
+	return res
+}
+
 func (t *Tree) foreachLeafRegionRecursive(a, b []float64, depth int,
 	fn func(a, b []float64, hist data.Histogram, depth int)) {
 	if t.IsLeaf() {
@@ -201,6 +294,10 @@ func FromData(data *data.Data) classification.Classifier {
 	return DefaultFactory.FromData(data)
 }
 
-func TreeFromData(data *data.Data) (*Tree, float64) {
+// TreeFromData constructs a new classification tree from training
+// data, using the parameters from DefaultFactory.  The returned values
+// are the new tree, an estimate of the expected loss, and an error
+// for invalid training data.
+func TreeFromData(data *data.Data) (*Tree, float64, error) {
 	return DefaultFactory.TreeFromData(data)
 }
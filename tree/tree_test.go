@@ -3,6 +3,10 @@ package tree
 import (
 	"testing"
 
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/matrix"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -29,3 +33,61 @@ func (*Tests) TestClasses(c *C) {
 	}
 	c.Check(tree.NumClasses(), Equals, 3)
 }
+
+func (*Tests) TestBatch(c *C) {
+	tree := &Tree{
+		Hist:   []float64{3, 3},
+		Column: 0,
+		Limit:  0.5,
+		LeftChild: &Tree{
+			Hist: []float64{3, 1},
+		},
+		RightChild: &Tree{
+			Hist: []float64{0, 2},
+		},
+	}
+	n := 1000
+	x := matrix.NewFloat64(n, 1, 0, nil)
+	for i := 0; i < n; i++ {
+		x.Set(i, 0, float64(i%3)/2)
+	}
+	prob := matrix.NewFloat64(n, 2, 0, nil)
+	tree.EstimateClassProbabilitiesBatch(x, prob)
+	for i := 0; i < n; i++ {
+		c.Check(prob.Row(i), DeepEquals, []float64(tree.EstimateClassProbabilities(x.Row(i))))
+	}
+
+	c.Check(func() { tree.EstimateClassProbabilitiesBatch(x, matrix.NewFloat64(n, 3, 0, nil)) },
+		PanicMatches, "wrong shape.*")
+}
+
+func (*Tests) TestForeachLeafRegion(c *C) {
+	// Two classes but three inputs: the regions must have one
+	// coordinate per input.
+	tree := &Tree{
+		Hist:   []float64{1, 1},
+		Column: 2,
+		Limit:  0.5,
+		LeftChild: &Tree{
+			Hist: []float64{1, 0},
+		},
+		RightChild: &Tree{
+			Hist: []float64{0, 1},
+		},
+	}
+	var sizes []int
+	tree.ForeachLeafRegion(func(a, b []float64, _ data.Histogram, _ int) {
+		sizes = append(sizes, len(a), len(b))
+	})
+	c.Check(sizes, DeepEquals, []int{3, 3, 3, 3})
+
+	tree.Info = &classification.Info{NumClasses: 2, NumInputs: 5}
+	sizes = nil
+	tree.ForeachLeafRegion(func(a, b []float64, _ data.Histogram, _ int) {
+		sizes = append(sizes, len(a), len(b))
+	})
+	c.Check(sizes, DeepEquals, []int{5, 5, 5, 5})
+
+	c.Check(func() { tree.EstimateClassProbabilities([]float64{1, 2, 3}) },
+		PanicMatches, "input has 3 values, expected 5.*")
+}
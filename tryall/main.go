@@ -1,24 +1,42 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"os"
+	"os/signal"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"seehuhn.de/go/classification"
 	"seehuhn.de/go/classification/bagging"
+	"seehuhn.de/go/classification/baseline"
+	"seehuhn.de/go/classification/calibrate"
+	"seehuhn.de/go/classification/compare"
 	"seehuhn.de/go/classification/data"
 	"seehuhn.de/go/classification/forest"
+	"seehuhn.de/go/classification/gaussian"
+	"seehuhn.de/go/classification/gbm"
 	"seehuhn.de/go/classification/impurity"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/logit"
 	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/mlp"
+	"seehuhn.de/go/classification/stacking"
+	"seehuhn.de/go/classification/svm"
 	"seehuhn.de/go/classification/tree"
 	"seehuhn.de/go/classification/tree/stop"
+	"seehuhn.de/go/classification/tuning"
 )
 
 func formatVal(x, se float64, width, maxPrec int) string {
-	prec := int(math.Ceil(-math.Log10(1.96 * se)))
+	prec := maxPrec
+	if se > 0 {
+		prec = int(math.Ceil(-math.Log10(1.96 * se)))
+	}
 	if prec > maxPrec {
 		prec = maxPrec
 	}
@@ -62,13 +80,46 @@ func main() {
 		},
 		NumTrees: 1000,
 	}
+	boost1 := &gbm.Factory{
+		Rounds:         100,
+		ColumnFraction: 0.3,
+	}
+	forest3 := &forest.RandomForestFactory{
+		RandomTree: forest1.RandomTree,
+		NumTrees:   100,
+	}
+	stack1 := &stacking.Factory{
+		Base: []classification.Factory{
+			tree1,
+			forest3.New(),
+			&logit.Factory{L2: 1e-3},
+		},
+	}
+	knn1 := &tuning.Search{
+		Name:  "kNN, tuned K",
+		Space: tuning.Grid{"K": {1, 3, 5, 10, 20}},
+		Build: func(p tuning.Params) classification.Factory {
+			return &knn.Factory{K: p.Int("K")}
+		},
+	}
 	methods := []classification.Factory{
+		&baseline.Prior{},
 		tree1,
 		tree2,
+		&calibrate.Factory{Base: tree1, Method: calibrate.Isotonic},
 		bagging.New(tree1, 4, 0),
 		bagging.New(tree1, 16, 0),
 		forest1.New(),
 		forest2.New(),
+		boost1,
+		&knn.Factory{K: 5},
+		knn1,
+		&gaussian.NaiveBayes{},
+		&gaussian.LDA{},
+		&gaussian.QDA{Shrinkage: 0.1},
+		&svm.Factory{},
+		&mlp.Factory{},
+		stack1,
 	}
 
 	testCases := []data.Set{
@@ -78,15 +129,24 @@ func main() {
 		data.NewNormals(3.0, 10000, 10000),
 	}
 
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	status := &statusLine{}
+	ctx = classification.WithProgress(ctx, status)
+	go status.run(ctx)
+
 	rows := make(chan *row, 8)
 	go func() {
 		for _, sample := range testCases {
+			if ctx.Err() != nil {
+				break
+			}
 			r := row{
 				name:   sample.GetName(),
 				values: make([]<-chan *classification.Result, len(methods)),
 			}
 			for i, method := range methods {
-				r.values[i] = XAssess(method, sample, loss.ZeroOne)
+				r.values[i] = XAssess(ctx, method, sample, loss.ZeroOne)
 			}
 			rows <- &r
 		}
@@ -120,11 +180,21 @@ func main() {
 	methodTrainingTime := make([]time.Duration, len(methods))
 	methodTestTime := make([]time.Duration, len(methods))
 	for row := range rows {
+		// wait for all results before printing, so that the output
+		// is not mixed up with the status line
+		values := make([]*classification.Result, len(row.values))
+		for i, c := range row.values {
+			values[i] = <-c
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		status.clear()
+
 		var rowTrainingTime, rowTestTime time.Duration
 		fmt.Printf("%-*s", sampleNameLength, row.name)
 		var errors []string
-		for i, c := range row.values {
-			value := <-c
+		for i, value := range values {
 			if value.Err != nil {
 				fmt.Print("| ERROR" + strings.Repeat(" ", colWidth-7))
 				errors = append(errors, value.Err.Error())
@@ -139,10 +209,18 @@ func main() {
 		}
 		fmt.Printf("      %6.1f  %6.1f\n",
 			rowTrainingTime.Seconds(), rowTestTime.Seconds())
+		printExcess(values, sampleNameLength, colWidth, maxPrec)
+		printBest(values, loss.ZeroOne)
 		for _, msg := range errors {
 			fmt.Println("  " + msg)
 		}
 	}
+	if ctx.Err() != nil {
+		status.clear()
+		fmt.Println()
+		fmt.Println("interrupted")
+		return
+	}
 	fmt.Println()
 	for i := range methods {
 		fmt.Printf("%s = %6.1f  %6.1f\n", string([]byte{'A' + byte(i)}),
@@ -151,13 +229,67 @@ func main() {
 	fmt.Println()
 }
 
+// printExcess prints the excess loss over the Bayes classifier, if
+// the Bayes loss for the data set is known.
+func printExcess(values []*classification.Result, nameLength, colWidth, maxPrec int) {
+	bayesLoss := math.NaN()
+	for _, value := range values {
+		if value.Err == nil && value.HasBayes {
+			bayesLoss = value.BayesLoss
+			break
+		}
+	}
+	if math.IsNaN(bayesLoss) {
+		return
+	}
+
+	fmt.Printf("%-*s", nameLength, fmt.Sprintf("  excess (Bayes %.4f)", bayesLoss))
+	for _, value := range values {
+		if value.Err != nil || !value.HasBayes {
+			fmt.Print("|" + strings.Repeat(" ", colWidth-1))
+			continue
+		}
+		fmt.Print("| " + formatVal(value.ExcessLoss, value.ExcessStdErr,
+			colWidth-2, maxPrec))
+	}
+	fmt.Println()
+}
+
+// printBest compares the two methods with the smallest mean loss,
+// using paired tests on the test data.
+func printBest(values []*classification.Result, L loss.Function) {
+	first, second := -1, -1
+	for i, value := range values {
+		if value.Err != nil {
+			continue
+		}
+		switch {
+		case first < 0 || value.MeanLoss < values[first].MeanLoss:
+			first, second = i, first
+		case second < 0 || value.MeanLoss < values[second].MeanLoss:
+			second = i
+		}
+	}
+	if second < 0 {
+		return
+	}
+
+	cmp := compare.FromPredictions(values[first].Predictions,
+		values[second].Predictions, L)
+	if cmp.Err != nil {
+		return
+	}
+	fmt.Printf("  %c vs. %c: McNemar p = %.3g, Wilcoxon p = %.3g\n",
+		'A'+first, 'A'+second, cmp.McNemar.PValue, cmp.Wilcoxon.PValue)
+}
+
 var queue chan int
 
-func XAssess(cf classification.Factory, samples data.Set, L loss.Function) <-chan *classification.Result {
+func XAssess(ctx context.Context, cf classification.Factory, samples data.Set, L loss.Function) <-chan *classification.Result {
 	worker := <-queue
 	resChan := make(chan *classification.Result, 1)
 	go func() {
-		res := classification.Assess(cf, samples, L)
+		res := classification.AssessContext(ctx, cf, samples, L)
 		resChan <- res
 		queue <- worker
 	}()
@@ -171,3 +303,38 @@ func init() {
 		queue <- i
 	}
 }
+
+// statusLine shows the training progress on stderr.
+type statusLine struct {
+	nodes, trees, folds int64
+}
+
+func (s *statusLine) Report(e classification.ProgressEvent) {
+	switch e.Kind {
+	case classification.NodeGrown:
+		atomic.AddInt64(&s.nodes, 1)
+	case classification.TreeDone:
+		atomic.AddInt64(&s.trees, 1)
+	case classification.FoldDone:
+		atomic.AddInt64(&s.folds, 1)
+	}
+}
+
+func (s *statusLine) run(ctx context.Context) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fmt.Fprintf(os.Stderr, "\r[%d nodes, %d trees, %d folds] ",
+				atomic.LoadInt64(&s.nodes), atomic.LoadInt64(&s.trees),
+				atomic.LoadInt64(&s.folds))
+		}
+	}
+}
+
+func (s *statusLine) clear() {
+	fmt.Fprint(os.Stderr, "\r"+strings.Repeat(" ", 50)+"\r")
+}
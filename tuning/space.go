@@ -0,0 +1,162 @@
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Params gives the values of the hyperparameters for one candidate
+// factory.  Integer and categorical parameters are also represented
+// as float64 values; categorical parameters can be encoded as an
+// index into a list of choices.
+type Params map[string]float64
+
+// Int returns the value of parameter `name`, rounded to the nearest
+// integer.
+func (p Params) Int(name string) int {
+	return int(math.Round(p[name]))
+}
+
+// String formats the parameters, sorted by name.
+func (p Params) String() string {
+	var parts []string
+	for _, name := range sortedKeys(p) {
+		parts = append(parts, fmt.Sprintf("%s=%g", name, p[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys(m interface{}) []string {
+	var res []string
+	switch m := m.(type) {
+	case Params:
+		for name := range m {
+			res = append(res, name)
+		}
+	case Grid:
+		for name := range m {
+			res = append(res, name)
+		}
+	case map[string]Distribution:
+		for name := range m {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Space describes the set of candidate parameters to consider.
+type Space interface {
+	// Candidates returns the list of candidate parameters.
+	Candidates() []Params
+}
+
+// Grid specifies a list of values for every parameter.  The candidates
+// are all combinations of these values.
+type Grid map[string][]float64
+
+// Candidates implements the Space interface.  Parameters are varied
+// in alphabetical order, where the last parameter varies fastest.
+func (g Grid) Candidates() []Params {
+	names := sortedKeys(g)
+	res := []Params{{}}
+	for _, name := range names {
+		var next []Params
+		for _, p := range res {
+			for _, val := range g[name] {
+				q := make(Params, len(p)+1)
+				for k, v := range p {
+					q[k] = v
+				}
+				q[name] = val
+				next = append(next, q)
+			}
+		}
+		res = next
+	}
+	return res
+}
+
+// Random specifies a random search, where `N` candidates are drawn
+// independently from the given distributions.
+type Random struct {
+	// Dims gives the distribution of every parameter.
+	Dims map[string]Distribution
+
+	// N gives the number of candidates.
+	N int
+
+	// Seed is used to initialise the random number generator.
+	Seed int64
+}
+
+// Candidates implements the Space interface.
+func (r *Random) Candidates() []Params {
+	seed := r.Seed
+	if seed == 0 {
+		seed = tuningSeed
+	}
+	rng := rand.New(rand.NewSource(seed))
+	names := sortedKeys(r.Dims)
+	res := make([]Params, r.N)
+	for i := range res {
+		p := make(Params, len(names))
+		for _, name := range names {
+			p[name] = r.Dims[name].Sample(rng)
+		}
+		res[i] = p
+	}
+	return res
+}
+
+// Distribution is the distribution of a parameter in a random search.
+type Distribution interface {
+	Sample(rng *rand.Rand) float64
+}
+
+// Uniform is the uniform distribution on the interval [Min, Max).
+type Uniform struct {
+	Min, Max float64
+}
+
+// Sample implements the Distribution interface.
+func (u Uniform) Sample(rng *rand.Rand) float64 {
+	return u.Min + (u.Max-u.Min)*rng.Float64()
+}
+
+// LogUniform is the distribution on the interval [Min, Max) where the
+// logarithm of the value is uniformly distributed.  This is useful
+// for parameters like learning rates or regularisation constants.
+type LogUniform struct {
+	Min, Max float64
+}
+
+// Sample implements the Distribution interface.
+func (u LogUniform) Sample(rng *rand.Rand) float64 {
+	a := math.Log(u.Min)
+	b := math.Log(u.Max)
+	return math.Exp(a + (b-a)*rng.Float64())
+}
+
+// IntUniform is the uniform distribution on the integers Min, ...,
+// Max.
+type IntUniform struct {
+	Min, Max int
+}
+
+// Sample implements the Distribution interface.
+func (u IntUniform) Sample(rng *rand.Rand) float64 {
+	return float64(u.Min + rng.Intn(u.Max-u.Min+1))
+}
+
+// Choice is the uniform distribution on the given values.
+type Choice []float64
+
+// Sample implements the Distribution interface.
+func (c Choice) Sample(rng *rand.Rand) float64 {
+	return c[rng.Intn(len(c))]
+}
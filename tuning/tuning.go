@@ -0,0 +1,197 @@
+// Package tuning implements hyperparameter search for classification
+// methods.
+//
+// A Search combines a parameter Space with a function which maps
+// parameters to a classification.Factory.  Every candidate is assessed
+// by cross-validation, using identical splits for all candidates, and
+// the candidate with the smallest mean loss is selected.  Since Search
+// itself implements the classification.Factory interface, the error
+// of the complete tuning procedure can be estimated by nested
+// cross-validation, using Search.Nested.
+package tuning
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/resampling"
+)
+
+const tuningSeed = 1411208356
+
+// Search describes a hyperparameter search.  Any zero field values,
+// other than `Space` and `Build`, are replaced by default values.
+type Search struct {
+	// Name gives a short, human-readable description of the tuned
+	// method.
+	Name string
+
+	// Space gives the candidate parameters.
+	Space Space
+
+	// Build constructs the factory for the given parameters.  Build
+	// is called concurrently from several goroutines, so it must be
+	// safe for concurrent use.
+	Build func(p Params) classification.Factory
+
+	// Loss is the loss function used to compare candidates.  The
+	// default is zero-one loss.
+	Loss loss.Function
+
+	// Resampling gives the training/test splits used to assess every
+	// candidate.  The default is 5-fold cross-validation.
+	Resampling resampling.Scheme
+
+	// Workers gives the number of candidates assessed in parallel.
+	// If this is zero or negative, runtime.GOMAXPROCS(0) is used.
+	Workers int
+}
+
+func (s *Search) setDefaults() *Search {
+	res := *s // make a copy
+	if res.Loss == nil {
+		res.Loss = loss.ZeroOne
+	}
+	if res.Resampling == nil {
+		res.Resampling = &resampling.KFold{K: 5, Seed: tuningSeed}
+	}
+	if res.Workers < 1 {
+		res.Workers = runtime.GOMAXPROCS(0)
+	}
+	return &res
+}
+
+// GetName returns a human-readable name for the search.
+func (s *Search) GetName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	candidates := s.Space.Candidates()
+	if len(candidates) == 0 {
+		return "tuned (no candidates)"
+	}
+	return "tuned " + s.Build(candidates[0]).GetName()
+}
+
+// Candidate describes the assessment of one set of parameters.
+type Candidate struct {
+	Params  Params
+	Name    string
+	Result  *classification.Result
+	Factory classification.Factory
+}
+
+// Results lists the outcome of a search.
+type Results struct {
+	// Candidates lists all candidates, in the order given by the
+	// parameter space.
+	Candidates []*Candidate
+
+	// Best is the index of the candidate with the smallest mean loss.
+	Best int
+}
+
+// BestFactory returns the factory with the smallest mean loss.
+func (r *Results) BestFactory() classification.Factory {
+	return r.Candidates[r.Best].Factory
+}
+
+// BestParams returns the parameters with the smallest mean loss.
+func (r *Results) BestParams() Params {
+	return r.Candidates[r.Best].Params
+}
+
+// String formats the results as a table, sorted by mean loss.
+func (r *Results) String() string {
+	idx := make([]int, len(r.Candidates))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return r.Candidates[idx[i]].Result.MeanLoss < r.Candidates[idx[j]].Result.MeanLoss
+	})
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%-10s %-10s  %s\n", "loss", "std.err.", "parameters")
+	for _, i := range idx {
+		cand := r.Candidates[i]
+		mark := ""
+		if i == r.Best {
+			mark = " *"
+		}
+		fmt.Fprintf(b, "%-10.6f %-10.6f  %s%s\n",
+			cand.Result.MeanLoss, cand.Result.StdErr, cand.Params, mark)
+	}
+	return b.String()
+}
+
+// Run assesses all candidates on the data `d`, in parallel.
+func (s *Search) Run(d *data.Data) (*Results, error) {
+	s = s.setDefaults()
+	params := s.Space.Candidates()
+	if len(params) == 0 {
+		return nil, errors.New("no candidate parameters")
+	}
+
+	res := &Results{
+		Candidates: make([]*Candidate, len(params)),
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := s.Build(params[i])
+				res.Candidates[i] = &Candidate{
+					Params:  params[i],
+					Name:    f.GetName(),
+					Factory: f,
+					Result:  classification.AssessResampled(f, d, s.Loss, s.Resampling),
+				}
+			}
+		}()
+	}
+	for i := range params {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	res.Best = -1
+	for i, cand := range res.Candidates {
+		if cand.Result.Err != nil {
+			return nil, cand.Result.Err
+		}
+		if res.Best < 0 || cand.Result.MeanLoss < res.Candidates[res.Best].Result.MeanLoss {
+			res.Best = i
+		}
+	}
+	return res, nil
+}
+
+// FromData runs the search on the data `d` and trains the best
+// candidate on the full data.  The method panics if the search fails.
+func (s *Search) FromData(d *data.Data) classification.Classifier {
+	res, err := s.Run(d)
+	if err != nil {
+		panic(err)
+	}
+	return res.BestFactory().FromData(d)
+}
+
+// Nested estimates the expected loss of the complete tuning procedure,
+// including the selection of parameters, using the training/test
+// splits given by `outer`.  For every outer split, the search is run
+// on the outer training data only.
+func (s *Search) Nested(d *data.Data, outer resampling.Scheme) *classification.Result {
+	return classification.AssessResampled(s, d, s.setDefaults().Loss, outer)
+}
@@ -0,0 +1,114 @@
+package tuning
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"seehuhn.de/go/classification"
+	"seehuhn.de/go/classification/data"
+	"seehuhn.de/go/classification/knn"
+	"seehuhn.de/go/classification/loss"
+	"seehuhn.de/go/classification/resampling"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type Tests struct{}
+
+var _ = Suite(&Tests{})
+
+func (*Tests) TestGrid(c *C) {
+	g := Grid{
+		"b": {1, 2, 3},
+		"a": {10, 20},
+	}
+	cand := g.Candidates()
+	c.Assert(cand, HasLen, 6)
+	c.Check(cand[0], DeepEquals, Params{"a": 10, "b": 1})
+	c.Check(cand[1], DeepEquals, Params{"a": 10, "b": 2})
+	c.Check(cand[5], DeepEquals, Params{"a": 20, "b": 3})
+	c.Check(cand[5].String(), Equals, "a=20 b=3")
+	c.Check(Params{"K": 2.9999}.Int("K"), Equals, 3)
+}
+
+func (*Tests) TestRandom(c *C) {
+	r := &Random{
+		Dims: map[string]Distribution{
+			"x": Uniform{-1, 1},
+			"y": LogUniform{1e-3, 1},
+			"k": IntUniform{2, 4},
+			"c": Choice{5, 7},
+		},
+		N: 100,
+	}
+	cand := r.Candidates()
+	c.Assert(cand, HasLen, 100)
+	for _, p := range cand {
+		c.Check(p["x"] >= -1 && p["x"] < 1, Equals, true)
+		c.Check(p["y"] >= 1e-3 && p["y"] < 1, Equals, true)
+		k := p["k"]
+		c.Check(k == 2 || k == 3 || k == 4, Equals, true)
+		c.Check(p["c"] == 5 || p["c"] == 7, Equals, true)
+	}
+	c.Check(r.Candidates(), DeepEquals, cand)
+}
+
+func knnSearch() *Search {
+	return &Search{
+		Space: Grid{"K": {1, 25}},
+		Build: func(p Params) classification.Factory {
+			return &knn.Factory{K: p.Int("K")}
+		},
+		Loss: loss.ZeroOne,
+	}
+}
+
+func (*Tests) TestSearch(c *C) {
+	set := data.NewNormals(1.0, 500, 0)
+	d, _ := set.TrainingData()
+
+	s := knnSearch()
+	res, err := s.Run(d)
+	c.Assert(err, IsNil)
+	c.Assert(res.Candidates, HasLen, 2)
+	// For overlapping classes, averaging over many neighbours is
+	// better than using the nearest neighbour only.
+	c.Check(res.BestParams(), DeepEquals, Params{"K": 25})
+	c.Check(res.BestFactory().GetName(), Equals, res.Candidates[1].Name)
+	c.Check(strings.Contains(res.String(), "K=25 *"), Equals, true)
+
+	// Run is deterministic, since all candidates use the same splits.
+	res2, err := s.Run(d)
+	c.Assert(err, IsNil)
+	for i, cand := range res.Candidates {
+		c.Check(res2.Candidates[i].Result.MeanLoss, Equals, cand.Result.MeanLoss)
+	}
+
+	cf := s.FromData(d)
+	p := cf.EstimateClassProbabilities([]float64{0})
+	c.Check(math.Abs(p.Sum()-1) < 1e-12, Equals, true)
+
+	// Negative worker counts use the default.
+	s.Workers = -1
+	res3, err := s.Run(d)
+	c.Assert(err, IsNil)
+	c.Check(res3.BestParams(), DeepEquals, res.BestParams())
+}
+
+func (*Tests) TestNested(c *C) {
+	set := data.NewNormals(1.0, 300, 0)
+	d, _ := set.TrainingData()
+
+	s := knnSearch()
+	res := s.Nested(d, &resampling.KFold{K: 3})
+	c.Assert(res.Err, IsNil)
+	c.Check(res.Predictions.Len(), Equals, 300)
+	c.Check(res.MeanLoss > 0 && res.MeanLoss < 0.5, Equals, true)
+	c.Check(strings.HasPrefix(s.GetName(), "tuned "), Equals, true)
+
+	_, err := (&Search{Space: Grid{"K": {}}, Build: s.Build}).Run(d)
+	c.Check(err, NotNil)
+}